@@ -100,6 +100,7 @@ juno_executables="\
 	github.com/paypal/junodb/cmd/proxy \
 	github.com/paypal/junodb/cmd/storageserv \
 	github.com/paypal/junodb/cmd/storageserv/storage/db/dbcopy \
+	github.com/paypal/junodb/cmd/storageserv/storage/db/nsdump \
 	github.com/paypal/junodb/cmd/tools/junocli \
 	github.com/paypal/junodb/cmd/clustermgr \
 	github.com/paypal/junodb/cmd/dbscanserv \
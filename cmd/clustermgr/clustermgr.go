@@ -26,9 +26,11 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
+	"github.com/paypal/junodb/pkg/etcd"
 	"github.com/paypal/junodb/pkg/version"
 
 	"github.com/paypal/junodb/cmd/clustermgr/cmd"
@@ -49,6 +51,10 @@ var (
 	flagVersion     bool
 	flagRateLimit   int
 	flagAMarkdown   bool
+	flagTarget      string
+	flagAddr        string
+	flagNamespaces  string
+	flagSSL         bool
 )
 
 func main() {
@@ -59,7 +65,7 @@ func main() {
 	flag.StringVar(&flagConfigNew, "new_config", "", "new configfile")
 	flag.BoolVar(&flagDryrun, "dryrun", false, "dry run -- do not save to etcd")
 	flag.BoolVar(&flagVerbose, "verbose", false, "verbose -- print more info")
-	flag.StringVar(&flagCmd, "cmd", "", "command -- store, redist, redistserv, zonemarkdown")
+	flag.StringVar(&flagCmd, "cmd", "", "command -- store, redist, redistserv, zonemarkdown, bootstrap")
 	flag.StringVar(&flagType, "type", "cluster_info", "type -- cluster_info, auto, abort")
 	flag.IntVar(&flagZoneid, "zone", -1, "specify zone id")
 	flag.IntVar(&flagSkipZone, "skipzone", -1, "specify zone id to skip")
@@ -69,6 +75,10 @@ func main() {
 	flag.BoolVar(&flagVersion, "version", false, "display version information.")
 	flag.IntVar(&flagRateLimit, "ratelimit", 0, "rate limit for redistribution in KB, 0 means not set")
 	flag.BoolVar(&flagAMarkdown, "automarkdown", true, "mark down during redistribution")
+	flag.StringVar(&flagTarget, "target", "", "replication target name to bootstrap")
+	flag.StringVar(&flagAddr, "addr", "", "address of the replication target to bootstrap")
+	flag.StringVar(&flagNamespaces, "ns", "", "comma separated namespaces to bootstrap, all if not set")
+	flag.BoolVar(&flagSSL, "ssl", false, "connect to the replication target with SSL")
 
 	flag.Parse()
 
@@ -137,6 +147,21 @@ func main() {
 		cmd.RestoreCache(flagConfig, flagCache, flagDryrun)
 	} else if flagCmd == "zonemarkdown" {
 		cmd.ZoneMarkDown(flagConfig, flagType, flagZoneid)
+	} else if flagCmd == "bootstrap" {
+		spec := etcd.RepBootstrapSpec{
+			Target:     flagTarget,
+			Addr:       flagAddr,
+			SSLEnabled: flagSSL,
+			Zone:       flagZoneid,
+			RateLimit:  flagRateLimit,
+		}
+		if spec.Zone < 0 {
+			spec.Zone = 0
+		}
+		if len(flagNamespaces) != 0 {
+			spec.Namespaces = strings.Split(flagNamespaces, etcd.TagRepBootstrapNsDelimiter)
+		}
+		cmd.RepBootstrap(flagConfig, flagType, spec)
 	} else {
 		printUsage()
 		return
@@ -181,4 +206,9 @@ func printUsage() {
 	fmt.Printf("Dump redist commit to stdout:    ./%s --new_config redist.toml --cmd redist --type commit --dryrun\n", progName)
 	fmt.Printf("Dump redist resume: ./%s --new_config redist.toml --cmd redist --type resume --zone [n] --ratelimit 10000 (optional, in kb)\n", progName)
 	fmt.Printf("Zone markdown:    ./%s --config config.toml --cmd zonemarkdown --type set/get/delete --zone [n] (--zone -1 disables markdwon)\n", progName)
+
+	fmt.Printf("\n4) USAGE: ./%s --config [configfile] --cmd [bootstrap] --type [begin|status|finish|abort]\n\n", progName)
+	fmt.Printf("Bootstrap a replication target:  ./%s --config config.toml --cmd bootstrap --type begin --target [name] --addr [host:port] --zone [n] --ns [ns1,ns2] --ratelimit 10000 (optional, in kb)\n", progName)
+	fmt.Printf("Bootstrap progress:              ./%s --config config.toml --cmd bootstrap --type status\n", progName)
+	fmt.Printf("Switch over to live replication: ./%s --config config.toml --cmd bootstrap --type finish\n", progName)
 }
@@ -0,0 +1,134 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/pkg/cluster"
+	"github.com/paypal/junodb/pkg/etcd"
+	redistst "github.com/paypal/junodb/pkg/stats/redist"
+)
+
+// RepBootstrap drives the bootstrap of a new replication target.
+//
+//	begin:  proxies start holding the replication to the target in their
+//	        spill logs, the storage nodes of the zone stream their snapshot.
+//	status: show the progress of each storage node.
+//	finish: once all the nodes are done, proxies replay the held requests.
+//	abort:  stop the snapshot, proxies replay the held requests.
+func RepBootstrap(configFile string, flagType string, spec etcd.RepBootstrapSpec) {
+	LoadConfig(configFile)
+
+	etcdcli := etcd.NewEtcdClient(&cfg.Etcd, cfg.ClusterName)
+	if etcdcli == nil {
+		glog.Exit("[ERROR] failed to connect to etcd server")
+	}
+	defer etcdcli.Close()
+
+	maxretry := 2
+	cur, _ := etcd.ParseRepBootstrapSpec(getValue(etcdcli, etcd.TagRepBootstrap))
+
+	switch flagType {
+	case etcd.TagRepBootstrapBegin:
+		if cur != nil && cur.State == etcd.TagRepBootstrapBegin && cur.Target != spec.Target {
+			glog.Exitf("[ERROR] bootstrap of target %s is in progress", cur.Target)
+		}
+		spec.State = etcd.TagRepBootstrapBegin
+		if _, err := etcd.ParseRepBootstrapSpec(spec.String()); err != nil {
+			glog.Exitf("[ERROR] %s", err.Error())
+		}
+		etcdcli.DeleteKeyWithPrefix(etcd.TagRepBootstrapStatePrefix)
+		if err := etcdcli.PutValue(etcd.TagRepBootstrap, spec.String(), maxretry); err != nil {
+			glog.Exit("[ERROR] bootstrap begin failed")
+		}
+		glog.Infof("bootstrap begin: %s", spec.String())
+
+	case etcd.TagRepBootstrapFinish, etcd.TagRepBootstrapAbort:
+		if cur == nil || cur.State != etcd.TagRepBootstrapBegin {
+			glog.Exit("[ERROR] no bootstrap in progress")
+		}
+		if flagType == etcd.TagRepBootstrapFinish {
+			if done, total := bootstrapProgress(etcdcli, cur); done != total {
+				glog.Exitf("[ERROR] snapshot not finished on %d of %d nodes", total-done, total)
+			}
+		}
+		end := etcd.RepBootstrapSpec{State: flagType, Target: cur.Target}
+		if err := etcdcli.PutValue(etcd.TagRepBootstrap, end.String(), maxretry); err != nil {
+			glog.Exitf("[ERROR] bootstrap %s failed", flagType)
+		}
+		glog.Infof("bootstrap %s: target %s", flagType, cur.Target)
+
+	default:
+		if cur == nil {
+			fmt.Println("no bootstrap")
+			return
+		}
+		fmt.Println(cur.String())
+		if cur.State == etcd.TagRepBootstrapBegin {
+			done, total := bootstrapProgress(etcdcli, cur)
+			fmt.Printf("%d of %d nodes finished\n", done, total)
+		}
+	}
+}
+
+func getValue(etcdcli *etcd.EtcdClient, key string) string {
+	value, err := etcdcli.GetValue(key)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// bootstrapProgress prints the state of each storage node in the source zone
+// and returns the number of nodes that have sent their snapshot.
+func bootstrapProgress(etcdcli *etcd.EtcdClient, spec *etcd.RepBootstrapSpec) (done int, total int) {
+	var c cluster.Cluster
+	if _, err := c.Read(etcd.NewEtcdReadWriter(etcdcli)); err != nil {
+		glog.Exitf("[ERROR] cannot read cluster info: %s", err.Error())
+	}
+	if spec.Zone < 0 || spec.Zone >= int(c.NumZones) {
+		glog.Exitf("[ERROR] bad zone %d", spec.Zone)
+	}
+	states, err := etcdcli.GetRepBootstrapStates()
+	if err != nil {
+		glog.Exitf("[ERROR] cannot read bootstrap states: %s", err.Error())
+	}
+
+	total = len(c.Zones[spec.Zone].Nodes)
+	keys := make([]string, 0, total)
+	for n := 0; n < total; n++ {
+		key := etcd.KeyRepBootstrapState(spec.Zone, n)
+		keys = append(keys, key)
+		st := redistst.NewKVPairs(states[key])
+		if st.GetValue(etcd.TagRepBootstrapTarget, "") == spec.Target &&
+			st.GetValue(string(redistst.StatsTagStatus), "") == redistst.StatsFinish {
+			done++
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("%s: %s\n", key, states[key])
+	}
+	return
+}
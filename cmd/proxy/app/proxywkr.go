@@ -30,9 +30,11 @@ import (
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
+	"github.com/paypal/junodb/cmd/proxy/audit"
 	"github.com/paypal/junodb/cmd/proxy/config"
 	"github.com/paypal/junodb/cmd/proxy/handler"
 	"github.com/paypal/junodb/cmd/proxy/replication"
+	"github.com/paypal/junodb/cmd/proxy/shadow"
 	"github.com/paypal/junodb/cmd/proxy/stats"
 	"github.com/paypal/junodb/cmd/proxy/stats/shmstats"
 	"github.com/paypal/junodb/cmd/proxy/watcher"
@@ -121,7 +123,9 @@ func (c *Worker) Exec() {
 	}
 
 	initmgr.Register(sec.Initializer, &cfg.Sec, cfg.GetSecFlag())
-	initmgr.RegisterWithFuncs(replication.Initialize, replication.Finalize, &cfg.Replication)
+	initmgr.RegisterWithFuncs(replication.Initialize, replication.Finalize, &cfg.Replication, int(c.optWorkerId))
+	initmgr.RegisterWithFuncs(audit.Initialize, audit.Finalize, &cfg.Audit, int(c.optWorkerId))
+	initmgr.RegisterWithFuncs(shadow.Initialize, shadow.Finalize, &cfg.Shadow, cfg.StateLogDir, int(c.optWorkerId))
 	if cfg.EtcdEnabled {
 		initmgr.RegisterWithFuncs(watcher.Initialize, watcher.Finalize, cfg.ClusterName, etcd.GetEtcdCli(), &cfg.Etcd)
 	}
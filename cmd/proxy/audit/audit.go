@@ -0,0 +1,166 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package audit logs the writes to the audited namespaces. Each worker
+// appends the records as JSON lines to its own rotating files, and each
+// record carries the SHA-256 hash of the previous one, so that a record
+// altered, inserted or removed breaks the chain.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+type (
+	Record struct {
+		Time          time.Time
+		Worker        int
+		Seq           uint64
+		Op            string
+		Namespace     string
+		KeyHash       string // hex SHA-256 of the key
+		Key           string `json:",omitempty"`
+		AppName       string `json:",omitempty"`
+		SourceIP      string `json:",omitempty"`
+		SourcePort    uint16 `json:",omitempty"`
+		RequestId     string
+		CorrelationId string `json:",omitempty"`
+		Originator    string `json:",omitempty"` // request id of the originating write, if replicated
+		Replication   bool   `json:",omitempty"`
+		Version       uint32
+		Status        string
+		PrevHash      string
+	}
+)
+
+var (
+	namespaces map[string]config.AuditNamespaceConfig
+	chRecord   chan *Record
+	chFlushed  chan struct{}
+	workerId   int
+)
+
+// Initialize is the initializer registered with initmgr. It expects the
+// audit config and the worker id.
+func Initialize(args ...interface{}) (err error) {
+	if len(args) < 2 {
+		err = fmt.Errorf("audit config and worker id expected")
+		glog.Error(err)
+		return
+	}
+	conf, ok := args[0].(*config.AuditConfig)
+	if !ok {
+		err = fmt.Errorf("wrong argument type")
+		glog.Error(err)
+		return
+	}
+	wid, ok := args[1].(int)
+	if !ok {
+		err = fmt.Errorf("wrong argument type")
+		glog.Error(err)
+		return
+	}
+	err = Init(conf, wid)
+	return
+}
+
+// Finalize waits for the records logged so far to be written.
+func Finalize() {
+	if chRecord != nil {
+		chRecord <- nil
+		<-chFlushed
+	}
+}
+
+// Init opens the audit log of the worker if any namespace is audited. It is
+// to be called before any request is processed.
+func Init(conf *config.AuditConfig, wid int) (err error) {
+	if len(conf.Namespaces) == 0 {
+		glog.Info("audit log disabled")
+		return
+	}
+	w := newWriter(conf.LogDir, wid, conf.MaxFileSize, conf.MaxFiles)
+	if err = w.open(); err != nil {
+		glog.Errorf("failed to open audit log: %s", err.Error())
+		return
+	}
+	workerId = wid
+	chRecord = make(chan *Record, conf.QueueSize)
+	chFlushed = make(chan struct{})
+	namespaces = conf.Namespaces
+	go w.run(chRecord, chFlushed)
+	return
+}
+
+// IsAudited returns whether the opcode changes a record.
+func IsAudited(op proto.OpCode) bool {
+	switch op {
+	case proto.OpCodeCreate, proto.OpCodeUpdate, proto.OpCodeSet, proto.OpCodeDestroy, proto.OpCodeUDFSet, proto.OpCodeTouch:
+		return true
+	}
+	return false
+}
+
+// Log records the write of the request to an audited namespace, with the
+// version and the status replied to the client. It blocks if the records
+// are written slower than they come.
+func Log(req *proto.OperationalMessage, version uint32, status string) {
+	if chRecord == nil || !IsAudited(req.GetOpCode()) {
+		return
+	}
+	nsConf, ok := namespaces[string(req.GetNamespace())]
+	if !ok {
+		return
+	}
+	key := req.GetKey()
+	h := sha256.Sum256(key)
+	r := &Record{
+		Time:          time.Now(),
+		Worker:        workerId,
+		Op:            req.GetOpCodeText(),
+		Namespace:     string(req.GetNamespace()),
+		KeyHash:       hex.EncodeToString(h[:]),
+		AppName:       string(req.GetAppName()),
+		SourcePort:    req.GetSrcPort(),
+		RequestId:     req.GetRequestIDString(),
+		CorrelationId: string(req.GetCorrelationID()),
+		Replication:   req.IsForReplication(),
+		Version:       version,
+		Status:        status,
+	}
+	if nsConf.LogKey {
+		r.Key = util.ToPrintableString(key)
+	}
+	if ip := req.GetSrcIP(); ip != nil {
+		r.SourceIP = ip.String()
+	}
+	if req.IsOriginatorSet() {
+		r.Originator = req.GetOriginatorRequestIDString()
+	}
+	chRecord <- r
+}
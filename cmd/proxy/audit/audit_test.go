@@ -0,0 +1,232 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func verifyFiles(t *testing.T, files ...string) (n int, err error) {
+	var c Chain
+	for _, name := range files {
+		f, ferr := os.Open(name)
+		if ferr != nil {
+			t.Fatal(ferr)
+		}
+		var m int
+		m, err = Verify(f, &c)
+		f.Close()
+		n += m
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.AuditConfig{
+		LogDir:      dir,
+		MaxFileSize: 1024,
+		QueueSize:   8,
+		Namespaces:  map[string]config.AuditNamespaceConfig{"audited": {LogKey: true}},
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(&conf, 2); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { chRecord = nil }()
+
+	log := func(op proto.OpCode, ns string) {
+		var req proto.OperationalMessage
+		req.SetAsRequest()
+		req.SetOpCode(op)
+		req.SetNamespace([]byte(ns))
+		req.SetKey([]byte("key"))
+		req.SetSource(net.ParseIP("10.0.0.1"), 8080, []byte("app"))
+		req.SetNewRequestID()
+		Log(&req, 3, proto.OpStatusNoError.String())
+	}
+	for i := 0; i < 10; i++ {
+		log(proto.OpCodeSet, "audited")
+	}
+	log(proto.OpCodeGet, "audited")
+	log(proto.OpCodeDestroy, "other")
+	Finalize()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "audit.2.*.log"))
+	if len(files) == 0 {
+		t.Fatal("not rotated")
+	}
+	files = append(files, filepath.Join(dir, "audit.2.log"))
+	n, err := verifyFiles(t, files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("%d records, expected 10", n)
+	}
+
+	data, _ := os.ReadFile(files[len(files)-1])
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	var rec Record
+	if err = json.Unmarshal(lines[len(lines)-1], &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Seq != 10 || rec.Op != "Set" || rec.Key != "key" || rec.AppName != "app" ||
+		rec.SourceIP != "10.0.0.1" || rec.Version != 3 || rec.Worker != 2 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
+
+func TestResumeAndTamper(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "audit.0.log")
+	for i := 0; i < 2; i++ {
+		w := newWriter(dir, 0, 1<<20, 0)
+		if err := w.open(); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 3; j++ {
+			if err := w.write(&Record{Op: "Set", Namespace: "ns", RequestId: "rid"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.file.Close()
+	}
+	if n, err := verifyFiles(t, name); err != nil || n != 6 {
+		t.Fatalf("%d records verified, err=%v", n, err)
+	}
+
+	data, _ := os.ReadFile(name)
+	tampered := strings.Replace(string(data), `"Op":"Set"`, `"Op":"Del"`, 1)
+	os.WriteFile(name, []byte(tampered), 0644)
+	if _, err := verifyFiles(t, name); err == nil {
+		t.Error("altered record not detected")
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	removed := strings.Join(append(lines[:2:2], lines[3:]...), "")
+	os.WriteFile(name, []byte(removed), 0644)
+	if _, err := verifyFiles(t, name); err == nil {
+		t.Error("removed record not detected")
+	}
+}
+
+func TestResumeAfterTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "audit.0.log")
+	writeRecords := func(n int) {
+		w := newWriter(dir, 0, 1<<20, 0)
+		if err := w.open(); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < n; j++ {
+			if err := w.write(&Record{Op: "Set", Namespace: "ns", RequestId: "rid"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.file.Close()
+	}
+	writeRecords(3)
+	data, _ := os.ReadFile(name)
+	lines := strings.SplitAfter(string(data), "\n")
+	torn := lines[2][:len(lines[2])/2]
+
+	// a crash in the middle of the write of the 4th record
+	f, _ := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(torn)
+	f.Close()
+	writeRecords(2)
+
+	if n, err := verifyFiles(t, name); err != nil || n != 5 {
+		t.Fatalf("%d records verified, err=%v", n, err)
+	}
+	saved, _ := filepath.Glob(filepath.Join(dir, "audit.0.*.torn"))
+	if len(saved) != 1 {
+		t.Fatalf("torn record not saved: %v", saved)
+	}
+	if data, _ := os.ReadFile(saved[0]); string(data) != torn {
+		t.Errorf("saved %q, want %q", data, torn)
+	}
+
+	// nothing valid left in the file, the chain goes on from the rotated file
+	rotated := filepath.Join(dir, "audit.0.20231018T150405.000000.log")
+	os.Rename(name, rotated)
+	os.WriteFile(name, []byte(torn), 0644)
+	writeRecords(1)
+	if n, err := verifyFiles(t, rotated, name); err != nil || n != 6 {
+		t.Fatalf("%d records verified, err=%v", n, err)
+	}
+}
+
+func TestTruncateAfterFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "audit.0.log")
+	w := newWriter(dir, 0, 1<<20, 0)
+	if err := w.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.file.Close()
+	for i := 0; i < 2; i++ {
+		if err := w.write(&Record{Op: "Set", Namespace: "ns", RequestId: "rid"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the file size limit lets the next record be written only in part
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &rlim); err != nil {
+		t.Skip(err)
+	}
+	signal.Ignore(syscall.SIGXFSZ)
+	defer signal.Reset(syscall.SIGXFSZ)
+	limited := rlim
+	limited.Cur = uint64(w.file.Size() + 10)
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limited); err != nil {
+		t.Skip(err)
+	}
+	err := w.write(&Record{Op: "Set", Namespace: "ns", RequestId: "torn"})
+	syscall.Setrlimit(syscall.RLIMIT_FSIZE, &rlim)
+	if err == nil {
+		t.Fatal("write beyond the file size limit succeeded")
+	}
+
+	if err = w.write(&Record{Op: "Set", Namespace: "ns", RequestId: "rid"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := verifyFiles(t, name); err != nil || n != 3 {
+		t.Fatalf("%d records verified, err=%v", n, err)
+	}
+}
@@ -0,0 +1,84 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Chain is the position in a hash chain, i.e. the last record verified.
+type Chain struct {
+	Seq  uint64
+	Hash string
+}
+
+// parseRecord checks the hash of the record line, and returns the record
+// and its hash.
+func parseRecord(line []byte) (rec Record, hash string, err error) {
+	i := bytes.LastIndex(line, []byte(kHashField))
+	if i < 0 || !bytes.HasSuffix(line, []byte("\"}")) {
+		err = fmt.Errorf("hash not found")
+		return
+	}
+	hash = string(line[i+len(kHashField) : len(line)-2])
+	body := make([]byte, 0, i+1)
+	body = append(append(body, line[:i]...), '}')
+	h := sha256.Sum256(body)
+	if hex.EncodeToString(h[:]) != hash {
+		err = fmt.Errorf("hash mismatch")
+		return
+	}
+	err = json.Unmarshal(body, &rec)
+	return
+}
+
+// Verify checks the hash of each record read, and that it is chained to the
+// one before, starting from c unless c.Hash is empty. It returns the number
+// of records verified, and updates c with the last one, so that the files of
+// a worker can be verified one after another.
+func Verify(r io.Reader, c *Chain) (n int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, kMaxRecordLength), kMaxRecordLength)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec, hash, perr := parseRecord(line)
+		if perr != nil {
+			err = fmt.Errorf("record %d: %s", n+1, perr.Error())
+			return
+		}
+		if len(c.Hash) != 0 && (rec.PrevHash != c.Hash || rec.Seq != c.Seq+1) {
+			err = fmt.Errorf("record %d: seq %d not chained to seq %d", n+1, rec.Seq, c.Seq)
+			return
+		}
+		c.Seq, c.Hash = rec.Seq, hash
+		n++
+	}
+	err = scanner.Err()
+	return
+}
@@ -0,0 +1,221 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kHashField       = `,"Hash":"`
+	kRotateTimeFmt   = "20060102T150405.000000"
+	kMaxRecordLength = 64 * 1024 // to find the last record of a file
+)
+
+// writerT appends the records of a worker to audit.<worker id>.log. Once
+// larger than maxSize, the file is renamed with the time of the rotation,
+// e.g. audit.0.20231018T150405.000000.log, and the chain goes on in a new
+// file.
+type writerT struct {
+	dir    string
+	prefix string
+	file   *util.RotatingFile
+
+	seq      uint64
+	prevHash string
+}
+
+func newWriter(dir string, wid int, maxSize int64, maxFiles int) *writerT {
+	prefix := fmt.Sprintf("audit.%d.", wid)
+	return &writerT{
+		dir:    dir,
+		prefix: prefix,
+		file:   util.NewRotatingFile(dir, prefix, maxSize, maxFiles),
+	}
+}
+
+// open opens the file, and goes on with the chain of the last record of the
+// file, or of the last rotated file. A torn record at the end of the file is
+// cut off first, see repair.
+func (w *writerT) open() (err error) {
+	if err = w.file.Open(); err != nil {
+		return
+	}
+	if w.file.Size() != 0 {
+		var rec Record
+		var found bool
+		if rec, found, err = w.repair(); err != nil || found {
+			w.seq = rec.Seq
+			w.prevHash = rec.PrevHash
+			return
+		}
+	}
+	if rotated := w.file.RotatedFiles(); len(rotated) != 0 {
+		last := rotated[len(rotated)-1]
+		if rec, rerr := readLastRecord(last); rerr == nil {
+			w.seq = rec.Seq
+			w.prevHash = rec.PrevHash
+		} else {
+			glog.Errorf("AUDIT CHAIN BROKEN: no valid record at the end of %s (%s), starting a new chain",
+				last, rerr.Error())
+		}
+	}
+	return
+}
+
+// repair cuts off what follows the last valid record of the file, e.g. a line
+// torn by a crash or a full disk in the middle of a write, so that the chain
+// goes on from that record. The bytes cut off are saved in
+// audit.<worker id>.<time>.torn for inspection.
+func (w *writerT) repair() (rec Record, found bool, err error) {
+	var buf []byte
+	var off int64
+	if buf, off, err = readTail(w.file.File(), w.file.Size()); err != nil {
+		return
+	}
+	var end int
+	rec, end, found = lastRecord(buf)
+	if end == len(buf) {
+		return
+	}
+	torn := filepath.Join(w.dir, w.prefix+time.Now().UTC().Format(kRotateTimeFmt)+".torn")
+	if err = os.WriteFile(torn, buf[end:], 0644); err != nil {
+		return
+	}
+	if err = w.file.Truncate(off + int64(end)); err != nil {
+		return
+	}
+	if found {
+		glog.Errorf("AUDIT LOG REPAIRED: cut off %d bytes of torn record after seq %d of %s, saved in %s",
+			len(buf)-end, rec.Seq, w.file.Name(), torn)
+	} else {
+		glog.Errorf("AUDIT LOG REPAIRED: cut off %d bytes of torn record of %s, no valid record left, saved in %s",
+			len(buf)-end, w.file.Name(), torn)
+	}
+	return
+}
+
+// run writes the records received. A nil record asks for the file to be
+// synced, which is signaled on flushed.
+func (w *writerT) run(ch <-chan *Record, flushed chan<- struct{}) {
+	for r := range ch {
+		if r == nil {
+			w.file.Sync()
+			flushed <- struct{}{}
+			continue
+		}
+		if err := w.write(r); err != nil {
+			glog.Errorf("failed to write audit record rid=%s: %s", r.RequestId, err.Error())
+		}
+	}
+}
+
+func (w *writerT) write(r *Record) (err error) {
+	r.Seq = w.seq + 1
+	r.PrevHash = w.prevHash
+	var line []byte
+	if line, err = json.Marshal(r); err != nil {
+		return
+	}
+	h := sha256.Sum256(line)
+	hash := hex.EncodeToString(h[:])
+	line = append(line[:len(line)-1], kHashField...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+
+	if _, err = w.file.Write(line); err == nil {
+		w.seq = r.Seq
+		w.prevHash = hash
+	}
+	return
+}
+
+// readLastRecord returns the last valid record of the file, with the hash of
+// the record in PrevHash.
+func readLastRecord(name string) (rec Record, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	defer f.Close()
+	var fi os.FileInfo
+	if fi, err = f.Stat(); err != nil {
+		return
+	}
+	var buf []byte
+	if buf, _, err = readTail(f, fi.Size()); err != nil {
+		return
+	}
+	var found bool
+	if rec, _, found = lastRecord(buf); !found {
+		err = fmt.Errorf("no valid record in the last %d bytes", len(buf))
+	}
+	return
+}
+
+// readTail reads the last kMaxRecordLength bytes of the file, and returns
+// them with their offset in the file.
+func readTail(f *os.File, size int64) (buf []byte, off int64, err error) {
+	off = size - kMaxRecordLength
+	if off < 0 {
+		off = 0
+	}
+	buf = make([]byte, size-off)
+	if _, err = f.ReadAt(buf, off); err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+// lastRecord returns the last complete and valid record line of buf, with the
+// hash of the record in PrevHash, and the end of the line in buf.
+func lastRecord(buf []byte) (rec Record, end int, found bool) {
+	end = len(buf)
+	for end > 0 {
+		line := buf[:end]
+		complete := line[len(line)-1] == '\n'
+		if complete {
+			line = line[:len(line)-1]
+		}
+		start := bytes.LastIndexByte(line, '\n') + 1
+		if complete {
+			if r, hash, err := parseRecord(line[start:]); err == nil {
+				rec = r
+				rec.PrevHash = hash
+				found = true
+				return
+			}
+		}
+		end = start
+	}
+	return
+}
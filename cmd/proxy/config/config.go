@@ -37,8 +37,10 @@ import (
 	"github.com/paypal/junodb/pkg/io"
 	cal "github.com/paypal/junodb/pkg/logging/cal/config"
 	otel "github.com/paypal/junodb/pkg/logging/otel/config"
+	"github.com/paypal/junodb/pkg/proto"
 	"github.com/paypal/junodb/pkg/sec"
 	"github.com/paypal/junodb/pkg/service"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/util"
 	"github.com/paypal/junodb/pkg/version"
 )
@@ -76,8 +78,47 @@ var (
 		Outbound: io.DefaultOutboundConfig,
 		ReqProc: ReqProcConfig{
 			SSReqTimeout: util.Duration{100 * time.Millisecond},
+			HedgedRead: HedgedReadConfig{
+				Percentile:      95,
+				MinDelay:        util.Duration{2 * time.Millisecond},
+				MaxDelay:        util.Duration{50 * time.Millisecond},
+				MaxHedgePercent: 10,
+			},
+			SlowLog: SlowLogConfig{
+				Threshold:     util.Duration{Duration: 50 * time.Millisecond},
+				SamplePercent: 100,
+				MaxEntries:    200,
+				MaxFileSize:   100 * 1024 * 1024,
+				MaxFiles:      5,
+			},
 		},
 		Replication: repconfig.DefaultConfig,
+		HotKeys:     hotkey.DefaultConfig,
+		Audit: AuditConfig{
+			LogDir:      "audit",
+			MaxFileSize: 100 * 1024 * 1024,
+			QueueSize:   4096,
+		},
+		Shadow: ShadowConfig{
+			Timeout:           util.Duration{Duration: time.Second},
+			DiffSamplePercent: 100,
+			MaxDiffs:          100,
+			MaxFileSize:       100 * 1024 * 1024,
+			MaxFiles:          5,
+			IO:                io.DefaultOutboundConfig,
+		},
+		Resp: RespConfig{
+			Namespace: "resp",
+			AppName:   "resp",
+		},
+		Rest: RestConfig{
+			AppName:      "rest",
+			MaxBatchSize: 100,
+		},
+		Grpc: GrpcConfig{
+			AppName:          "grpc",
+			MaxBatchInFlight: 64,
+		},
 		CAL: cal.Config{
 			Host:             "127.0.0.1",
 			Port:             1118,
@@ -99,6 +140,90 @@ var (
 
 type ReqProcConfig struct {
 	SSReqTimeout util.Duration
+	HedgedRead   HedgedReadConfig
+	SlowLog      SlowLogConfig
+}
+
+// HedgedReadConfig configures sending a read to one more storage server
+// when the responses have not all arrived after a delay. The delay is the
+// given percentile of the recent storage server read response times, bounded
+// by MinDelay and MaxDelay.
+type HedgedReadConfig struct {
+	Enabled         bool
+	Percentile      float64
+	MinDelay        util.Duration
+	MaxDelay        util.Duration
+	MaxHedgePercent float64 // max percentage of the reads to be hedged
+}
+
+// SlowLogConfig configures logging the requests replied to later than a
+// threshold, with the timeline of their storage server requests. The
+// threshold of the namespace is used if any, then the one of the opcode.
+type SlowLogConfig struct {
+	Enabled             bool
+	Threshold           util.Duration
+	OpThresholds        map[string]util.Duration // by proxy opcode name, e.g. Get = "20ms"
+	NamespaceThresholds map[string]util.Duration
+	SamplePercent       float64 // percentage of the slow requests logged
+	MaxEntries          int     // number of the last entries kept per worker for /slowlog
+	MaxFileSize         int64   // the log file of a worker is rotated once larger
+	MaxFiles            int     // rotated files kept per worker, 0 means all
+}
+
+// AuditConfig configures the audit log of the writes to the namespaces
+// listed, e.g.
+//
+//	[Audit.Namespaces.ns1]
+//	LogKey = true
+type AuditConfig struct {
+	LogDir      string
+	MaxFileSize int64 // the file is rotated once larger
+	MaxFiles    int   // number of the rotated files kept per worker. 0 means no limit
+	QueueSize   int   // the requests are held up once this many records wait to be written
+	Namespaces  map[string]AuditNamespaceConfig
+}
+
+type AuditNamespaceConfig struct {
+	LogKey bool // log the key in addition to its hash
+}
+
+// ShadowConfig configures mirroring the client requests to the proxy of a
+// shadow cluster, and comparing its responses with the ones replied to the
+// clients. The keys are sampled by hash, so that the reads of a key mirrored
+// are mirrored with its writes if WritePercent is not below ReadPercent.
+type ShadowConfig struct {
+	io.ServiceEndpoint               // the proxy of the shadow cluster. Shadowing is disabled if Addr is empty
+	ReadPercent        float64       // percentage of the keys whose reads are mirrored
+	WritePercent       float64       // percentage of the keys whose writes are mirrored
+	Namespaces         []string      // empty means all
+	Timeout            util.Duration // of a shadow request
+	DiffSamplePercent  float64       // percentage of the mismatches logged
+	MaxDiffs           int           // number of the last mismatches kept per worker for /shadow
+	MaxFileSize        int64         // the diff log file of a worker is rotated once larger
+	MaxFiles           int           // rotated files kept per worker, 0 means all
+	IO                 io.OutboundConfig
+}
+
+// RespConfig configures the listeners with Protocol "resp", serving the Redis
+// clients.
+type RespConfig struct {
+	Namespace          string // namespace of the keys until a SELECT
+	NamespaceSeparator string // if not empty, the key "ns<sep>k" is the key k in the namespace ns
+	AppName            string // app name of the requests, in the logs
+}
+
+// RestConfig configures the listeners with Protocol "http", serving the
+// HTTP/JSON key-value API.
+type RestConfig struct {
+	AppName      string // app name of the requests, in the logs
+	MaxBatchSize int    // max number of requests in a batch
+}
+
+// GrpcConfig configures the listeners with Protocol "grpc", serving the gRPC
+// API.
+type GrpcConfig struct {
+	AppName          string // app name of the requests, in the logs
+	MaxBatchInFlight int    // max number of requests of a Batch stream processed at a time
 }
 
 type Config struct {
@@ -137,6 +262,12 @@ type Config struct {
 	Outbound     io.OutboundConfig
 	ReqProc      ReqProcConfig
 	Replication  repconfig.Config
+	HotKeys      hotkey.Config
+	Audit        AuditConfig
+	Shadow       ShadowConfig
+	Resp         RespConfig
+	Rest         RestConfig
+	Grpc         GrpcConfig
 	CAL          cal.Config
 	Etcd         etcd.Config
 	Sec          sec.Config
@@ -170,6 +301,7 @@ func (c *Config) validatePathAndFileNames() (err error) {
 		c.RootDir = filepath.Dir(os.Args[0])
 	}
 	c.validatePath(&c.StateLogDir)
+	c.validatePath(&c.Audit.LogDir)
 	c.validatePath(&c.Sec.CertPemFilePath)
 	c.validatePath(&c.Sec.KeyPemFilePath)
 	c.validatePath(&c.Sec.KeyStoreFilePath)
@@ -180,14 +312,123 @@ func (c *Config) validatePathAndFileNames() (err error) {
 
 func (c *Config) Validate() (err error) {
 	c.Config.SetDefaultIfNotDefined()
-	c.Replication.Validate()
-	err = c.Config.Validate()
+	if err = c.Replication.Validate(); err == nil {
+		if err = c.ReqProc.HedgedRead.Validate(); err == nil {
+			if err = c.ReqProc.SlowLog.Validate(); err == nil {
+				err = c.Config.Validate()
+			}
+		}
+	}
+	if err == nil {
+		err = c.HotKeys.Validate()
+	}
+	if err == nil {
+		err = c.Audit.Validate()
+	}
+	if err == nil {
+		err = c.Shadow.Validate()
+	}
+	if err == nil && c.Grpc.MaxBatchInFlight <= 0 {
+		err = fmt.Errorf("Grpc.MaxBatchInFlight %d not positive", c.Grpc.MaxBatchInFlight)
+	}
 	if err != nil {
 		glog.Errorf("config error: %s", err)
 	}
 	return
 }
 
+func (c *HedgedReadConfig) Validate() (err error) {
+	if !c.Enabled {
+		return
+	}
+	if c.Percentile <= 0 || c.Percentile >= 100 {
+		err = fmt.Errorf("ReqProc.HedgedRead.Percentile %g not in (0, 100)", c.Percentile)
+	} else if c.MaxDelay.Duration < c.MinDelay.Duration {
+		err = fmt.Errorf("ReqProc.HedgedRead.MaxDelay %s less than MinDelay %s", c.MaxDelay.Duration, c.MinDelay.Duration)
+	} else if c.MaxHedgePercent <= 0 || c.MaxHedgePercent > 100 {
+		err = fmt.Errorf("ReqProc.HedgedRead.MaxHedgePercent %g not in (0, 100]", c.MaxHedgePercent)
+	}
+	return
+}
+
+func (c *AuditConfig) Validate() (err error) {
+	if len(c.Namespaces) == 0 {
+		return
+	}
+	if c.MaxFileSize <= 0 {
+		err = fmt.Errorf("Audit.MaxFileSize %d not positive", c.MaxFileSize)
+	} else if c.MaxFiles < 0 {
+		err = fmt.Errorf("Audit.MaxFiles %d negative", c.MaxFiles)
+	} else if c.QueueSize <= 0 {
+		err = fmt.Errorf("Audit.QueueSize %d not positive", c.QueueSize)
+	}
+	return
+}
+
+// Enabled returns true if the requests are mirrored to a shadow cluster.
+func (c *ShadowConfig) Enabled() bool {
+	return len(c.Addr) != 0 && (c.ReadPercent > 0 || c.WritePercent > 0)
+}
+
+func (c *ShadowConfig) Validate() (err error) {
+	if len(c.Addr) == 0 {
+		return
+	}
+	if len(c.Network) == 0 {
+		c.Network = "tcp"
+	}
+	c.IO.SetDefaultIfNotDefined()
+	if c.ReadPercent < 0 || c.ReadPercent > 100 {
+		err = fmt.Errorf("Shadow.ReadPercent %g not in [0, 100]", c.ReadPercent)
+	} else if c.WritePercent < 0 || c.WritePercent > 100 {
+		err = fmt.Errorf("Shadow.WritePercent %g not in [0, 100]", c.WritePercent)
+	} else if c.DiffSamplePercent < 0 || c.DiffSamplePercent > 100 {
+		err = fmt.Errorf("Shadow.DiffSamplePercent %g not in [0, 100]", c.DiffSamplePercent)
+	} else if c.Timeout.Duration <= 0 {
+		err = fmt.Errorf("Shadow.Timeout %s not positive", c.Timeout.Duration)
+	} else if c.MaxDiffs < 0 {
+		err = fmt.Errorf("Shadow.MaxDiffs %d negative", c.MaxDiffs)
+	} else if c.MaxFileSize <= 0 {
+		err = fmt.Errorf("Shadow.MaxFileSize %d not positive", c.MaxFileSize)
+	} else if c.MaxFiles < 0 {
+		err = fmt.Errorf("Shadow.MaxFiles %d negative", c.MaxFiles)
+	}
+	return
+}
+
+func (c *SlowLogConfig) Validate() (err error) {
+	if !c.Enabled {
+		return
+	}
+	if c.Threshold.Duration <= 0 {
+		err = fmt.Errorf("ReqProc.SlowLog.Threshold %s not positive", c.Threshold.Duration)
+	} else if c.SamplePercent <= 0 || c.SamplePercent > 100 {
+		err = fmt.Errorf("ReqProc.SlowLog.SamplePercent %g not in (0, 100]", c.SamplePercent)
+	} else if c.MaxEntries < 0 {
+		err = fmt.Errorf("ReqProc.SlowLog.MaxEntries %d negative", c.MaxEntries)
+	} else if c.MaxFileSize <= 0 {
+		err = fmt.Errorf("ReqProc.SlowLog.MaxFileSize %d not positive", c.MaxFileSize)
+	} else if c.MaxFiles < 0 {
+		err = fmt.Errorf("ReqProc.SlowLog.MaxFiles %d negative", c.MaxFiles)
+	}
+	for name := range c.OpThresholds {
+		if err == nil && SlowLogOpCode(name) == proto.OpCodeNop {
+			err = fmt.Errorf("ReqProc.SlowLog.OpThresholds: unknown opcode %s", name)
+		}
+	}
+	return
+}
+
+// SlowLogOpCode returns the proxy opcode of the name, OpCodeNop if none.
+func SlowLogOpCode(name string) proto.OpCode {
+	for op := proto.OpCodeCreate; op < proto.OpCodeLastProxyOp; op++ {
+		if op.String() == name {
+			return op
+		}
+	}
+	return proto.OpCodeNop
+}
+
 func (c *Config) IsTLSEnabled(serverSide bool) (enabled bool) {
 	if serverSide {
 		for _, lsnr := range c.Listener {
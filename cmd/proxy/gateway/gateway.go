@@ -0,0 +1,129 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package gateway passes to the proxy request handler the requests translated
+// from the protocols other than Juno and Mayfly, e.g. by the RESP, HTTP and
+// gRPC listeners.
+package gateway
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// kMaxParallel is the number of requests of a batch processed at a time.
+const kMaxParallel = 32
+
+// Result is the part of a response the gateways need
+type Result struct {
+	Status               proto.OpStatus
+	Value                []byte
+	Version              uint32
+	CreationTime         uint32
+	ExpirationTime       uint32
+	LastModificationTime uint64
+	ValueSize            uint32 // of a GetMeta
+}
+
+// NewRequest returns a request with a new request ID, with the source set to
+// addr if a TCP address.
+func NewRequest(op proto.OpCode, ns []byte, key []byte, value []byte, ttl uint32, addr net.Addr, appName []byte) *proto.OperationalMessage {
+	var payload proto.Payload
+	payload.SetWithClearValue(value)
+	request := &proto.OperationalMessage{}
+	request.SetRequest(op, key, ns, &payload, ttl)
+	request.SetNewRequestID()
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		request.SetSource(tcpAddr.IP, uint16(tcpAddr.Port), appName)
+	}
+	return request
+}
+
+// IsOK returns whether the status is a success for the client
+func IsOK(st proto.OpStatus) bool {
+	return st == proto.OpStatusNoError || st == proto.OpStatusInconsistent
+}
+
+// Process passes the request to the request handler, as the Connector does
+// for a Juno client, and waits for the response. The request times out after
+// timeout, or when ctx is done.
+func Process(ctx context.Context, reqHandler io.IRequestHandler, request *proto.OperationalMessage, timeout time.Duration) (res Result, err error) {
+	var raw proto.RawMessage
+	if err = request.Encode(&raw); err != nil {
+		return
+	}
+	ch := make(chan io.IResponseContext, 1)
+	reqCtx := io.NewRequestContext(&raw, ch)
+	reqCtx.SetTimeout(ctx, timeout)
+	go reqHandler.Process(reqCtx)
+
+	resp := <-ch
+	defer resp.OnComplete()
+	var response proto.OperationalMessage
+	if err = response.Decode(resp.GetMessage()); err != nil {
+		return
+	}
+	res.Status = response.GetOpStatus()
+	res.Version = response.GetVersion()
+	res.CreationTime = response.GetCreationTime()
+	res.ExpirationTime = response.GetExpirationTime()
+	res.LastModificationTime = response.GetLastModificationTime()
+	res.ValueSize = response.GetValueSize()
+	if payload := response.GetPayload(); payload != nil && payload.GetLength() != 0 {
+		var value []byte
+		if value, err = payload.GetClearValue(); err != nil {
+			return
+		}
+		// the response buffer is released on return
+		res.Value = append([]byte{}, value...)
+	}
+	return
+}
+
+// ProcessAll processes the requests in parallel, kMaxParallel at most at a
+// time. The error is the first one of the requests, if any.
+func ProcessAll(ctx context.Context, reqHandler io.IRequestHandler, requests []*proto.OperationalMessage, timeout time.Duration) (results []Result, err error) {
+	results = make([]Result, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	wg.Add(len(requests))
+	sem := make(chan struct{}, kMaxParallel)
+	for i := range requests {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = Process(ctx, reqHandler, requests[i], timeout)
+		}(i)
+	}
+	wg.Wait()
+	for _, err = range errs {
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,214 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package grpcserv
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/paypal/junodb/pkg/grpc/junopb"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// kHangKey is replied to with a timeout once the request times out.
+const kHangKey = "hang"
+
+// fakeHandler keeps the values created in memory.
+type fakeHandler struct {
+	mtx    sync.Mutex
+	values map[string][]byte
+}
+
+func (h *fakeHandler) Init()                                             {}
+func (h *fakeHandler) Finish()                                           {}
+func (h *fakeHandler) GetReqCtxCreator() io.InboundRequestContextCreator { return nil }
+func (h *fakeHandler) OnKeepAlive(c *io.Connector, r io.IRequestContext) error {
+	return nil
+}
+
+func (h *fakeHandler) Process(reqCtx io.IRequestContext) error {
+	var request proto.OperationalMessage
+	if err := request.Decode(reqCtx.GetMessage()); err != nil {
+		return err
+	}
+	resp := request.CreateResponse()
+	if string(request.GetKey()) == kHangKey {
+		<-reqCtx.GetCtx().Done()
+		resp.SetOpStatus(proto.OpStatusReqProcTimeout)
+		var raw proto.RawMessage
+		resp.Encode(&raw)
+		reqCtx.Reply(io.NewInboundRespose(request.GetOpCode(), &raw))
+		return nil
+	}
+	h.mtx.Lock()
+	k := string(request.GetNamespace()) + "/" + string(request.GetKey())
+	value, found := h.values[k]
+	switch request.GetOpCode() {
+	case proto.OpCodeCreate:
+		if found {
+			resp.SetOpStatus(proto.OpStatusDupKey)
+		} else {
+			h.values[k] = request.GetPayload().GetData()
+			resp.SetVersion(1)
+		}
+	case proto.OpCodeGet:
+		if found {
+			var payload proto.Payload
+			payload.SetWithClearValue(value)
+			resp.SetPayload(&payload)
+			resp.SetVersion(1)
+		} else {
+			resp.SetOpStatus(proto.OpStatusNoKey)
+		}
+	default:
+		resp.SetOpStatus(proto.OpStatusNotSupported)
+	}
+	h.mtx.Unlock()
+	var raw proto.RawMessage
+	resp.Encode(&raw)
+	reqCtx.Reply(io.NewInboundRespose(request.GetOpCode(), &raw))
+	return nil
+}
+
+// listen serves the connections of a listener with the config, and returns
+// a client connected to it.
+func listen(t *testing.T, iocfg io.InboundConfig, reqHandler io.IRequestHandler) junopb.JunoClient {
+	lsnr, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lsnr.Close() })
+	serve := NewConnServer(iocfg, reqHandler)
+	go func() {
+		for {
+			conn, err := lsnr.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, iocfg, reqHandler)
+		}
+	}()
+	conn, err := grpc.Dial(lsnr.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return junopb.NewJunoClient(conn)
+}
+
+func TestService(t *testing.T) {
+	reqHandler := &fakeHandler{values: make(map[string][]byte)}
+	cli := listen(t, io.DefaultInboundConfig, reqHandler)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	req := &junopb.Request{Namespace: "ns", Key: []byte("k"), Value: []byte("v")}
+	if _, err = cli.Get(ctx, req); status.Code(err) != codes.NotFound {
+		t.Fatalf("expect NotFound, got %v", err)
+	}
+	if _, err = cli.Create(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Create(ctx, req); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expect AlreadyExists, got %v", err)
+	}
+	resp, err := cli.Get(ctx, req)
+	if err != nil || string(resp.Value) != "v" || resp.Version != 1 {
+		t.Fatalf("unexpected response %v %v", resp, err)
+	}
+	if _, err = cli.UDFGet(ctx, req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expect InvalidArgument without udf name, got %v", err)
+	}
+
+	stream, err := cli.Batch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := []junopb.Op{junopb.Op_OP_GET, junopb.Op_OP_CREATE, junopb.Op_OP_UNSPECIFIED}
+	expected := []codes.Code{codes.OK, codes.AlreadyExists, codes.InvalidArgument}
+	for i, op := range ops {
+		if err = stream.Send(&junopb.Request{Op: op, Tag: uint64(i), Namespace: "ns", Key: []byte("k")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.CloseSend()
+	for range ops {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes.Code(resp.Code) != expected[resp.Tag] {
+			t.Fatalf("request %d: expect %s, got %+v", resp.Tag, expected[resp.Tag], resp)
+		}
+	}
+}
+
+func TestServerPerListener(t *testing.T) {
+	reqHandler := &fakeHandler{values: make(map[string][]byte)}
+	slowCfg := io.DefaultInboundConfig
+	slowCfg.RequestTimeout.Duration = time.Minute
+	fastCfg := io.DefaultInboundConfig
+	fastCfg.RequestTimeout.Duration = 50 * time.Millisecond
+	slow := listen(t, slowCfg, reqHandler)
+	fast := listen(t, fastCfg, reqHandler)
+
+	req := &junopb.Request{Namespace: "ns", Key: []byte(kHangKey)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := slow.Get(ctx, req); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := fast.Get(ctx, req); err == nil {
+		t.Fatal("expect the request to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timed out after %s, not with the timeout of its listener", elapsed)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	s := &service{timeout: time.Second}
+	if timeout, err := s.requestTimeout(context.Background()); err != nil || timeout != time.Second {
+		t.Fatalf("expect the proxy timeout, got %s %v", timeout, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if timeout, err := s.requestTimeout(ctx); err != nil || timeout > 100*time.Millisecond {
+		t.Fatalf("expect the deadline, got %s %v", timeout, err)
+	}
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Millisecond))
+	defer cancel()
+	if _, err := s.requestTimeout(ctx); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+}
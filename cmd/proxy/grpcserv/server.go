@@ -0,0 +1,108 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package grpcserv serves the gRPC API of pkg/grpc/junopb on the proxy
+// listeners with Protocol "grpc".
+package grpcserv
+
+import (
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/grpc/junopb"
+	"github.com/paypal/junodb/pkg/io"
+)
+
+// connListener hands over to the gRPC server the connections accepted by the
+// proxy listeners.
+type connListener struct {
+	ch   chan net.Conn
+	addr net.Addr
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	return <-l.ch, nil
+}
+
+func (l *connListener) Close() error {
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// trackedConn tells when the gRPC server has closed the connection.
+type trackedConn struct {
+	net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+func (c *trackedConn) Close() (err error) {
+	err = c.Conn.Close()
+	c.once.Do(func() { close(c.done) })
+	return
+}
+
+// connServer is the gRPC server of a listener, built from the config of the
+// listener and started on the first connection.
+type connServer struct {
+	iocfg      io.InboundConfig
+	reqHandler io.IRequestHandler
+	startOnce  sync.Once
+	lsnr       *connListener
+}
+
+// NewConnServer returns the server of the connections of a listener.
+func NewConnServer(iocfg io.InboundConfig, reqHandler io.IRequestHandler) io.ConnServer {
+	s := &connServer{iocfg: iocfg, reqHandler: reqHandler}
+	return s.serve
+}
+
+// serve passes the connection to the gRPC server of the listener, and returns
+// once the connection is closed.
+func (s *connServer) serve(conn net.Conn, _ io.InboundConfig, _ io.IRequestHandler) {
+	s.startOnce.Do(func() {
+		s.lsnr = &connListener{ch: make(chan net.Conn), addr: conn.LocalAddr()}
+		go newServer(s.iocfg, s.reqHandler).Serve(s.lsnr)
+	})
+	c := &trackedConn{Conn: conn, done: make(chan struct{})}
+	s.lsnr.ch <- c
+	<-c.done
+}
+
+func newServer(iocfg io.InboundConfig, reqHandler io.IRequestHandler) *grpc.Server {
+	conf := &config.Conf.Grpc
+	srv := grpc.NewServer(
+		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: iocfg.IdleTimeout.Duration}),
+		grpc.MaxRecvMsgSize(config.Conf.MaxPayloadLength+config.Conf.MaxKeyLength+1024),
+	)
+	junopb.RegisterJunoServer(srv, &service{
+		reqHandler:       reqHandler,
+		timeout:          iocfg.RequestTimeout.Duration,
+		appName:          []byte(conf.AppName),
+		maxBatchInFlight: conf.MaxBatchInFlight,
+	})
+	return srv
+}
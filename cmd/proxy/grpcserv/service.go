@@ -0,0 +1,204 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package grpcserv
+
+import (
+	"context"
+	goio "io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/paypal/junodb/cmd/proxy/gateway"
+	"github.com/paypal/junodb/pkg/grpc/junopb"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+var _ junopb.JunoServer = (*service)(nil)
+
+type service struct {
+	junopb.UnimplementedJunoServer
+	reqHandler       io.IRequestHandler
+	timeout          time.Duration
+	appName          []byte
+	maxBatchInFlight int
+}
+
+var opCodes = map[junopb.Op]proto.OpCode{
+	junopb.Op_OP_CREATE:  proto.OpCodeCreate,
+	junopb.Op_OP_GET:     proto.OpCodeGet,
+	junopb.Op_OP_UPDATE:  proto.OpCodeUpdate,
+	junopb.Op_OP_SET:     proto.OpCodeSet,
+	junopb.Op_OP_DESTROY: proto.OpCodeDestroy,
+	junopb.Op_OP_UDF_GET: proto.OpCodeUDFGet,
+}
+
+// grpcCode returns the gRPC status code of an op status
+func grpcCode(st proto.OpStatus) codes.Code {
+	switch st {
+	case proto.OpStatusNoError, proto.OpStatusInconsistent:
+		return codes.OK
+	case proto.OpStatusNoKey:
+		return codes.NotFound
+	case proto.OpStatusDupKey:
+		return codes.AlreadyExists
+	case proto.OpStatusVersionConflict:
+		return codes.FailedPrecondition
+	case proto.OpStatusBadParam, proto.OpStatusBadMsg:
+		return codes.InvalidArgument
+	case proto.OpStatusRecordLocked, proto.OpStatusBusy, proto.OpStatusNoStorageServer:
+		return codes.Unavailable
+	case proto.OpStatusReqProcTimeout:
+		return codes.DeadlineExceeded
+	case proto.OpStatusNotSupported:
+		return codes.Unimplemented
+	}
+	return codes.Internal
+}
+
+func (s *service) Create(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeCreate, req)
+}
+
+func (s *service) Get(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeGet, req)
+}
+
+func (s *service) Update(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeUpdate, req)
+}
+
+func (s *service) Set(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeSet, req)
+}
+
+func (s *service) Destroy(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeDestroy, req)
+}
+
+func (s *service) UDFGet(ctx context.Context, req *junopb.Request) (*junopb.Response, error) {
+	return s.process(ctx, proto.OpCodeUDFGet, req)
+}
+
+// requestTimeout returns the proxy request timeout, or the time left before
+// the deadline of the call if shorter.
+func (s *service) requestTimeout(ctx context.Context) (timeout time.Duration, err error) {
+	timeout = s.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left <= 0 {
+			err = status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+		} else if left < timeout {
+			timeout = left
+		}
+	}
+	return
+}
+
+func (s *service) process(ctx context.Context, op proto.OpCode, req *junopb.Request) (*junopb.Response, error) {
+	if op == proto.OpCodeUDFGet && len(req.UdfName) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no udf_name")
+	}
+	timeout, err := s.requestTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request := gateway.NewRequest(op, []byte(req.Namespace), req.Key, req.Value, req.Ttl, peerAddr(ctx), s.appName)
+	if req.Version != 0 {
+		request.SetVersion(req.Version)
+	}
+	if len(req.CorrelationId) != 0 {
+		request.SetCorrelationID([]byte(req.CorrelationId))
+	}
+	if len(req.UdfName) != 0 {
+		request.SetUDFName([]byte(req.UdfName))
+	}
+	res, err := gateway.Process(ctx, s.reqHandler, request, timeout)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if code := grpcCode(res.Status); code != codes.OK {
+		return nil, status.Error(code, res.Status.String())
+	}
+	return &junopb.Response{
+		Value:          res.Value,
+		Version:        res.Version,
+		CreationTime:   res.CreationTime,
+		ExpirationTime: res.ExpirationTime,
+	}, nil
+}
+
+// Batch processes up to maxBatchInFlight requests of the stream at a time.
+func (s *service) Batch(stream junopb.Juno_BatchServer) (err error) {
+	ctx := stream.Context()
+	inFlight := make(chan struct{}, s.maxBatchInFlight)
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	var sendErr error
+
+	for {
+		var req *junopb.Request
+		if req, err = stream.Recv(); err != nil {
+			break
+		}
+		inFlight <- struct{}{}
+		wg.Add(1)
+		go func(req *junopb.Request) {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			var resp *junopb.Response
+			var perr error
+			if op, ok := opCodes[req.Op]; ok {
+				resp, perr = s.process(ctx, op, req)
+			} else {
+				perr = status.Errorf(codes.InvalidArgument, "invalid op %s", req.Op)
+			}
+			if perr != nil {
+				st := status.Convert(perr)
+				resp = &junopb.Response{Code: uint32(st.Code()), Error: st.Message()}
+			}
+			resp.Tag = req.Tag
+			mtx.Lock()
+			if sendErr == nil {
+				sendErr = stream.Send(resp)
+			}
+			mtx.Unlock()
+		}(req)
+	}
+	wg.Wait()
+	if err == goio.EOF {
+		err = sendErr
+	}
+	return
+}
+
+func peerAddr(ctx context.Context) (addr net.Addr) {
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr
+	}
+	return
+}
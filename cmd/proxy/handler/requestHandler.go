@@ -25,7 +25,10 @@ import (
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
 	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/grpcserv"
 	"github.com/paypal/junodb/cmd/proxy/proc"
+	"github.com/paypal/junodb/cmd/proxy/resp"
+	"github.com/paypal/junodb/cmd/proxy/rest"
 	"github.com/paypal/junodb/cmd/proxy/stats"
 	"github.com/paypal/junodb/pkg/io"
 	"github.com/paypal/junodb/pkg/net/netutil"
@@ -35,6 +38,12 @@ import (
 
 var _ io.IRequestHandler = (*RequestHandler)(nil)
 
+func init() {
+	io.RegisterConnServer("resp", resp.Serve)
+	io.RegisterConnServer("http", rest.Serve)
+	io.RegisterConnServerFactory("grpc", grpcserv.NewConnServer)
+}
+
 type RequestHandler struct {
 	procPools []*proc.ReqProcessorPool
 }
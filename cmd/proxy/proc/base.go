@@ -30,8 +30,10 @@ import (
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
+	"github.com/paypal/junodb/cmd/proxy/audit"
 	"github.com/paypal/junodb/cmd/proxy/config"
 	"github.com/paypal/junodb/cmd/proxy/replication"
+	"github.com/paypal/junodb/cmd/proxy/shadow"
 	proxystats "github.com/paypal/junodb/cmd/proxy/stats"
 	"github.com/paypal/junodb/pkg/cluster"
 	"github.com/paypal/junodb/pkg/debug"
@@ -43,6 +45,7 @@ import (
 	"github.com/paypal/junodb/pkg/proto"
 	"github.com/paypal/junodb/pkg/shard"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/util"
 )
 
@@ -68,6 +71,7 @@ type (
 		OnResponseReceived(st *SSRequestContext)
 		OnSSTimeout(st *SSRequestContext)
 		OnSSIOError(st *SSRequestContext)
+		onHedgeTimeout()
 
 		//Return true if it has completed all the SS requests and can be cached
 		Process(reqCtx io.IRequestContext) bool
@@ -91,6 +95,7 @@ type (
 		ssResponseOpStatus proto.OpStatus
 		ssIndex            uint32
 		state              ssReqContextState
+		hedged             bool
 	}
 
 	ProxyInResponseContext struct {
@@ -126,7 +131,13 @@ type (
 		pendingResponses     []*SSRequestContext
 		pendingResponseQueue []*SSRequestContext
 		responseTimer        *util.TimerWrapper
+		hedgeTimer           *util.TimerWrapper
 		hasRepliedClient     bool
+		respValueLen         int // value length of the response to the client, for the hot key stats
+		timeReplied          time.Time
+		replyStatus          proto.OpStatus
+		replyVersion         uint32
+		replyDigest          string // of the value replied, for a request mirrored to the shadow cluster
 
 		self IRequestProcessor
 	}
@@ -230,7 +241,16 @@ func (p *ProcessorBase) Init() {
 	} else {
 		p.responseTimer.Stop()
 	}
+	if p.hedgeTimer == nil {
+		p.hedgeTimer = util.NewTimerWrapper(confSSRequestTimeout)
+	} else {
+		p.hedgeTimer.Stop()
+	}
 	p.hasRepliedClient = false
+	p.respValueLen = 0
+	p.timeReplied = time.Time{}
+	p.replyVersion = 0
+	p.replyDigest = ""
 	p.numSSRequestSent = 0
 	p.numSSResponseReceived = 0
 	p.numSSResponseIOError = 0
@@ -243,6 +263,9 @@ func (p *ProcessorBase) needApplyUDF() bool {
 func (p *ProcessorBase) applyUDF(opmsg *proto.OperationalMessage) {
 }
 
+func (p *ProcessorBase) onHedgeTimeout() {
+}
+
 func (p *ProcessorBase) isDone() bool {
 	return (p.numSSRequestSent == p.numSSResponseReceived)
 }
@@ -255,6 +278,13 @@ func (p *ProcessorBase) chSSTimeout() <-chan time.Time {
 	}
 }
 
+func (p *ProcessorBase) chHedgeTimeout() <-chan time.Time {
+	if p.hedgeTimer == nil {
+		return nil
+	}
+	return p.hedgeTimer.GetTimeoutCh()
+}
+
 func (p *ProcessorBase) callStateString(st *SSRequestContext) string {
 	var states [3]string
 
@@ -308,12 +338,16 @@ func (p *ProcessorBase) replyToClient(resp *ResponseWrapper) {
 			opMsg := &resp.ssRequest.ssRespOpMsg
 			opstatus := opMsg.GetOpStatus()
 			opcode := p.clientRequest.GetOpCode()
+			p.respValueLen = int(opMsg.GetPayloadValueLength())
 			var logData, callData *logging.KeyValueBuffer
 			m := resp.ssRequest.ssResponse.GetMessage()
 			if opstatus == proto.OpStatusAlreadyFulfilled {
 				opstatus = proto.OpStatusNoError
 				proto.SetOpStatus(m, proto.OpStatusNoError)
 			}
+			p.timeReplied = time.Now()
+			p.replyStatus = opstatus
+			p.replyVersion = opMsg.GetVersion()
 			if cal.IsEnabled() {
 				if logData == nil {
 					logData, callData = p.genLogData(opMsg)
@@ -340,6 +374,7 @@ func (p *ProcessorBase) replyToClient(resp *ResponseWrapper) {
 						calLogReqProcError(kDecrypt, []byte(errmsg))
 					}
 					otel.RecordCount(otel.ReqProc, []otel.Tags{{otel.Operation, kDecrypt}, {otel.Status, otel.StatusError}})
+					p.replyStatus = proto.OpStatusInternal
 					msg := p.clientRequest.CreateResponse()
 					msg.SetOpStatus(proto.OpStatusInternal)
 					var raw proto.RawMessage
@@ -354,6 +389,9 @@ func (p *ProcessorBase) replyToClient(resp *ResponseWrapper) {
 					if p.self.needApplyUDF() {
 						p.self.applyUDF(&reply)
 					}
+					if shadow.IsShadowed(&p.clientRequest) {
+						p.replyDigest = shadow.Digest(reply.GetPayload())
+					}
 					reply.Encode(&raw)
 					response := NewProxyInRespose(&p.clientRequest, &raw, p.requestContext.GetReceiveTime(), logData, callData)
 					p.requestContext.Reply(response)
@@ -376,6 +414,9 @@ func (p *ProcessorBase) replyToClient(resp *ResponseWrapper) {
 					// Set in RawMessage.
 					proto.SetRequestHandlingTime(m, rhtms)
 				}
+				if shadow.IsShadowed(&p.clientRequest) {
+					p.replyDigest = shadow.Digest(opMsg.GetPayload())
+				}
 				response := NewProxyInRespose(&p.clientRequest, m, p.requestContext.GetReceiveTime(), logData, callData)
 				p.requestContext.Reply(response)
 			}
@@ -394,6 +435,7 @@ func (p *ProcessorBase) replicate(opstatus proto.OpStatus, resp *SSRequestContex
 	opcode := p.clientRequest.GetOpCode()
 	if (opstatus == proto.OpStatusNoError || opstatus == proto.OpStatusInconsistent) &&
 		((opcode != proto.OpCodeGet && opcode != proto.OpCodeUDFGet) || p.clientRequest.GetTimeToLive() > 0) &&
+		opcode != proto.OpCodeExists && opcode != proto.OpCodeGetMeta &&
 		opMsg.GetCreationTime() != 0 &&
 		opMsg.GetVersion() != 0 {
 		if LOG_VERBOSE {
@@ -415,7 +457,10 @@ func (p *ProcessorBase) replicate(opstatus proto.OpStatus, resp *SSRequestContex
 		repRequest.SetAsReplication()
 		repRequest.SetCreationTime(opMsg.GetCreationTime())
 		repRequest.SetVersion(opMsg.GetVersion())
-		repRequest.SetLastModificationTime(opMsg.GetLastModificationTime())
+		if !confActiveActive || opcode != proto.OpCodeDestroy {
+			// in active-active mode a destroy keeps the time it was stamped with
+			repRequest.SetLastModificationTime(opMsg.GetLastModificationTime())
+		}
 		repRequest.SetOriginatorRequestID(opMsg.GetOriginatorRequestID())
 		expTime := opMsg.GetExpirationTime()
 		repRequest.SetExpirationTime(expTime)
@@ -461,6 +506,9 @@ func (p *ProcessorBase) replyStatusToClient(st proto.OpStatus) {
 
 			}
 			p.hasRepliedClient = true
+			p.timeReplied = time.Now()
+			p.replyStatus = st
+			p.replyVersion = msg.GetVersion()
 			p.requestContext.Reply(resp)
 		}
 	}
@@ -524,6 +572,7 @@ func (p *ProcessorBase) sendMessage(msg *proto.RawMessage, ssIndex uint32) *erro
 	st.timeRespReceived = time.Time{}
 	st.ssResponse = nil
 	st.ssResponseOpStatus = proto.OpStatusNoError
+	st.hedged = false
 
 	// Create a OutboundRequestContext
 	st.timeToExpire = st.timeReqSent.Add(confSSRequestTimeout)
@@ -628,6 +677,30 @@ func (p *ProcessorBase) validateInboundRequest(r *proto.OperationalMessage) bool
 	return true
 }
 
+// stampOrigin sets the origin cluster id and, for destroy, the modification
+// time of a local write in active-active mode. It returns false for a
+// replication request that came back to its origin, which is to be dropped.
+func (p *ProcessorBase) stampOrigin() bool {
+	request := &p.clientRequest
+	if request.IsForReplication() {
+		if request.GetOriginClusterId() == confClusterId {
+			if cal.IsEnabled() {
+				calLogReqProcEvent(kReplicaLoop, []byte("rid="+p.requestID))
+			}
+			if LOG_DEBUG {
+				glog.DebugInfof("drop replication request from the local cluster. rid=%s", p.requestID)
+			}
+			return false
+		}
+		return true
+	}
+	request.SetOriginClusterId(confClusterId)
+	if request.GetOpCode() == proto.OpCodeDestroy {
+		request.SetLastModificationTime(uint64(time.Now().UnixNano()))
+	}
+	return true
+}
+
 func (p *ProcessorBase) Process(request io.IRequestContext) bool {
 
 	p.ctx = request.GetCtx()
@@ -649,6 +722,12 @@ func (p *ProcessorBase) Process(request io.IRequestContext) bool {
 
 	p.requestID = p.clientRequest.GetRequestIDString()
 
+	if confActiveActive && !p.stampOrigin() {
+		p.replyStatusToClient(proto.OpStatusNoError)
+		p.OnComplete()
+		return true
+	}
+
 	shardId, ok := p.ssGroup.getProcessors(p.clientRequest.GetKey())
 
 	if !ok {
@@ -709,6 +788,9 @@ loop:
 			break loop
 		case t := <-p.chSSTimeout():
 			p.handleSSTimeout(t)
+		case <-p.chHedgeTimeout():
+			p.hedgeTimer.Stop()
+			p.self.onHedgeTimeout()
 		case respFromSS := <-p.chSSResponse:
 			p.onResponseReceived(respFromSS)
 		}
@@ -734,6 +816,15 @@ func (p *ProcessorBase) OnComplete() {
 	if LOG_VERBOSE {
 		p.logStats()
 	}
+	p.logSlowRequest()
+	hotkey.Record(p.clientRequest.GetNamespace(), p.clientRequest.GetKey(), p.clientRequest.GetOpCode(),
+		int(p.clientRequest.GetPayloadValueLength())+p.respValueLen)
+	if p.hasRepliedClient {
+		audit.Log(&p.clientRequest, p.replyVersion, p.replyStatus.String())
+		shadow.Mirror(&p.clientRequest, shadow.Result{Status: p.replyStatus.String(), Version: p.replyVersion, Digest: p.replyDigest})
+	} else {
+		audit.Log(&p.clientRequest, 0, "NotReplied")
+	}
 	for i := 0; i < p.numSSRequestSent; i++ {
 		st := &p.ssRequestContexts[i]
 		if st.ssResponse != nil {
@@ -744,6 +835,7 @@ func (p *ProcessorBase) OnComplete() {
 	p.requestContext.Cancel()
 	p.requestContext.OnComplete()
 	p.responseTimer.Stop()
+	p.hedgeTimer.Stop()
 	p.self.Init()
 
 }
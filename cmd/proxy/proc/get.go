@@ -22,6 +22,7 @@ package proc
 import (
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
+	proxystats "github.com/paypal/junodb/cmd/proxy/stats"
 	"github.com/paypal/junodb/pkg/logging"
 	"github.com/paypal/junodb/pkg/proto"
 )
@@ -36,6 +37,7 @@ type GetProcessor struct {
 	repair               RequestAndStats
 	numNoKey             int
 	numTTLExtendFailures int
+	hedge                *SSRequestContext // the read sent to one more SS after the hedge delay
 }
 
 func NewGetProcessor() *GetProcessor {
@@ -53,6 +55,49 @@ func (p *GetProcessor) Init() {
 	p.repair.init()
 	p.numNoKey = 0
 	p.numTTLExtendFailures = 0
+	p.hedge = nil
+}
+
+func (p *GetProcessor) sendInitRequests() {
+	p.OnePhaseProcessor.sendInitRequests()
+	if confHedgedRead.Enabled && p.ssRequestOpCode == proto.OpCodeRead && !p.hasRepliedClient && int(p.request.nextSSIndex) < p.ssGroup.numAvailableSSs {
+		hedgeBudget.onRead()
+		p.hedgeTimer.Reset(hedgeDelay())
+	}
+}
+
+// onHedgeTimeout sends the read to the next candidate SS if the client has
+// not been replied to yet.
+func (p *GetProcessor) onHedgeTimeout() {
+	if p.hasRepliedClient || p.hedge != nil || int(p.request.nextSSIndex) >= p.ssGroup.numAvailableSSs {
+		return
+	}
+	if !hedgeBudget.allow(confHedgedRead.MaxHedgePercent) {
+		return
+	}
+	n := p.numSSRequestSent
+	ssIndex := p.request.nextSSIndex
+	p.sendRequest()
+	if p.numSSRequestSent > n {
+		p.hedge = &p.ssRequestContexts[n]
+		p.hedge.hedged = true
+		hedgeBudget.onHedged()
+		proxystats.OnHedgedRead()
+		if LOG_DEBUG {
+			glog.DebugInfof("hedged read to %s rid=%s", p.logStrSsIdx(ssIndex), p.requestID)
+		}
+	}
+}
+
+// hasPendingRead returns true if a read other than rc is waiting for the response.
+func (p *GetProcessor) hasPendingRead(rc *SSRequestContext) bool {
+	for i := 0; i < p.numSSRequestSent; i++ {
+		st := &p.ssRequestContexts[i]
+		if st != rc && st.opCode == p.ssRequestOpCode && st.state == stSSRequestSent {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *GetProcessor) sendRepair(ssIndex uint32) {
@@ -77,6 +122,15 @@ func (p *GetProcessor) succeeded() bool {
 func (p *GetProcessor) replyToClientAndRepair() {
 	if !p.hasRepliedClient {
 		st := p.request.mostUpdatedOkResponse.ssRequest.ssRespOpMsg.GetOpStatus()
+		if p.clientRequest.IsHistoryRead() || p.ssRequestOpCode != proto.OpCodeRead {
+			// neither an earlier version of the record nor the meta is repaired
+			if st == proto.OpStatusKeyMarkedDelete {
+				p.replyStatusToClient(proto.OpStatusNoKey)
+			} else {
+				p.replyToClient(p.request.mostUpdatedOkResponse)
+			}
+			return
+		}
 		if st == proto.OpStatusKeyMarkedDelete {
 			opMsg := p.request.mostUpdatedOkResponse.ssRequest.ssRespOpMsg
 			opMsg.SetAsRequest()
@@ -185,7 +239,13 @@ func (p *GetProcessor) onFailure(rc *SSRequestContext) {
 }
 
 func (p *GetProcessor) OnResponseReceived(rc *SSRequestContext) {
-	if rc.opCode == proto.OpCodeRead {
+	if rc.opCode == p.ssRequestOpCode {
+		if confHedgedRead.Enabled && rc.opCode == proto.OpCodeRead {
+			ssReadLatency.observe(rc.timeRespReceived.Sub(rc.timeReqSent))
+			if rc == p.hedge && !p.hasRepliedClient && p.hasPendingRead(rc) {
+				proxystats.OnHedgeWon()
+			}
+		}
 		switch rc.ssResponseOpStatus {
 		case proto.OpStatusNoError:
 			p.onSuccess(rc)
@@ -207,6 +267,9 @@ func (p *GetProcessor) OnResponseReceived(rc *SSRequestContext) {
 }
 
 func (p *GetProcessor) OnSSTimeout(rc *SSRequestContext) {
+	if confHedgedRead.Enabled && rc.opCode == proto.OpCodeRead {
+		ssReadLatency.observe(confSSRequestTimeout)
+	}
 	p.onFailure(rc) ///TODO proto.OpStatusNoStorageServer)
 }
 
@@ -0,0 +1,140 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package proc
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	kLatencyMinBound   = 50 * time.Microsecond
+	kLatencyMaxBound   = 10 * time.Second
+	kLatencyDecayCount = 8192 // halve the counts once this many are observed
+	kLatencyMinSamples = 100  // use the max delay until this many are observed
+	kHedgeDecayCount   = 8192
+)
+
+var (
+	latencyBounds []time.Duration
+
+	ssReadLatency latencyTrackerT
+	hedgeBudget   hedgeBudgetT
+)
+
+type (
+	// latencyTrackerT is a histogram of the SS read response times, with
+	// buckets growing by 25%. Older samples are aged out by halving the
+	// counts, so the percentiles follow the recent response times.
+	latencyTrackerT struct {
+		counts []uint32
+		total  uint32
+	}
+
+	// hedgeBudgetT limits the percentage of the reads to be hedged, so that
+	// hedging does not add much load when all the SSs are slow.
+	hedgeBudgetT struct {
+		numReads  uint32
+		numHedged uint32
+	}
+)
+
+func init() {
+	for b := kLatencyMinBound; b < kLatencyMaxBound; b += b / 4 {
+		latencyBounds = append(latencyBounds, b)
+	}
+	latencyBounds = append(latencyBounds, kLatencyMaxBound)
+	ssReadLatency.counts = make([]uint32, len(latencyBounds))
+}
+
+func (t *latencyTrackerT) observe(d time.Duration) {
+	i := sort.Search(len(latencyBounds), func(i int) bool { return latencyBounds[i] >= d })
+	if i == len(latencyBounds) {
+		i--
+	}
+	atomic.AddUint32(&t.counts[i], 1)
+	if atomic.AddUint32(&t.total, 1) == kLatencyDecayCount {
+		t.decay()
+	}
+}
+
+func (t *latencyTrackerT) decay() {
+	var total uint32
+	for i := range t.counts {
+		for {
+			c := atomic.LoadUint32(&t.counts[i])
+			if atomic.CompareAndSwapUint32(&t.counts[i], c, c/2) {
+				total += c / 2
+				break
+			}
+		}
+	}
+	atomic.StoreUint32(&t.total, total)
+}
+
+// percentile returns the upper bound of the bucket holding the given
+// percentile, and false if there are not enough samples.
+func (t *latencyTrackerT) percentile(pct float64) (d time.Duration, ok bool) {
+	var total uint64
+	for i := range t.counts {
+		total += uint64(atomic.LoadUint32(&t.counts[i]))
+	}
+	if total < kLatencyMinSamples {
+		return
+	}
+	target := uint64(float64(total) * pct / 100)
+	var sum uint64
+	for i := range t.counts {
+		sum += uint64(atomic.LoadUint32(&t.counts[i]))
+		if sum > target {
+			return latencyBounds[i], true
+		}
+	}
+	return latencyBounds[len(latencyBounds)-1], true
+}
+
+func (b *hedgeBudgetT) onRead() {
+	if atomic.AddUint32(&b.numReads, 1) == kHedgeDecayCount {
+		atomic.StoreUint32(&b.numReads, kHedgeDecayCount/2)
+		atomic.StoreUint32(&b.numHedged, atomic.LoadUint32(&b.numHedged)/2)
+	}
+}
+
+func (b *hedgeBudgetT) allow(maxPercent float64) bool {
+	return float64(atomic.LoadUint32(&b.numHedged))*100 < float64(atomic.LoadUint32(&b.numReads))*maxPercent
+}
+
+func (b *hedgeBudgetT) onHedged() {
+	atomic.AddUint32(&b.numHedged, 1)
+}
+
+// hedgeDelay returns how long to wait for the SS responses of a read before
+// sending it to one more SS.
+func hedgeDelay() time.Duration {
+	d, ok := ssReadLatency.percentile(confHedgedRead.Percentile)
+	if !ok || d > confHedgedRead.MaxDelay.Duration {
+		return confHedgedRead.MaxDelay.Duration
+	}
+	if d < confHedgedRead.MinDelay.Duration {
+		return confHedgedRead.MinDelay.Duration
+	}
+	return d
+}
@@ -0,0 +1,155 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package proc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+func newLatencyTracker() *latencyTrackerT {
+	return &latencyTrackerT{counts: make([]uint32, len(latencyBounds))}
+}
+
+func observeN(t *latencyTrackerT, n int, d time.Duration) {
+	for i := 0; i < n; i++ {
+		t.observe(d)
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	tr := newLatencyTracker()
+	observeN(tr, kLatencyMinSamples-1, time.Millisecond)
+	if _, ok := tr.percentile(50); ok {
+		t.Fatal("expect not enough samples")
+	}
+	observeN(tr, 1, time.Millisecond)
+	observeN(tr, 10, 100*time.Millisecond)
+	observeN(tr, 1, time.Minute) // counted in the last bucket
+
+	for _, tc := range []struct {
+		pct      float64
+		min, max time.Duration
+	}{
+		{50, time.Millisecond, time.Millisecond * 5 / 4},
+		{95, 100 * time.Millisecond, 125 * time.Millisecond},
+		{99.9, kLatencyMaxBound, kLatencyMaxBound},
+	} {
+		d, ok := tr.percentile(tc.pct)
+		if !ok || d < tc.min || d > tc.max {
+			t.Errorf("p%g: expect in [%s, %s], got %s", tc.pct, tc.min, tc.max, d)
+		}
+	}
+}
+
+func TestLatencyDecay(t *testing.T) {
+	tr := newLatencyTracker()
+	observeN(tr, kLatencyDecayCount/2, time.Millisecond)
+	observeN(tr, kLatencyDecayCount/2-1, 100*time.Millisecond)
+	if tr.total != kLatencyDecayCount-1 {
+		t.Fatalf("expect %d samples, got %d", kLatencyDecayCount-1, tr.total)
+	}
+	// the counts are halved once kLatencyDecayCount are observed
+	observeN(tr, 1, 100*time.Millisecond)
+	if tr.total != kLatencyDecayCount/2 {
+		t.Fatalf("expect %d samples after decay, got %d", kLatencyDecayCount/2, tr.total)
+	}
+	var sum uint32
+	for _, c := range tr.counts {
+		sum += c
+	}
+	if sum != tr.total {
+		t.Errorf("counts sum to %d, total %d", sum, tr.total)
+	}
+
+	// the recent samples take over
+	observeN(tr, kLatencyDecayCount/2, 10*time.Millisecond)
+	if d, _ := tr.percentile(50); d < 10*time.Millisecond || d > 13*time.Millisecond {
+		t.Errorf("expect p50 of the recent samples, got %s", d)
+	}
+}
+
+func TestHedgeBudget(t *testing.T) {
+	var b hedgeBudgetT
+	if b.allow(5) {
+		t.Error("expect no hedge before any read")
+	}
+	for i := 0; i < 100; i++ {
+		b.onRead()
+	}
+	for i := 0; i < 4; i++ {
+		if !b.allow(5) {
+			t.Fatalf("expect hedge %d allowed", i)
+		}
+		b.onHedged()
+	}
+	if !b.allow(5) {
+		t.Fatal("expect the 5th hedge allowed")
+	}
+	b.onHedged()
+	if b.allow(5) {
+		t.Error("expect the 6th hedge over the budget")
+	}
+
+	// the counts are halved once kHedgeDecayCount reads are counted
+	for i := 100; i < kHedgeDecayCount; i++ {
+		b.onRead()
+	}
+	if b.numReads != kHedgeDecayCount/2 || b.numHedged != 2 {
+		t.Errorf("expect %d reads and 2 hedged after decay, got %d and %d", kHedgeDecayCount/2, b.numReads, b.numHedged)
+	}
+}
+
+func TestHedgeDelay(t *testing.T) {
+	savedConf, savedTracker := confHedgedRead, ssReadLatency
+	defer func() {
+		confHedgedRead, ssReadLatency = savedConf, savedTracker
+	}()
+	ssReadLatency = *newLatencyTracker()
+
+	setConf := func(min, max time.Duration) {
+		confHedgedRead = config.HedgedReadConfig{
+			Enabled:    true,
+			Percentile: 95,
+			MinDelay:   util.Duration{Duration: min},
+			MaxDelay:   util.Duration{Duration: max},
+		}
+	}
+	setConf(time.Millisecond, 100*time.Millisecond)
+	if d := hedgeDelay(); d != 100*time.Millisecond {
+		t.Errorf("expect MaxDelay without enough samples, got %s", d)
+	}
+
+	observeN(&ssReadLatency, kLatencyMinSamples, 10*time.Millisecond)
+	if d := hedgeDelay(); d < 10*time.Millisecond || d > 13*time.Millisecond {
+		t.Errorf("expect the p95, got %s", d)
+	}
+	setConf(20*time.Millisecond, 100*time.Millisecond)
+	if d := hedgeDelay(); d != 20*time.Millisecond {
+		t.Errorf("expect MinDelay, got %s", d)
+	}
+	setConf(time.Millisecond, 5*time.Millisecond)
+	if d := hedgeDelay(); d != 5*time.Millisecond {
+		t.Errorf("expect MaxDelay, got %s", d)
+	}
+}
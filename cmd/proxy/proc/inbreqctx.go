@@ -90,6 +90,15 @@ func (r *InboundRequestContext) ValidateRequest() bool {
 		return false
 	}
 	ttl := r.GetTimeToLive()
+	if ttl == 0 && r.GetOpCode() == proto.OpCodeTouch {
+		glog.Warningf("0 TTL for touch request")
+		data := logging.NewKVBuffer()
+		data.AddReqIdString(r.GetRequestIDString())
+		data.AddInt([]byte("ttl"), int(ttl))
+		calLogReqProcEvent(kBadParamInvalidTTL, data.Bytes())
+		otel.RecordCount(otel.ReqProc, []otel.Tags{{otel.Status, kBadParamInvalidTTL}})
+		return false
+	}
 	if isReplication {
 		if ttl == 0 && r.GetOpCode() != proto.OpCodeDestroy {
 			glog.Warningf("0 TTL for replication request")
@@ -150,6 +159,7 @@ func (r *InboundRequestContext) ReplicateIfNeeded(opstatus proto.OpStatus, ssres
 
 	if (opstatus == proto.OpStatusNoError || opstatus == proto.OpStatusInconsistent) &&
 		(opcode != proto.OpCodeGet || r.GetTimeToLive() > 0) &&
+		opcode != proto.OpCodeExists && opcode != proto.OpCodeGetMeta &&
 		opmsg.GetCreationTime() != 0 &&
 		opmsg.GetVersion() != 0 {
 		if LOG_VERBOSE {
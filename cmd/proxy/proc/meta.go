@@ -0,0 +1,50 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package proc
+
+import (
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// The metadata-only operations are processed the same way as Get, without
+// payload in the SS responses and without read repair.
+
+func NewExistsProcessor() *GetProcessor {
+	return newMetaProcessor(proto.OpCodeReadExists)
+}
+
+func NewGetMetaProcessor() *GetProcessor {
+	return newMetaProcessor(proto.OpCodeReadMeta)
+}
+
+// NewTouchProcessor returns a processor replacing the TTL of a record on the SSs.
+func NewTouchProcessor() *GetProcessor {
+	return newMetaProcessor(proto.OpCodeSetTTL)
+}
+
+func newMetaProcessor(op proto.OpCode) *GetProcessor {
+	p := &GetProcessor{
+		OnePhaseProcessor: OnePhaseProcessor{
+			ssRequestOpCode: op,
+		},
+	}
+	p.self = p
+	return p
+}
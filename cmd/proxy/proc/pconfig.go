@@ -44,6 +44,9 @@ var (
 	confEncryptionEnabled            bool
 	confReplicationEncryptionEnabled bool
 	confMaxRecordVersion             uint32
+	confActiveActive                 bool
+	confClusterId                    uint32
+	confHedgedRead                   config.HedgedReadConfig
 )
 
 func InitConfig() {
@@ -60,6 +63,9 @@ func InitConfig() {
 	confEncryptionEnabled = config.Conf.PayloadEncryptionEnabled
 	confReplicationEncryptionEnabled = config.Conf.ReplicationEncryptionEnabled
 	confMaxRecordVersion = config.Conf.MaxRecordVersion
+	confActiveActive = config.Conf.Replication.ActiveActive
+	confClusterId = uint32(config.Conf.Replication.ClusterId)
+	confHedgedRead = config.Conf.ReqProc.HedgedRead
 
 	storedcfg, err := readStoredLimits()
 	if err == nil && storedcfg != nil {
@@ -66,6 +66,7 @@ const (
 	kEncrypt        = "Encrypt"
 	kInconsistent   = "Inconsistent"
 	kRecVerOverflow = "RecVerOverflow"
+	kReplicaLoop    = "ReplicaLoop"
 
 	kBadParamInvalidKeyLen   = "BadParam_InvalidKeyLen"
 	kBadParamInvalidNsLen    = "BadParam_invalidNsLen"
@@ -52,6 +52,12 @@ func NewRequestProcessorPool(chansize int32, maxsize int32, op proto.OpCode) *Re
 		case proto.OpCodeUDFSet:
 			p = NewSetProcessor()
 			//p = NewUDFSetProcessor()
+		case proto.OpCodeExists:
+			p = NewExistsProcessor()
+		case proto.OpCodeGetMeta:
+			p = NewGetMetaProcessor()
+		case proto.OpCodeTouch:
+			p = NewTouchProcessor()
 		default:
 			return nil
 		}
@@ -63,11 +69,11 @@ func NewRequestProcessorPool(chansize int32, maxsize int32, op proto.OpCode) *Re
 	switch op {
 	case proto.OpCodeCreate:
 		counter = stats.GetActiveCreateCounter()
-	case proto.OpCodeGet:
+	case proto.OpCodeGet, proto.OpCodeExists, proto.OpCodeGetMeta:
 		counter = stats.GetActiveGetCounter()
 	case proto.OpCodeUpdate:
 		counter = stats.GetActiveUpdateCounter()
-	case proto.OpCodeSet:
+	case proto.OpCodeSet, proto.OpCodeTouch:
 		counter = stats.GetActiveSetCounter()
 	case proto.OpCodeDestroy:
 		counter = stats.GetActiveDestroyCounter()
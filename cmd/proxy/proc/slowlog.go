@@ -0,0 +1,99 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package proc
+
+import (
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/stats/slowlog"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+// logSlowRequest adds the request to the slow log if replied to later than
+// the threshold, with the storage server requests sent so far. The requests
+// of the first phase sent beyond the first confNumWrites ones, except a
+// hedged read, are the retries of the failed ones.
+func (p *ProcessorBase) logSlowRequest() {
+	if !slowlog.Enabled() || p.timeReplied.IsZero() {
+		return
+	}
+	req := &p.clientRequest
+	receiveTime := p.requestContext.GetReceiveTime()
+	rht := p.timeReplied.Sub(receiveTime)
+	if rht < slowlog.Threshold(req.GetOpCode(), req.GetNamespace()) || !slowlog.Sampled() {
+		return
+	}
+	e := &slowlog.Entry{
+		Time:        receiveTime,
+		RequestId:   p.requestID,
+		Op:          req.GetOpCodeText(),
+		Namespace:   string(req.GetNamespace()),
+		Key:         util.ToPrintableString(req.GetKey()),
+		AppName:     string(req.GetAppName()),
+		Replication: req.IsForReplication(),
+		ShardId:     p.shardId,
+		Status:      p.replyStatus.String(),
+		RepliedUs:   rht.Microseconds(),
+		CompletedUs: time.Since(receiveTime).Microseconds(),
+	}
+	if ip := req.GetSrcIP(); ip != nil {
+		e.Source = ip.String()
+	}
+	numFirstPhase := 0
+	for i := 0; i < p.numSSRequestSent; i++ {
+		st := &p.ssRequestContexts[i]
+		c := slowlog.Call{
+			Op:     st.opCode.String(),
+			SentUs: st.timeReqSent.Sub(receiveTime).Microseconds(),
+			Status: slowCallStatus(st),
+			Hedged: st.hedged,
+		}
+		if ss := p.ssGroup.processors[st.ssIndex]; ss != nil {
+			c.SS = ss.Name() + "/" + ss.GetConnInfo()
+		}
+		if !st.timeRespReceived.IsZero() {
+			c.ElapsedUs = st.timeRespReceived.Sub(st.timeReqSent).Microseconds()
+		}
+		if st.opCode == p.ssRequestContexts[0].opCode && !st.hedged {
+			numFirstPhase++
+			c.Retry = numFirstPhase > confNumWrites
+		}
+		e.AddCall(c, st.state == stSSResponseReceived)
+	}
+	slowlog.Add(e)
+}
+
+func slowCallStatus(st *SSRequestContext) string {
+	switch st.state {
+	case stSSResponseReceived:
+		return st.ssResponseOpStatus.String()
+	case stSSRequestSent:
+		return "Pending"
+	case stSSRequestIOError, stSSResponseIOError:
+		return "IOError"
+	case stSSRequestTimeout:
+		return "SSTimeout"
+	case stRequestTimeout:
+		return "RequestTimeout"
+	case stRequestCancelled:
+		return "Cancelled"
+	}
+	return ""
+}
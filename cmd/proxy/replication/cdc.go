@@ -0,0 +1,188 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	repconfig "github.com/paypal/junodb/cmd/proxy/replication/config"
+	"github.com/paypal/junodb/cmd/proxy/stats/shmstats"
+	"github.com/paypal/junodb/pkg/logging"
+	"github.com/paypal/junodb/pkg/logging/cal"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+var (
+	kSinkMaxBatch      = 256
+	kSinkRetryInterval = time.Second
+)
+
+// sinkProcessorT feeds a change data capture sink. The requests to the target
+// are appended to its spill log, and delivered to the sink in batches. The
+// read position is committed once a batch is accepted, so an event is
+// delivered again only if the proxy stops before the commit.
+type sinkProcessorT struct {
+	targetName   string
+	targetIndex  int
+	offsetPrefix string // of the offsets of the change events
+	specNsMap    map[string]bool
+	sink         ISink
+	spill        *spillLog
+	health       *repHealthT
+	healthy      int32
+	numDelivered uint32 // in the current second
+	lastRate     uint32
+	chDone       chan struct{}
+	wg           sync.WaitGroup
+}
+
+func newSinkProcessor(index int, target *repconfig.ReplicationTarget, sink ISink, spill *spillLog) *sinkProcessorT {
+	s := &sinkProcessorT{
+		targetName:   target.Name,
+		targetIndex:  index,
+		offsetPrefix: fmt.Sprintf("%s/%d/", target.Name, workerId),
+		sink:         sink,
+		spill:        spill,
+		health:       newRepHealth(target.Name),
+		healthy:      1,
+		chDone:       make(chan struct{}),
+	}
+	if len(target.Namespaces) != 0 {
+		s.specNsMap = make(map[string]bool)
+		for _, ns := range target.Namespaces {
+			s.specNsMap[ns] = true
+		}
+	}
+	s.wg.Add(1)
+	go s.deliver()
+	return s
+}
+
+func (s *sinkProcessorT) IsReplicable(opMsg *proto.OperationalMessage) bool {
+	if len(s.specNsMap) != 0 {
+		return s.specNsMap[string(opMsg.GetNamespace())]
+	}
+	return true
+}
+
+func (s *sinkProcessorT) replicate(recExpirationTime uint32, msg *proto.RawMessage, dropCnt *util.AtomicShareCounter) {
+	if err := s.spill.AppendMessage(recExpirationTime, msg); err != nil {
+		glog.Warningf("sink %s: drop the req: %s", s.targetName, err.Error())
+		if cal.IsEnabled() {
+			var request proto.OperationalMessage
+			request.Decode(msg)
+			buf := logging.NewKVBuffer()
+			buf.AddOpRequest(&request)
+			cal.Event("RR_Drop_SinkFull", request.GetOpCodeText(), cal.StatusWarning, buf.Bytes())
+		}
+		dropCnt.Add(1)
+	}
+}
+
+func (s *sinkProcessorT) deliver() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(kSpillReplayInterval)
+	defer ticker.Stop()
+	secTicker := time.NewTicker(time.Second)
+	defer secTicker.Stop()
+
+	var batch []*ChangeEvent
+	var nextTry time.Time
+	for {
+		select {
+		case <-s.chDone:
+			return
+		case <-secTicker.C:
+			atomic.StoreUint32(&s.lastRate, atomic.SwapUint32(&s.numDelivered, 0))
+			s.spill.Sync()
+		case now := <-ticker.C:
+			if now.Before(nextTry) {
+				continue
+			}
+			for {
+				if len(batch) == 0 {
+					batch = s.nextBatch()
+				}
+				if len(batch) == 0 {
+					break
+				}
+				if err := s.sink.Write(batch); err != nil {
+					if atomic.SwapInt32(&s.healthy, 0) != 0 {
+						glog.Warningf("sink %s: %s, retry in %s", s.targetName, err.Error(), kSinkRetryInterval)
+					}
+					shmstats.GetCurrentWorkerStatsManager().GetReplicatorErrorCounter(s.targetIndex).Add(1)
+					nextTry = now.Add(kSinkRetryInterval)
+					break
+				}
+				if atomic.SwapInt32(&s.healthy, 1) == 0 {
+					glog.Infof("sink %s: recovered", s.targetName)
+				}
+				atomic.AddUint32(&s.numDelivered, uint32(len(batch)))
+				ackTime := time.Now()
+				for _, ev := range batch {
+					s.health.onAck(int64(ev.LastModified), ackTime)
+				}
+				batch = nil
+				if err := s.spill.Commit(); err != nil {
+					glog.Warningf("sink %s: cannot commit: %s", s.targetName, err.Error())
+				}
+			}
+		}
+	}
+}
+
+// nextBatch consumes up to kSinkMaxBatch entries from the spill log. A batch
+// does not span segments, as a segment is removed once read past its end.
+func (s *sinkProcessorT) nextBatch() (batch []*ChangeEvent) {
+	for len(batch) < kSinkMaxBatch {
+		if len(batch) != 0 && s.spill.AtSegmentEnd() {
+			break
+		}
+		entry, ok := s.spill.Peek()
+		if !ok {
+			break
+		}
+		s.spill.Advance()
+		ev, err := newChangeEvent(s.offsetPrefix, entry)
+		if err != nil {
+			glog.Warningf("sink %s: skip bad entry %d.%d: %s", s.targetName, entry.seq, entry.offset, err.Error())
+			continue
+		}
+		batch = append(batch, ev)
+	}
+	return
+}
+
+func (s *sinkProcessorT) Shutdown() {
+	close(s.chDone)
+	s.wg.Wait()
+}
+
+func (s *sinkProcessorT) WaitShutdown() {
+	s.sink.Close()
+	s.spill.Close()
+}
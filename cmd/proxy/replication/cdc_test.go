@@ -0,0 +1,138 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	repconfig "github.com/paypal/junodb/cmd/proxy/replication/config"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func appendTestRequest(t *testing.T, l *spillLog, key string, value string) {
+	var payload proto.Payload
+	payload.SetWithClearValue([]byte(value))
+	var op proto.OperationalMessage
+	op.SetRequest(proto.OpCodeSet, []byte(key), []byte("ns"), &payload, 100)
+	op.SetVersion(2)
+	var raw proto.RawMessage
+	if err := op.Encode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AppendMessage(0, &raw); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	target := &repconfig.ReplicationTarget{Name: "cdc", Sink: repconfig.SinkFile, SinkFormat: repconfig.SinkFormatJSON}
+	target.Addr = filepath.Join(dir, "events.jsonl")
+
+	spillDir := filepath.Join(dir, "spill")
+	l, err := newSpillLog(spillDir, 1<<20, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := newSink(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newSinkProcessor(0, target, sink, l)
+	appendTestRequest(t, l, "k1", "v1")
+	appendTestRequest(t, l, "k2", "v2")
+
+	for i := 0; i < 100 && !l.Empty(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	p.Shutdown()
+	p.WaitShutdown()
+
+	f, err := os.Open(target.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var events []ChangeEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev ChangeEvent
+		if err = json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 2 || string(events[0].Key) != "k1" || string(events[1].Value) != "v2" ||
+		events[1].Namespace != "ns" || events[1].Version != 2 || events[0].Offset == events[1].Offset ||
+		!strings.HasPrefix(events[0].Offset, target.Name+"/0/") {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	// the delivered events are not replayed
+	if l, err = newSpillLog(spillDir, 1<<20, 1<<20, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if !l.Empty() {
+		t.Error("expect the committed entries to be skipped after reload")
+	}
+}
+
+func TestProtoEvent(t *testing.T) {
+	ev := &ChangeEvent{Offset: "cdc/0/0.0", Op: "Set", Namespace: "ns", Key: []byte("k"), Value: []byte("v"), Version: 3}
+	var buf bytes.Buffer
+	encodeProtoEvent(&buf, ev)
+
+	b := buf.Bytes()
+	sz, n := protowire.ConsumeVarint(b)
+	if n < 0 || int(sz) != len(b)-n {
+		t.Fatalf("bad length prefix %d", sz)
+	}
+	b = b[n:]
+	fields := map[protowire.Number][]byte{}
+	var version uint64
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			fields[num] = v
+			b = b[n:]
+		} else {
+			v, n := protowire.ConsumeVarint(b)
+			if num == 7 {
+				version = v
+			}
+			b = b[n:]
+		}
+	}
+	if string(fields[2]) != "Set" || string(fields[4]) != "k" || string(fields[5]) != "v" || version != 3 {
+		t.Errorf("unexpected fields %v, version %d", fields, version)
+	}
+}
@@ -31,6 +31,13 @@ import (
 var (
 	kDefaultName = "default"
 
+	SinkFile    = "file"
+	SinkUnix    = "unix"
+	SinkWebhook = "webhook"
+
+	SinkFormatJSON  = "json"
+	SinkFormatProto = "proto"
+
 	kDefaultReplicationIoConfig = io.OutboundConfig{
 		ConnectTimeout:        util.Duration{1 * time.Second},
 		ReqChanBufSize:        8092,
@@ -45,8 +52,21 @@ var (
 		EnableConnRecycle:     true,
 		GracefulShutdownTime:  util.Duration{2 * time.Second},
 	}
+	DefaultSpillConfig = SpillConfig{
+		Dir:         "./rep_spill",
+		SegmentSize: 64 * 1024 * 1024,
+		MaxBytes:    4 * 1024 * 1024 * 1024,
+		MaxAge:      util.Duration{24 * time.Hour},
+		ReplayRate:  5000,
+	}
+	DefaultAlarmConfig = AlarmConfig{
+		MaxLag:       util.Duration{60 * time.Second},
+		MaxNoSuccess: util.Duration{60 * time.Second},
+	}
 	DefaultConfig = Config{
-		IO: io.OutboundConfigMap{kDefaultName: kDefaultReplicationIoConfig},
+		IO:    io.OutboundConfigMap{kDefaultName: kDefaultReplicationIoConfig},
+		Spill: DefaultSpillConfig,
+		Alarm: DefaultAlarmConfig,
 	}
 )
 
@@ -57,11 +77,45 @@ type (
 		UseMayflyProtocol bool
 		Namespaces        []string
 		BypassLTMEnabled  bool
+		SpillEnabled      bool // SpillEnabled keeps the requests that cannot be queued on disk until the target is back
+
+		// Sink, if set, sends the change events to a local sink instead of a
+		// proxy: "file" appends to the file at Addr, "unix" writes to the UNIX
+		// socket at Addr, and "webhook" posts to the URL at Addr. The events
+		// are queued in the spill log and delivered at least once.
+		Sink       string
+		SinkFormat string // "json" for JSON lines (default), or "proto" for length-delimited protobuf
+	}
+
+	// SpillConfig configures the on-disk spill logs of the targets with SpillEnabled.
+	// The log of a target is kept in Dir/<target name>/<worker id>.
+	SpillConfig struct {
+		Dir         string
+		SegmentSize int64         // SegmentSize is the size at which a new segment file is started
+		MaxBytes    int64         // MaxBytes caps the size of a log. The oldest segments are dropped first
+		MaxAge      util.Duration // MaxAge is how long requests are kept. Older segments are dropped
+		ReplayRate  int           // ReplayRate is the maximum number of requests replayed per second
+	}
+
+	// AlarmConfig sets the thresholds above which an alarm is raised for a
+	// target. A zero value disables the check.
+	AlarmConfig struct {
+		MaxLag       util.Duration // MaxLag is the end-to-end replication lag
+		MaxQueued    int           // MaxQueued is the number of requests queued or in flight
+		MaxNoSuccess util.Duration // MaxNoSuccess is the time since the last ack while requests are pending
 	}
 
 	Config struct {
 		Targets []ReplicationTarget
 		IO      io.OutboundConfigMap
+		Spill   SpillConfig
+		Alarm   AlarmConfig
+
+		// ActiveActive enables bidirectional replication. Writes are stamped
+		// with ClusterId, which must be non-zero and unique among the peers,
+		// and conflicts are resolved with last-writer-wins.
+		ActiveActive bool
+		ClusterId    uint16
 	}
 )
 
@@ -79,7 +133,7 @@ func (c *Config) GetIoConfig(target *ReplicationTarget) *io.OutboundConfig {
 	return &kDefaultReplicationIoConfig
 }
 
-func (c *Config) Validate() {
+func (c *Config) Validate() (err error) {
 
 	for i := len(c.Targets) - 1; i >= 0; i-- {
 		t := &c.Targets[i]
@@ -92,7 +146,45 @@ func (c *Config) Validate() {
 			if len(t.Network) == 0 {
 				c.Targets[i].Network = "tcp"
 			}
+			if len(t.Sink) != 0 {
+				if t.Sink != SinkFile && t.Sink != SinkUnix && t.Sink != SinkWebhook {
+					err = fmt.Errorf("Replication target %s: unsupported sink '%s'", t.Name, t.Sink)
+				}
+				if len(t.SinkFormat) == 0 {
+					c.Targets[i].SinkFormat = SinkFormatJSON
+				} else if t.SinkFormat != SinkFormatJSON && t.SinkFormat != SinkFormatProto {
+					err = fmt.Errorf("Replication target %s: unsupported sink format '%s'", t.Name, t.SinkFormat)
+				}
+			}
 		}
 	}
 	c.IO.SetDefaultIfNotDefined()
+	c.Spill.SetDefaultIfNotDefined()
+	if err == nil && c.ActiveActive && c.ClusterId == 0 {
+		err = fmt.Errorf("Replication.ClusterId is required for active-active replication")
+	}
+	return
+}
+
+// HasSpill returns true if the requests to the target go through a spill log.
+func (t *ReplicationTarget) HasSpill() bool {
+	return t.SpillEnabled || len(t.Sink) != 0
+}
+
+func (c *SpillConfig) SetDefaultIfNotDefined() {
+	if len(c.Dir) == 0 {
+		c.Dir = DefaultSpillConfig.Dir
+	}
+	if c.SegmentSize <= 0 {
+		c.SegmentSize = DefaultSpillConfig.SegmentSize
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = DefaultSpillConfig.MaxBytes
+	}
+	if c.MaxAge.Duration <= 0 {
+		c.MaxAge = DefaultSpillConfig.MaxAge
+	}
+	if c.ReplayRate <= 0 {
+		c.ReplayRate = DefaultSpillConfig.ReplayRate
+	}
 }
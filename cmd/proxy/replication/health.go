@@ -0,0 +1,185 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	repconfig "github.com/paypal/junodb/cmd/proxy/replication/config"
+	"github.com/paypal/junodb/cmd/proxy/stats/shmstats"
+	"github.com/paypal/junodb/pkg/logging"
+	"github.com/paypal/junodb/pkg/logging/cal"
+	"github.com/paypal/junodb/pkg/logging/otel"
+)
+
+// repHealthT tracks the end-to-end lag, the requests in flight and the
+// throughput of a replication target. The lag of a request is the time from
+// its commit at the source, i.e. its last modification time, to the ack of
+// the target. The counters are updated by the IO goroutines, and summarized
+// once a second by tick.
+type repHealthT struct {
+	targetName  string
+	numInFlight int32
+	numAcked    uint32 // since the last tick
+	maxLag      int64  // max lag in ns of the requests acked since the last tick
+	lastSuccess int64  // unix time in ns of the last ack
+
+	// owned by tick
+	lastTick time.Time
+	lag      time.Duration
+	ackRate  uint32
+	alarms   uint16
+}
+
+// repHealthStatsT is the summary of a repHealthT for the stats.
+type repHealthStatsT struct {
+	lag         time.Duration
+	numInFlight uint32
+	numQueued   int
+	ackRate     uint32
+	lastSuccess time.Time
+	alarms      uint16
+}
+
+func newRepHealth(targetName string) *repHealthT {
+	return &repHealthT{targetName: targetName, lastTick: time.Now()}
+}
+
+func (st *repHealthStatsT) lagMs() uint32 {
+	return uint32(st.lag / time.Millisecond)
+}
+
+func (st *repHealthStatsT) lastSuccessUnix() int64 {
+	if st.lastSuccess.IsZero() {
+		return 0
+	}
+	return st.lastSuccess.Unix()
+}
+
+func (h *repHealthT) onSent() {
+	atomic.AddInt32(&h.numInFlight, 1)
+}
+
+func (h *repHealthT) onDone() {
+	atomic.AddInt32(&h.numInFlight, -1)
+}
+
+// onAck records the ack of a request committed at commitTime, in unix ns.
+func (h *repHealthT) onAck(commitTime int64, now time.Time) {
+	atomic.AddUint32(&h.numAcked, 1)
+	t := now.UnixNano()
+	atomic.StoreInt64(&h.lastSuccess, t)
+	if commitTime <= 0 || commitTime > t {
+		return
+	}
+	lag := t - commitTime
+	for {
+		cur := atomic.LoadInt64(&h.maxLag)
+		if lag <= cur || atomic.CompareAndSwapInt64(&h.maxLag, cur, lag) {
+			return
+		}
+	}
+}
+
+// tick summarizes the counters since the last call. numQueued is the number of
+// requests waiting to be sent, and spillAge the age of the oldest spilled
+// request. If no request is acked while some are pending, the lag keeps
+// growing, so that a stalled target does not look caught up.
+func (h *repHealthT) tick(now time.Time, numQueued int, spillAge time.Duration,
+	conf *repconfig.AlarmConfig) (st repHealthStatsT) {
+	elapsed := now.Sub(h.lastTick)
+	h.lastTick = now
+
+	numAcked := atomic.SwapUint32(&h.numAcked, 0)
+	maxLag := time.Duration(atomic.SwapInt64(&h.maxLag, 0))
+	if secs := elapsed.Seconds(); secs > 0 {
+		h.ackRate = uint32(float64(numAcked)/secs + 0.5)
+	}
+	numInFlight := atomic.LoadInt32(&h.numInFlight)
+	if numInFlight < 0 {
+		numInFlight = 0
+	}
+	pending := int(numInFlight) + numQueued
+	if numAcked != 0 {
+		h.lag = maxLag
+	} else if pending != 0 || spillAge != 0 {
+		h.lag += elapsed
+	} else {
+		h.lag = 0
+	}
+	if h.lag < spillAge {
+		h.lag = spillAge
+	}
+
+	st.lag = h.lag
+	st.numInFlight = uint32(numInFlight)
+	st.numQueued = numQueued
+	st.ackRate = h.ackRate
+	if t := atomic.LoadInt64(&h.lastSuccess); t != 0 {
+		st.lastSuccess = time.Unix(0, t)
+	}
+
+	var alarms uint16
+	if conf.MaxLag.Duration > 0 && st.lag > conf.MaxLag.Duration {
+		alarms |= shmstats.RepAlarmLag
+	}
+	if conf.MaxQueued > 0 && pending > conf.MaxQueued {
+		alarms |= shmstats.RepAlarmQueue
+	}
+	if conf.MaxNoSuccess.Duration > 0 && (pending != 0 || spillAge != 0) &&
+		!st.lastSuccess.IsZero() && now.Sub(st.lastSuccess) > conf.MaxNoSuccess.Duration {
+		alarms |= shmstats.RepAlarmNoSuccess
+	}
+	if alarms != h.alarms {
+		h.onAlarmChange(h.alarms, alarms, &st)
+		h.alarms = alarms
+	}
+	st.alarms = alarms
+	return
+}
+
+func (h *repHealthT) onAlarmChange(prev uint16, alarms uint16, st *repHealthStatsT) {
+	detail := fmt.Sprintf("lag=%s inflight=%d queued=%d", st.lag.Truncate(time.Millisecond), st.numInFlight, st.numQueued)
+	if raised := alarms &^ prev; raised != 0 {
+		name := shmstats.RepAlarmText(raised)
+		glog.Warningf("target %s: replication alarm %s raised, %s", h.targetName, name, detail)
+		if cal.IsEnabled() {
+			buf := logging.NewKVBuffer()
+			buf.Add([]byte("alarm"), name)
+			buf.Add([]byte("detail"), detail)
+			cal.Event("RR_Alarm_"+h.targetName, name, cal.StatusWarning, buf.Bytes())
+		}
+		otel.RecordCount(otel.RRAlarm, []otel.Tags{{otel.Target, h.targetName}, {otel.Status, name}})
+	}
+	if cleared := prev &^ alarms; cleared != 0 {
+		name := shmstats.RepAlarmText(cleared)
+		glog.Infof("target %s: replication alarm %s cleared, %s", h.targetName, name, detail)
+		if cal.IsEnabled() {
+			buf := logging.NewKVBuffer()
+			buf.Add([]byte("alarm"), name)
+			buf.Add([]byte("detail"), detail)
+			cal.Event("RR_Alarm_"+h.targetName, name, cal.StatusSuccess, buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"testing"
+	"time"
+
+	repconfig "github.com/paypal/junodb/cmd/proxy/replication/config"
+	"github.com/paypal/junodb/cmd/proxy/stats/shmstats"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+func TestRepHealth(t *testing.T) {
+	conf := &repconfig.AlarmConfig{
+		MaxLag:       util.Duration{Duration: 5 * time.Second},
+		MaxQueued:    10,
+		MaxNoSuccess: util.Duration{Duration: 10 * time.Second},
+	}
+	h := newRepHealth("dr")
+	now := h.lastTick
+
+	for i := 0; i < 4; i++ {
+		h.onSent()
+	}
+	for i := 1; i <= 2; i++ {
+		h.onAck(now.Add(-time.Duration(i)*time.Second).UnixNano(), now)
+		h.onDone()
+	}
+	now = now.Add(time.Second)
+	st := h.tick(now, 3, 0, conf)
+	if st.lag != 2*time.Second || st.ackRate != 2 || st.numInFlight != 2 || st.alarms != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	// no ack while requests are pending: the lag keeps growing
+	now = now.Add(4 * time.Second)
+	if st = h.tick(now, 20, 0, conf); st.lag != 6*time.Second || st.alarms != shmstats.RepAlarmLag|shmstats.RepAlarmQueue {
+		t.Fatalf("expect lag and queue alarms, got %+v", st)
+	}
+	now = now.Add(10 * time.Second)
+	if st = h.tick(now, 0, 0, conf); st.alarms&shmstats.RepAlarmNoSuccess == 0 || st.alarms&shmstats.RepAlarmQueue != 0 {
+		t.Fatalf("expect no success alarm, got %+v", st)
+	}
+
+	// caught up
+	h.onDone()
+	h.onDone()
+	now = now.Add(time.Second)
+	if st = h.tick(now, 0, 0, conf); st.lag != 0 || st.alarms != 0 {
+		t.Fatalf("expect the alarms cleared, got %+v", st)
+	}
+
+	// the age of the spill backlog is a lower bound of the lag
+	now = now.Add(time.Second)
+	if st = h.tick(now, 0, 30*time.Second, conf); st.lag != 30*time.Second || st.alarms&shmstats.RepAlarmLag == 0 {
+		t.Fatalf("expect the spill age as lag, got %+v", st)
+	}
+}
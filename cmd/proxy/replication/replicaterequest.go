@@ -22,6 +22,7 @@ package replication
 import (
 	"context"
 	goio "io"
+	"sync/atomic"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -42,11 +43,15 @@ var (
 
 	_ repReqCtxCreatorI = (*repReqCreatorT)(nil)
 	_ repReqCtxCreatorI = (*mayflyRepReqCreatorT)(nil)
+	_ replayedRequestI  = (*RepRequestContext)(nil)
+	_ replayedRequestI  = (*mayflyRepRequestT)(nil)
 )
 
 type (
 	repReqCreatorT struct {
 		targetId string
+		spill    *spillLog
+		health   *repHealthT
 	}
 
 	RepRequestContext struct {
@@ -56,12 +61,16 @@ type (
 		try_cnt           int32
 		max_retry         int32
 		timeReceived      time.Time
+		commitTime        int64                   // unix time in ns the request was committed at the source
 		recExpirationTime uint32                  //Unix Timestamp
 		reqCh             chan io.IRequestContext // channel for retry
 		calBuf            *logging.KeyValueBuffer
 		this              io.IRequestContext
 		dropCnt           *util.AtomicShareCounter
 		errCnt            *util.AtomicShareCounter
+		spill             *spillLog // nil if spill is not enabled for the target
+		health            *repHealthT
+		replaying         *int32 // non-nil if replayed from the spill log
 	}
 
 	mayflyRepRequestT struct {
@@ -72,13 +81,15 @@ type (
 		targetId string
 		ip       uint32
 		port     uint16
+		spill    *spillLog
+		health   *repHealthT
 	}
 )
 
 // xuli: revisit. may be better to set deadline when adding it to ringbuffer. Race condition may still exist.
 // Need to consider how to make it consistant for outbound connection to SS and replication targets
-func (r *repReqCreatorT) newRequestContext(recExpirationTime uint32, msg *proto.RawMessage, reqCh chan io.IRequestContext,
-	dropCnt *util.AtomicShareCounter, errCnt *util.AtomicShareCounter) io.IRequestContext {
+func (r *repReqCreatorT) newRequestContext(recExpirationTime uint32, commitTime uint64, msg *proto.RawMessage,
+	reqCh chan io.IRequestContext, dropCnt *util.AtomicShareCounter, errCnt *util.AtomicShareCounter) io.IRequestContext {
 	ctx := &RepRequestContext{
 		targetId:          r.targetId,
 		try_cnt:           1,
@@ -87,7 +98,10 @@ func (r *repReqCreatorT) newRequestContext(recExpirationTime uint32, msg *proto.
 		reqCh:             reqCh,
 		dropCnt:           dropCnt,
 		errCnt:            errCnt,
+		spill:             r.spill,
+		health:            r.health,
 	}
+	ctx.setCommitTime(commitTime)
 	ctx.this = ctx
 	ctx.SetQueTimeout(REPLICATION_RESP_TIMEOUT)
 	ctx.message.DeepCopy(msg)
@@ -100,6 +114,16 @@ func (r *repReqCreatorT) newRequestContext(recExpirationTime uint32, msg *proto.
 	return ctx
 }
 
+// setCommitTime sets the time the lag is measured from. The receive time is
+// used if the last modification time is not known.
+func (r *RepRequestContext) setCommitTime(lastModificationTime uint64) {
+	if lastModificationTime != 0 {
+		r.commitTime = int64(lastModificationTime)
+	} else {
+		r.commitTime = r.timeReceived.UnixNano()
+	}
+}
+
 func (r *repReqCreatorT) newKeepAliveRequestContext() io.IRequestContext {
 	ctx := &keepAliveRequestContextT{}
 	opmsg := &proto.OperationalMessage{}
@@ -149,9 +173,21 @@ func (r *RepRequestContext) complete(calstatus string, opStatus string, rht time
 
 	otel.RecordReplication(opCode, opStatus, target, rht.Microseconds())
 
+	if r.health != nil {
+		r.health.onDone()
+	}
+	if r.replaying != nil {
+		atomic.AddInt32(r.replaying, -1)
+	}
 	r.this.OnComplete()
 }
 
+// setReplaying has the request decrement cnt once it is acked, dropped or
+// spilled again.
+func (r *RepRequestContext) setReplaying(cnt *int32) {
+	r.replaying = cnt
+}
+
 func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 
 	var retry int32 = 0
@@ -178,8 +214,12 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 	glog.Verbosef("receiving replication response: status=%d opstatus=%d",
 		status, opstatus)
 
+	// the target could not take the request, as opposed to the record being
+	// locked by another write on the target
+	unavailable := false
 	if status != proto.StatusOk {
 		retry = MAX_RETRY
+		unavailable = true
 		statusText = proto.StatusText(int(status))
 		calStatusText = cal.StatusError
 	} else {
@@ -191,6 +231,7 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 			opstatus == proto.OpStatusReqProcTimeout ||
 			opstatus == proto.OpStatusBusy {
 			retry = MAX_RETRY
+			unavailable = true
 		}
 	}
 
@@ -203,6 +244,9 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 
 	resp.OnComplete()
 	if retry == 0 {
+		if r.health != nil {
+			r.health.onAck(r.commitTime, time.Now())
+		}
 		r.complete(calStatusText, opstatus.String(), rht, opCodeText, r.targetId)
 		return
 	}
@@ -212,6 +256,10 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 	}
 
 	if r.try_cnt >= (r.max_retry + 1) {
+		// keep the request for replay if the target could not take it
+		if unavailable && r.spillOnDrop("MaxRetry", target, opCodeText, rht, opstatus.String()) {
+			return
+		}
 		glog.Infof("max rep retry (%d) reached, drop req", r.try_cnt-1)
 		if cal.IsEnabled() {
 			var evType string = string("RR_Drop_MaxRetry") + target
@@ -234,6 +282,9 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 		select {
 		case r.reqCh <- r.this:
 		default:
+			if r.spillOnDrop("QueueFull", target, opCodeText, rht, opstatus.String()) {
+				return
+			}
 			glog.Infof("replication queue full, drop the req, id=%d", r.this.GetId())
 			if cal.IsEnabled() {
 				var evType string = string("RR_Drop_QueueFull") + target
@@ -261,6 +312,28 @@ func (r *RepRequestContext) Reply(resp io.IResponseContext) {
 	}
 }
 
+// spillOnDrop appends the request to the spill log of the target instead of
+// dropping it. It returns false if spill is not enabled or the append failed.
+func (r *RepRequestContext) spillOnDrop(reason string, target string, opCodeText string,
+	rht time.Duration, opStatus string) bool {
+	if r.spill == nil {
+		return false
+	}
+	if err := r.spill.AppendMessage(r.recExpirationTime, &r.message); err != nil {
+		glog.Warningf("target %s: cannot spill the req: %s", r.targetId, err.Error())
+		return false
+	}
+	if cal.IsEnabled() {
+		if !otel.IsEnabled() {
+			cal.Event("RR_Spill_"+reason+target, opCodeText, cal.StatusWarning, r.calBuf.Bytes())
+		}
+		r.calBuf.AddDropReason("Spill" + reason)
+	}
+	otel.RecordCount(otel.RRSpill, []otel.Tags{{otel.Target, r.targetId}, {otel.Status, reason}})
+	r.complete(cal.StatusWarning, opStatus, rht, opCodeText, r.targetId)
+	return true
+}
+
 func (r *RepRequestContext) OnComplete() {
 	r.message.ReleaseBuffer()
 }
@@ -281,7 +354,7 @@ func (r *RepRequestContext) GetReceiveTime() time.Time {
 	return r.timeReceived
 }
 
-func (c *mayflyRepReqCreatorT) newRequestContext(recExpirationTime uint32, msg *proto.RawMessage,
+func (c *mayflyRepReqCreatorT) newRequestContext(recExpirationTime uint32, commitTime uint64, msg *proto.RawMessage,
 	reqCh chan io.IRequestContext, dropCnt *util.AtomicShareCounter, errCnt *util.AtomicShareCounter) io.IRequestContext {
 	r := &mayflyRepRequestT{
 		RepRequestContext: RepRequestContext{
@@ -292,8 +365,11 @@ func (c *mayflyRepReqCreatorT) newRequestContext(recExpirationTime uint32, msg *
 			reqCh:             reqCh,
 			dropCnt:           dropCnt,
 			errCnt:            errCnt,
+			spill:             c.spill,
+			health:            c.health,
 		},
 	}
+	r.setCommitTime(commitTime)
 	r.this = r
 	r.message.DeepCopy(msg)
 	r.SetQueTimeout(REPLICATION_RESP_TIMEOUT)
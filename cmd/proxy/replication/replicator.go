@@ -20,12 +20,15 @@
 package replication
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
+	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -36,6 +39,7 @@ import (
 	"github.com/paypal/junodb/pkg/io"
 	"github.com/paypal/junodb/pkg/logging"
 	"github.com/paypal/junodb/pkg/logging/cal"
+	"github.com/paypal/junodb/pkg/logging/otel"
 	"github.com/paypal/junodb/pkg/proto"
 	"github.com/paypal/junodb/pkg/util"
 )
@@ -45,6 +49,9 @@ var (
 	TheReplicator *Replicator
 	initOnce      sync.Once
 	enabled       bool = false
+	workerId      int
+
+	kSpillReplayInterval = 10 * time.Millisecond
 )
 
 type (
@@ -57,18 +64,37 @@ type (
 	Replicator struct {
 		conf       *repconfig.Config
 		processors []*replicationProcessorT
+		sinks      []*sinkProcessorT
 	}
 	repReqCtxCreatorI interface {
-		newRequestContext(recExpirationTime uint32, msg *proto.RawMessage, reqCh chan io.IRequestContext,
+		newRequestContext(recExpirationTime uint32, commitTime uint64, msg *proto.RawMessage, reqCh chan io.IRequestContext,
 			dropCnt *util.AtomicShareCounter, errCnt *util.AtomicShareCounter) io.IRequestContext
 		newKeepAliveRequestContext() io.IRequestContext
 	}
 
+	// replayedRequestI is implemented by the request contexts that track the
+	// completion of the requests replayed from the spill log.
+	replayedRequestI interface {
+		setReplaying(cnt *int32)
+	}
+
 	replicationProcessorT struct {
 		io.OutboundProcessor
 		reqCtxCreator repReqCtxCreatorI
 		specNsMap     map[string]bool
 		byPassLTM     bool
+		targetName    string
+		targetIndex   int
+		health        *repHealthT
+
+		spill         *spillLog // nil if spill is not enabled for the target
+		bootstrapping int32     // hold the requests in the spill while the target is bootstrapped
+		replayRate    int
+		numReplayed   uint32 // in the current second
+		numReplaying  int32  // replayed requests not completed yet
+		lastRate      uint32
+		chSpillDone   chan struct{}
+		spillReplayWg sync.WaitGroup
 	}
 )
 
@@ -132,6 +158,14 @@ func Initialize(args ...interface{}) (err error) {
 		glog.Error(err)
 		return
 	}
+	if sz > 1 {
+		// the worker id, to keep the spill logs of the workers apart
+		if workerId, ok = args[1].(int); !ok {
+			err = fmt.Errorf("wrong argument type")
+			glog.Error(err)
+			return
+		}
+	}
 	err = Init(conf)
 	return
 }
@@ -168,12 +202,31 @@ func newReplicator(conf *repconfig.Config) (r *Replicator, err error) {
 	}
 
 	r = &Replicator{
-		conf:       conf,
-		processors: make([]*replicationProcessorT, num),
+		conf: conf,
 	}
 
 	for i, target := range conf.Targets {
-		r.processors[i] = newReplicationProcessor(&target, conf.GetIoConfig(&target))
+		var spill *spillLog
+		if target.HasSpill() {
+			dir := filepath.Join(conf.Spill.Dir, target.Name, strconv.Itoa(workerId))
+			if spill, err = newSpillLog(dir, conf.Spill.SegmentSize, conf.Spill.MaxBytes, conf.Spill.MaxAge.Duration); err != nil {
+				err = fmt.Errorf("target %s: cannot open spill log: %s", target.Name, err.Error())
+				r.Shutdown()
+				return nil, err
+			}
+		}
+		if len(target.Sink) != 0 {
+			var sink ISink
+			if sink, err = newSink(&target); err != nil {
+				err = fmt.Errorf("target %s: cannot open sink: %s", target.Name, err.Error())
+				spill.Close()
+				r.Shutdown()
+				return nil, err
+			}
+			r.sinks = append(r.sinks, newSinkProcessor(i, &target, sink, spill))
+			continue
+		}
+		r.processors = append(r.processors, newReplicationProcessor(i, &target, conf.GetIoConfig(&target), spill, conf.Spill.ReplayRate))
 	}
 
 	return r, nil
@@ -186,29 +239,64 @@ func (r *Replicator) SendRequest(opMsg *proto.OperationalMessage) { //expiration
 	mgr := shmstats.GetCurrentWorkerStatsManager()
 	expirationTime := opMsg.GetExpirationTime()
 
-	for i, processor := range r.processors {
-		dropCnt := mgr.GetReplicatorDropCounter(i)
-		errCnt := mgr.GetReplicatorErrorCounter(i)
+	for _, processor := range r.processors {
+		dropCnt := mgr.GetReplicatorDropCounter(processor.targetIndex)
+		errCnt := mgr.GetReplicatorErrorCounter(processor.targetIndex)
 		if processor.IsReplicable(opMsg) {
 			// deep copy for each replication destination
-			processor.replicate(expirationTime, &msg, dropCnt, errCnt)
+			processor.replicate(expirationTime, opMsg.GetLastModificationTime(), &msg, dropCnt, errCnt)
+		}
+	}
+	for _, sink := range r.sinks {
+		if sink.IsReplicable(opMsg) {
+			sink.replicate(expirationTime, &msg, mgr.GetReplicatorDropCounter(sink.targetIndex))
 		}
 	}
 	msg.ReleaseBuffer()
 }
 
+// SetBootstrap starts or ends holding the requests to the target in its spill
+// log, so that they are replayed after the snapshot sent to the target.
+func (r *Replicator) SetBootstrap(target string, on bool) error {
+	for _, p := range r.processors {
+		if p.targetName != target {
+			continue
+		}
+		if p.spill == nil {
+			return fmt.Errorf("spill not enabled for target %s", target)
+		}
+		var v int32
+		if on {
+			v = 1
+		}
+		if atomic.SwapInt32(&p.bootstrapping, v) != v {
+			glog.Infof("target %s: bootstrapping=%t", target, on)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown target %s", target)
+}
+
 func (r *Replicator) Shutdown() {
 	for _, processor := range r.processors {
 		processor.Shutdown()
 	}
+	for _, sink := range r.sinks {
+		sink.Shutdown()
+	}
 
 	for _, processor := range r.processors {
 		processor.WaitShutdown()
 	}
+	for _, sink := range r.sinks {
+		sink.WaitShutdown()
+	}
 }
 
-func newReplicationProcessor(target *repconfig.ReplicationTarget, iocfg *io.OutboundConfig) *replicationProcessorT {
+func newReplicationProcessor(index int, target *repconfig.ReplicationTarget, iocfg *io.OutboundConfig,
+	spill *spillLog, replayRate int) *replicationProcessorT {
 	var reqCtxCreator repReqCtxCreatorI
+	health := newRepHealth(target.Name)
 	if target.UseMayflyProtocol {
 		var ipUint32 uint32
 		var port uint16
@@ -227,12 +315,12 @@ func newReplicationProcessor(target *repconfig.ReplicationTarget, iocfg *io.Outb
 			}
 		}
 		if ipUint32 != 0 && port != 0 {
-			reqCtxCreator = &mayflyRepReqCreatorT{targetId: target.Name, ip: ipUint32, port: port}
+			reqCtxCreator = &mayflyRepReqCreatorT{targetId: target.Name, ip: ipUint32, port: port, spill: spill, health: health}
 		} else {
 			glog.Error("invalid ip and/or port")
 		}
 	} else {
-		reqCtxCreator = &repReqCreatorT{targetId: target.Name}
+		reqCtxCreator = &repReqCreatorT{targetId: target.Name, spill: spill, health: health}
 	}
 
 	var nsMap map[string]bool
@@ -246,11 +334,21 @@ func newReplicationProcessor(target *repconfig.ReplicationTarget, iocfg *io.Outb
 	p := &replicationProcessorT{
 		reqCtxCreator: reqCtxCreator,
 		specNsMap:     nsMap,
+		targetName:    target.Name,
+		targetIndex:   index,
+		health:        health,
+		spill:         spill,
+		replayRate:    replayRate,
 	}
 	p.Init(target.ServiceEndpoint, iocfg, false)
 	p.SetConnEventHandler(p)
 	p.byPassLTM = target.BypassLTMEnabled
 	p.Start()
+	if spill != nil {
+		p.chSpillDone = make(chan struct{})
+		p.spillReplayWg.Add(1)
+		go p.replaySpill()
+	}
 
 	return p
 }
@@ -266,12 +364,30 @@ func (r *replicationProcessorT) IsReplicable(opMsg *proto.OperationalMessage) bo
 	return true
 }
 
-func (r *replicationProcessorT) replicate(recExpirationTime uint32, msg *proto.RawMessage,
+func (r *replicationProcessorT) replicate(recExpirationTime uint32, commitTime uint64, msg *proto.RawMessage,
 	dropCnt *util.AtomicShareCounter, errCnt *util.AtomicShareCounter) {
-	req := r.reqCtxCreator.newRequestContext(recExpirationTime, msg, r.GetRequestCh(), dropCnt, errCnt)
+	if r.spill != nil {
+		if r.isBootstrapping() {
+			if r.spillRequest(recExpirationTime, msg, "Bootstrap") {
+				return
+			}
+		} else if r.hasBacklog() {
+			// queue behind the backlog to keep the order
+			if r.spillRequest(recExpirationTime, msg, "Backlog") {
+				return
+			}
+		}
+	}
+	req := r.reqCtxCreator.newRequestContext(recExpirationTime, commitTime, msg, r.GetRequestCh(), dropCnt, errCnt)
 	glog.Verbosef("send replication request")
 
+	r.health.onSent()
 	if err := r.SendRequest(req); err != nil {
+		r.health.onDone()
+		if r.spill != nil && r.spillRequest(recExpirationTime, msg, "QueueFull") {
+			req.OnComplete()
+			return
+		}
 		glog.Infof("target %s, drop the req", err.Error())
 		if cal.IsEnabled() {
 			var request proto.OperationalMessage
@@ -288,6 +404,133 @@ func (r *replicationProcessorT) replicate(recExpirationTime uint32, msg *proto.R
 	}
 }
 
+func (r *replicationProcessorT) spillRequest(recExpirationTime uint32, msg *proto.RawMessage, reason string) bool {
+	if err := r.spill.AppendMessage(recExpirationTime, msg); err != nil {
+		glog.Warningf("target %s: cannot spill the req: %s", r.targetName, err.Error())
+		return false
+	}
+	otel.RecordCount(otel.RRSpill, []otel.Tags{{otel.Target, r.targetName}, {otel.Status, reason}})
+	return true
+}
+
+// hasBacklog returns true if requests are left to be replayed from the spill
+// log, or replayed but not acked yet. A new request sent before the replayed
+// ones are acked could overtake them for the same key.
+func (r *replicationProcessorT) hasBacklog() bool {
+	// a replayed request is counted before it is advanced past in the spill
+	return atomic.LoadInt32(&r.numReplaying) != 0 || !r.spill.Empty()
+}
+
+func (r *replicationProcessorT) isBootstrapping() bool {
+	return atomic.LoadInt32(&r.bootstrapping) != 0
+}
+
+// replaySpill sends the spilled requests to the target in order, at no more
+// than replayRate requests per second, while the target is connected.
+// The replayed requests are sent in batches within one segment. The read
+// position is committed once every request of the batch has completed, so
+// that the requests not acked by the target are replayed after a restart.
+func (r *replicationProcessorT) replaySpill() {
+	defer r.spillReplayWg.Done()
+
+	perTick := r.replayRate * int(kSpillReplayInterval) / int(time.Second)
+	if perTick <= 0 {
+		perTick = 1
+	}
+	ticker := time.NewTicker(kSpillReplayInterval)
+	defer ticker.Stop()
+	secTicker := time.NewTicker(time.Second)
+	defer secTicker.Stop()
+
+	uncommitted := false
+	for {
+		select {
+		case <-r.chSpillDone:
+			return
+		case <-secTicker.C:
+			atomic.StoreUint32(&r.lastRate, atomic.SwapUint32(&r.numReplayed, 0))
+			r.spill.Sync()
+		case <-ticker.C:
+			if atomic.LoadInt32(&r.numReplaying) != 0 {
+				continue
+			}
+			if uncommitted {
+				if err := r.spill.Commit(); err != nil {
+					glog.Warningf("failed to commit spill replay position: %s", err.Error())
+				}
+				uncommitted = false
+			}
+			if r.GetNumConnections() == 0 || r.isBootstrapping() {
+				continue
+			}
+			mgr := shmstats.GetCurrentWorkerStatsManager()
+			dropCnt := mgr.GetReplicatorDropCounter(r.targetIndex)
+			errCnt := mgr.GetReplicatorErrorCounter(r.targetIndex)
+			now := uint32(time.Now().Unix())
+
+			for i := 0; i < perTick; i++ {
+				if uncommitted && r.spill.AtSegmentEnd() {
+					// the next Peek removes the segment, keep it until the batch is acked
+					break
+				}
+				entry, ok := r.spill.Peek()
+				if !ok {
+					break
+				}
+				if entry.recExpirationTime != 0 && entry.recExpirationTime <= now {
+					r.spill.Advance()
+					uncommitted = true
+					continue
+				}
+				var msg proto.RawMessage
+				if _, err := msg.Read(bytes.NewReader(entry.msg)); err != nil {
+					glog.Warningf("skip bad spilled req: %s", err.Error())
+					r.spill.Advance()
+					uncommitted = true
+					continue
+				}
+				var op proto.OperationalMessage
+				op.Decode(&msg)
+				req := r.reqCtxCreator.newRequestContext(entry.recExpirationTime, op.GetLastModificationTime(), &msg,
+					r.GetRequestCh(), dropCnt, errCnt)
+				msg.ReleaseBuffer()
+				if rr, ok := req.(replayedRequestI); ok {
+					atomic.AddInt32(&r.numReplaying, 1)
+					rr.setReplaying(&r.numReplaying)
+				}
+				r.health.onSent()
+				if err := r.SendRequest(req); err != nil {
+					// queue full, try again on the next tick
+					if _, ok := req.(replayedRequestI); ok {
+						atomic.AddInt32(&r.numReplaying, -1)
+					}
+					r.health.onDone()
+					req.OnComplete()
+					break
+				}
+				r.spill.Advance()
+				uncommitted = true
+				atomic.AddUint32(&r.numReplayed, 1)
+			}
+		}
+	}
+}
+
+func (r *replicationProcessorT) Shutdown() {
+	if r.chSpillDone != nil {
+		close(r.chSpillDone)
+		r.spillReplayWg.Wait()
+	}
+	r.OutboundProcessor.Shutdown()
+}
+
+func (r *replicationProcessorT) WaitShutdown() {
+	r.OutboundProcessor.WaitShutdown()
+	if r.spill != nil {
+		r.spill.Close()
+	}
+}
+
 func (r *replicationProcessorT) OnConnectSuccess(conn io.Conn, connector *io.OutboundConnector, timeTaken time.Duration) {
 	r.OutboundProcessor.OnConnectSuccess(conn, connector, timeTaken)
 	if connector != nil {
@@ -312,9 +555,39 @@ func (cb *repStateCBImpl) Call() {
 	mgr := shmstats.GetCurrentWorkerStatsManager()
 
 	if TheReplicator != nil {
+		now := time.Now()
+		alarmConf := &TheReplicator.conf.Alarm
 		repProcs := TheReplicator.GetProcessors()
-		for i, proc := range repProcs {
-			mgr.SetReplicatorStats(i, uint16(proc.GetNumConnections()), uint16(len(proc.GetRequestCh())))
+		for _, proc := range repProcs {
+			i := proc.targetIndex
+			queueLen := len(proc.GetRequestCh())
+			mgr.SetReplicatorStats(i, uint16(proc.GetNumConnections()), uint16(queueLen))
+			var spillAge uint32
+			if proc.spill != nil {
+				var backlog uint64
+				backlog, spillAge = spillBacklog(proc.spill)
+				mgr.SetReplicatorSpillStats(i, backlog, spillAge, atomic.LoadUint32(&proc.lastRate))
+			}
+			st := proc.health.tick(now, queueLen, time.Duration(spillAge)*time.Second, alarmConf)
+			mgr.SetReplicatorHealthStats(i, st.lagMs(), st.numInFlight, st.ackRate, st.lastSuccessUnix(), st.alarms)
 		}
+		for _, sink := range TheReplicator.sinks {
+			// a sink counts as connected unless the last delivery failed
+			mgr.SetReplicatorStats(sink.targetIndex, uint16(atomic.LoadInt32(&sink.healthy)), 0)
+			backlog, age := spillBacklog(sink.spill)
+			mgr.SetReplicatorSpillStats(sink.targetIndex, backlog, age, atomic.LoadUint32(&sink.lastRate))
+			st := sink.health.tick(now, 0, time.Duration(age)*time.Second, alarmConf)
+			mgr.SetReplicatorHealthStats(sink.targetIndex, st.lagMs(), st.numInFlight, st.ackRate, st.lastSuccessUnix(), st.alarms)
+		}
+	}
+}
+
+// spillBacklog returns the backlog in bytes and the age in seconds of the
+// oldest entry.
+func spillBacklog(spill *spillLog) (backlog uint64, age uint32) {
+	bytes, oldest := spill.Backlog()
+	if !oldest.IsZero() {
+		age = uint32(time.Since(oldest) / time.Second)
 	}
+	return uint64(bytes), age
 }
@@ -0,0 +1,276 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	repconfig "github.com/paypal/junodb/cmd/proxy/replication/config"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+const (
+	kSinkWebhookTimeout = 5 * time.Second
+	kSinkDialTimeout    = time.Second
+)
+
+type (
+	// ISink receives the change events of a replication target. Write returns
+	// nil only once the whole batch is accepted; the batch is retried
+	// otherwise, so a sink may see an event more than once.
+	ISink interface {
+		Write(events []*ChangeEvent) error
+		Close() error
+	}
+
+	// ChangeEvent is a committed write. Offset is
+	// "<target name>/<worker id>/<segment>.<offset>", the position of the
+	// event in the spill log of the proxy worker. It is unique among the
+	// events of all the workers written to a sink, and is the same when an
+	// event is delivered again, so consumers can dedupe on it.
+	ChangeEvent struct {
+		Offset         string `json:"offset"`
+		Op             string `json:"op"`
+		Namespace      string `json:"namespace"`
+		Key            []byte `json:"key"`
+		Value          []byte `json:"value,omitempty"`
+		ValueEncrypted bool   `json:"valueEncrypted,omitempty"`
+		Version        uint32 `json:"version"`
+		CreationTime   uint32 `json:"creationTime"`
+		ExpirationTime uint32 `json:"expirationTime"`
+		LastModified   uint64 `json:"lastModified"`
+		RequestId      string `json:"requestId"`
+	}
+
+	sinkEncoderT func(buf *bytes.Buffer, ev *ChangeEvent) error
+
+	fileSinkT struct {
+		file   *os.File
+		encode sinkEncoderT
+	}
+
+	unixSinkT struct {
+		path   string
+		conn   net.Conn
+		encode sinkEncoderT
+	}
+
+	webhookSinkT struct {
+		url         string
+		contentType string
+		client      http.Client
+		encode      sinkEncoderT
+	}
+)
+
+func newSink(target *repconfig.ReplicationTarget) (ISink, error) {
+	encode, contentType := encodeJSONEvent, "application/x-ndjson"
+	if target.SinkFormat == repconfig.SinkFormatProto {
+		encode, contentType = encodeProtoEvent, "application/x-protobuf"
+	}
+
+	switch target.Sink {
+	case repconfig.SinkFile:
+		f, err := os.OpenFile(target.Addr, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSinkT{file: f, encode: encode}, nil
+	case repconfig.SinkUnix:
+		return &unixSinkT{path: target.Addr, encode: encode}, nil
+	case repconfig.SinkWebhook:
+		return &webhookSinkT{
+			url:         target.Addr,
+			contentType: contentType,
+			client:      http.Client{Timeout: kSinkWebhookTimeout},
+			encode:      encode,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported sink '%s'", target.Sink)
+}
+
+// newChangeEvent decodes the spilled request. offsetPrefix is the target name
+// and the worker id, see ChangeEvent.
+func newChangeEvent(offsetPrefix string, entry *spillEntry) (ev *ChangeEvent, err error) {
+	var raw proto.RawMessage
+	if _, err = raw.Read(bytes.NewReader(entry.msg)); err != nil {
+		return
+	}
+	defer raw.ReleaseBuffer()
+	var op proto.OperationalMessage
+	if err = op.Decode(&raw); err != nil {
+		return
+	}
+	ev = &ChangeEvent{
+		Offset:         fmt.Sprintf("%s%d.%d", offsetPrefix, entry.seq, entry.offset),
+		Op:             op.GetOpCodeText(),
+		Namespace:      string(op.GetNamespace()),
+		Key:            append([]byte(nil), op.GetKey()...),
+		Version:        op.GetVersion(),
+		CreationTime:   op.GetCreationTime(),
+		ExpirationTime: op.GetExpirationTime(),
+		LastModified:   op.GetLastModificationTime(),
+		RequestId:      op.GetOriginatorRequestID().String(),
+	}
+	payload := op.GetPayload()
+	if payload.GetLength() != 0 {
+		if value, e := payload.GetClearValue(); e == nil {
+			ev.Value = append([]byte(nil), value...)
+		} else {
+			// encrypted by the client
+			ev.Value = append([]byte(nil), payload.GetData()...)
+			ev.ValueEncrypted = true
+		}
+	}
+	return
+}
+
+func encodeJSONEvent(buf *bytes.Buffer, ev *ChangeEvent) error {
+	// Encode appends the newline
+	return json.NewEncoder(buf).Encode(ev)
+}
+
+// encodeProtoEvent writes the event as a varint length followed by
+//
+//	message ChangeEvent {
+//	  string offset = 1;
+//	  string op = 2;
+//	  string namespace = 3;
+//	  bytes key = 4;
+//	  bytes value = 5;
+//	  bool value_encrypted = 6;
+//	  uint32 version = 7;
+//	  uint32 creation_time = 8;
+//	  uint32 expiration_time = 9;
+//	  uint64 last_modified = 10;
+//	  string request_id = 11;
+//	}
+func encodeProtoEvent(buf *bytes.Buffer, ev *ChangeEvent) error {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, ev.Offset)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, ev.Op)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendString(b, ev.Namespace)
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, ev.Key)
+	if len(ev.Value) != 0 {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, ev.Value)
+	}
+	if ev.ValueEncrypted {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = protowire.AppendTag(b, 7, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(ev.Version))
+	b = protowire.AppendTag(b, 8, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(ev.CreationTime))
+	b = protowire.AppendTag(b, 9, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(ev.ExpirationTime))
+	b = protowire.AppendTag(b, 10, protowire.VarintType)
+	b = protowire.AppendVarint(b, ev.LastModified)
+	b = protowire.AppendTag(b, 11, protowire.BytesType)
+	b = protowire.AppendString(b, ev.RequestId)
+
+	buf.Write(protowire.AppendVarint(nil, uint64(len(b))))
+	buf.Write(b)
+	return nil
+}
+
+func encodeEvents(encode sinkEncoderT, events []*ChangeEvent) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	for _, ev := range events {
+		if err := encode(&buf, ev); err != nil {
+			return nil, err
+		}
+	}
+	return &buf, nil
+}
+
+func (s *fileSinkT) Write(events []*ChangeEvent) error {
+	buf, err := encodeEvents(s.encode, events)
+	if err != nil {
+		return err
+	}
+	if _, err = s.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSinkT) Close() error {
+	return s.file.Close()
+}
+
+func (s *unixSinkT) Write(events []*ChangeEvent) (err error) {
+	buf, err := encodeEvents(s.encode, events)
+	if err != nil {
+		return
+	}
+	if s.conn == nil {
+		if s.conn, err = net.DialTimeout("unix", s.path, kSinkDialTimeout); err != nil {
+			return
+		}
+	}
+	if _, err = s.conn.Write(buf.Bytes()); err != nil {
+		// reconnect on the retry
+		s.conn.Close()
+		s.conn = nil
+	}
+	return
+}
+
+func (s *unixSinkT) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+func (s *webhookSinkT) Write(events []*ChangeEvent) error {
+	buf, err := encodeEvents(s.encode, events)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, s.contentType, buf)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook status %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSinkT) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,454 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	goio "io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// Entry layout of a spill segment file:
+//
+//	| len (4) | crc32c of body (4) | enqueue time, unix nano (8) | body (len) |
+//
+// The body is the record expiration time (4) followed by the encoded request.
+const (
+	kSpillEntryHeaderSize = 16
+	kSpillSegmentSuffix   = ".seg"
+	kSpillCommitFile      = "commit"
+	kSpillMaxEntrySize    = 64 * 1024 * 1024
+)
+
+var (
+	errSpillFull        = errors.New("spill log full")
+	errSpillClosed      = errors.New("spill log closed")
+	errSpillCorrupted   = errors.New("spill entry corrupted")
+	spillCrcTable       = crc32.MakeTable(crc32.Castagnoli)
+	spillNow            = time.Now
+	errSpillEntryTooBig = errors.New("spill entry too big")
+)
+
+type (
+	spillSegment struct {
+		seq       uint64
+		path      string
+		size      int64
+		lastWrite time.Time
+	}
+
+	spillEntry struct {
+		seq               uint64 // segment of the entry
+		offset            int64  // position of the entry in the segment
+		recExpirationTime uint32
+		enqueueTime       time.Time
+		msg               []byte
+		size              int64
+	}
+
+	// spillLog is an append-only queue of replication requests on disk. It is
+	// made of segment files, the last of which is being appended to. Entries
+	// are consumed from the oldest segment, which is removed once read. Unless
+	// the read position is persisted with Commit, the unremoved part of the
+	// oldest segment is replayed again after a restart.
+	spillLog struct {
+		mtx            sync.Mutex
+		dir            string
+		maxSegmentSize int64
+		maxBytes       int64
+		maxAge         time.Duration
+
+		segments   []*spillSegment // oldest first
+		writer     *os.File
+		reader     *os.File
+		readOffset int64
+		pending    *spillEntry
+		totalBytes int64
+		numDropped uint64
+		closed     bool
+	}
+)
+
+func newSpillLog(dir string, maxSegmentSize int64, maxBytes int64, maxAge time.Duration) (l *spillLog, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	l = &spillLog{
+		dir:            dir,
+		maxSegmentSize: maxSegmentSize,
+		maxBytes:       maxBytes,
+		maxAge:         maxAge,
+	}
+	if err = l.load(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// load picks up the segments left by a previous run.
+func (l *spillLog) load() error {
+	files, err := os.ReadDir(l.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, kSpillSegmentSuffix) {
+			continue
+		}
+		seq, e := strconv.ParseUint(strings.TrimSuffix(name, kSpillSegmentSuffix), 10, 64)
+		if e != nil {
+			continue
+		}
+		info, e := f.Info()
+		if e != nil {
+			return e
+		}
+		l.segments = append(l.segments, &spillSegment{
+			seq:       seq,
+			path:      filepath.Join(l.dir, name),
+			size:      info.Size(),
+			lastWrite: info.ModTime(),
+		})
+		l.totalBytes += info.Size()
+	}
+	sort.Slice(l.segments, func(i, j int) bool { return l.segments[i].seq < l.segments[j].seq })
+	l.loadCommit()
+	if n := len(l.segments); n != 0 {
+		glog.Infof("spill log %s: %d segments, %d bytes to replay", l.dir, n, l.totalBytes)
+	}
+	return nil
+}
+
+// loadCommit resumes reading from the position saved by the last Commit.
+func (l *spillLog) loadCommit() {
+	data, err := os.ReadFile(filepath.Join(l.dir, kSpillCommitFile))
+	if err != nil || len(l.segments) == 0 {
+		return
+	}
+	var seq uint64
+	var offset int64
+	if _, err = fmt.Sscanf(string(data), "%d %d", &seq, &offset); err != nil {
+		glog.Warningf("spill log %s: bad commit file: %s", l.dir, err.Error())
+		return
+	}
+	if seg := l.segments[0]; seg.seq == seq && offset > 0 && offset <= seg.size {
+		l.readOffset = offset
+		l.totalBytes -= offset
+	}
+}
+
+func (l *spillLog) segmentPath(seq uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", seq, kSpillSegmentSuffix))
+}
+
+// AppendMessage adds an encoded request to the tail of the log.
+func (l *spillLog) AppendMessage(recExpirationTime uint32, msg *proto.RawMessage) error {
+	var buf bytes.Buffer
+	if _, err := msg.Write(&buf); err != nil {
+		return err
+	}
+	return l.Append(recExpirationTime, buf.Bytes())
+}
+
+// Append adds a request to the tail of the log.
+func (l *spillLog) Append(recExpirationTime uint32, msg []byte) (err error) {
+	sz := int64(kSpillEntryHeaderSize + 4 + len(msg))
+	if sz > kSpillMaxEntrySize {
+		return errSpillEntryTooBig
+	}
+	now := spillNow()
+	buf := make([]byte, sz)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(4+len(msg)))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(now.UnixNano()))
+	binary.BigEndian.PutUint32(buf[16:20], recExpirationTime)
+	copy(buf[20:], msg)
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(buf[16:], spillCrcTable))
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.closed {
+		return errSpillClosed
+	}
+	l.enforceLimits(now, sz)
+	if l.totalBytes+sz > l.maxBytes {
+		l.numDropped++
+		return errSpillFull
+	}
+	if err = l.prepareWriter(sz); err != nil {
+		return
+	}
+	if _, err = l.writer.Write(buf); err != nil {
+		return
+	}
+	seg := l.segments[len(l.segments)-1]
+	seg.size += sz
+	seg.lastWrite = now
+	l.totalBytes += sz
+	return
+}
+
+// prepareWriter makes sure there is an open segment with room for sz bytes.
+func (l *spillLog) prepareWriter(sz int64) (err error) {
+	n := len(l.segments)
+	if l.writer != nil && l.segments[n-1].size+sz <= l.maxSegmentSize {
+		return
+	}
+	if l.writer != nil {
+		l.writer.Sync()
+		l.writer.Close()
+		l.writer = nil
+	}
+	var seq uint64
+	if n != 0 {
+		seq = l.segments[n-1].seq + 1
+	}
+	seg := &spillSegment{seq: seq, path: l.segmentPath(seq), lastWrite: spillNow()}
+	if l.writer, err = os.OpenFile(seg.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+		return
+	}
+	l.segments = append(l.segments, seg)
+	return
+}
+
+// enforceLimits drops the oldest segments that are past the max age, or as
+// many as needed to make room for sz bytes. The segment being written is kept.
+func (l *spillLog) enforceLimits(now time.Time, sz int64) {
+	for len(l.segments) > 1 || (len(l.segments) == 1 && l.writer == nil) {
+		seg := l.segments[0]
+		if now.Sub(seg.lastWrite) <= l.maxAge && l.totalBytes+sz <= l.maxBytes {
+			return
+		}
+		glog.Warningf("spill log %s: drop segment %d of %d bytes", l.dir, seg.seq, seg.size)
+		l.numDropped += l.countEntries(seg)
+		l.removeOldest()
+	}
+}
+
+// countEntries returns the number of unread entries of the oldest segment.
+// It is only used for accounting of the dropped requests.
+func (l *spillLog) countEntries(seg *spillSegment) (n uint64) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	offset := l.readOffset
+	var hdr [kSpillEntryHeaderSize]byte
+	for offset+kSpillEntryHeaderSize <= seg.size {
+		if _, err = f.ReadAt(hdr[:], offset); err != nil {
+			return
+		}
+		offset += kSpillEntryHeaderSize + int64(binary.BigEndian.Uint32(hdr[0:4]))
+		n++
+	}
+	return
+}
+
+// removeOldest deletes the oldest segment.
+func (l *spillLog) removeOldest() {
+	seg := l.segments[0]
+	if l.reader != nil {
+		l.reader.Close()
+		l.reader = nil
+	}
+	l.totalBytes -= seg.size - l.readOffset
+	l.readOffset = 0
+	l.pending = nil
+	if len(l.segments) == 1 && l.writer != nil {
+		l.writer.Close()
+		l.writer = nil
+	}
+	os.Remove(seg.path)
+	l.segments = l.segments[1:]
+}
+
+// Peek returns the oldest entry without consuming it. ok is false if the log
+// is empty.
+func (l *spillLog) Peek() (entry *spillEntry, ok bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.peek()
+}
+
+func (l *spillLog) peek() (*spillEntry, bool) {
+	for l.pending == nil {
+		if len(l.segments) == 0 {
+			return nil, false
+		}
+		seg := l.segments[0]
+		if l.readOffset >= seg.size {
+			if len(l.segments) == 1 && l.writer != nil {
+				return nil, false
+			}
+			l.removeOldest()
+			continue
+		}
+		entry, err := l.readEntry(seg)
+		if err != nil {
+			// a crash may leave a torn entry at the end of a segment
+			glog.Warningf("spill log %s: segment %d: %s, skip the rest", l.dir, seg.seq, err.Error())
+			l.numDropped++
+			l.totalBytes -= seg.size - l.readOffset
+			seg.size = l.readOffset
+			if len(l.segments) == 1 && l.writer != nil {
+				// keep appending after the valid part
+				l.writer.Truncate(seg.size)
+				l.writer.Seek(seg.size, goio.SeekStart)
+			}
+			continue
+		}
+		l.pending = entry
+	}
+	return l.pending, true
+}
+
+func (l *spillLog) readEntry(seg *spillSegment) (entry *spillEntry, err error) {
+	if l.reader == nil {
+		if l.reader, err = os.Open(seg.path); err != nil {
+			return
+		}
+	}
+	var hdr [kSpillEntryHeaderSize]byte
+	if _, err = l.reader.ReadAt(hdr[:], l.readOffset); err != nil {
+		return
+	}
+	szBody := int64(binary.BigEndian.Uint32(hdr[0:4]))
+	if szBody < 4 || szBody > kSpillMaxEntrySize || l.readOffset+kSpillEntryHeaderSize+szBody > seg.size {
+		return nil, errSpillCorrupted
+	}
+	body := make([]byte, szBody)
+	if _, err = l.reader.ReadAt(body, l.readOffset+kSpillEntryHeaderSize); err != nil {
+		return
+	}
+	if crc32.Checksum(body, spillCrcTable) != binary.BigEndian.Uint32(hdr[4:8]) {
+		return nil, errSpillCorrupted
+	}
+	entry = &spillEntry{
+		seq:               seg.seq,
+		offset:            l.readOffset,
+		recExpirationTime: binary.BigEndian.Uint32(body[0:4]),
+		enqueueTime:       time.Unix(0, int64(binary.BigEndian.Uint64(hdr[8:16]))),
+		msg:               body[4:],
+		size:              kSpillEntryHeaderSize + szBody,
+	}
+	return
+}
+
+// Advance consumes the entry returned by the last Peek.
+func (l *spillLog) Advance() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.pending == nil {
+		return
+	}
+	l.readOffset += l.pending.size
+	l.totalBytes -= l.pending.size
+	l.pending = nil
+}
+
+// AtSegmentEnd returns true if the oldest segment has been read to its end.
+// The next Peek removes it.
+func (l *spillLog) AtSegmentEnd() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.pending == nil && len(l.segments) != 0 && l.readOffset >= l.segments[0].size
+}
+
+// Commit persists the read position, so that the consumed entries are not
+// replayed after a restart.
+func (l *spillLog) Commit() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if len(l.segments) == 0 {
+		return nil
+	}
+	path := filepath.Join(l.dir, kSpillCommitFile)
+	tmp := path + ".tmp"
+	data := fmt.Sprintf("%d %d\n", l.segments[0].seq, l.readOffset)
+	if err := os.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Empty returns true if there is nothing to replay.
+func (l *spillLog) Empty() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.totalBytes == 0
+}
+
+// Backlog returns the number of bytes to replay and the enqueue time of the
+// oldest entry.
+func (l *spillLog) Backlog() (bytes int64, oldest time.Time) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if entry, ok := l.peek(); ok {
+		oldest = entry.enqueueTime
+	}
+	return l.totalBytes, oldest
+}
+
+// NumDropped returns the number of requests dropped because of the limits.
+func (l *spillLog) NumDropped() uint64 {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.numDropped
+}
+
+// Sync flushes the segment being written to disk.
+func (l *spillLog) Sync() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.writer != nil {
+		l.writer.Sync()
+	}
+}
+
+func (l *spillLog) Close() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.closed = true
+	if l.writer != nil {
+		l.writer.Sync()
+		l.writer.Close()
+		l.writer = nil
+	}
+	if l.reader != nil {
+		l.reader.Close()
+		l.reader = nil
+	}
+}
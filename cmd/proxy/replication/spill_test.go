@@ -0,0 +1,172 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package replication
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func drainSpill(t *testing.T, l *spillLog) (msgs []string) {
+	for {
+		entry, ok := l.Peek()
+		if !ok {
+			return
+		}
+		msgs = append(msgs, string(entry.msg))
+		l.Advance()
+	}
+}
+
+func TestSpillLogOrderAndRotation(t *testing.T) {
+	l, err := newSpillLog(t.TempDir(), 100, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 20; i++ {
+		if err = l.Append(uint32(i), []byte(fmt.Sprintf("msg-%02d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(l.segments) < 2 {
+		t.Fatalf("expect the log to be rotated, got %d segment", len(l.segments))
+	}
+	if bytes, oldest := l.Backlog(); bytes == 0 || oldest.IsZero() {
+		t.Errorf("unexpected backlog %d %v", bytes, oldest)
+	}
+	msgs := drainSpill(t, l)
+	if len(msgs) != 20 {
+		t.Fatalf("expect 20 entries, got %d", len(msgs))
+	}
+	for i, m := range msgs {
+		if m != fmt.Sprintf("msg-%02d", i) {
+			t.Errorf("entry %d: got %s", i, m)
+		}
+	}
+	if !l.Empty() || len(l.segments) != 1 {
+		t.Errorf("expect empty log with the active segment, got %d segments", len(l.segments))
+	}
+}
+
+func TestSpillLogReload(t *testing.T) {
+	dir := t.TempDir()
+	l, err := newSpillLog(dir, 1<<20, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Append(0, []byte(fmt.Sprintf("msg-%d", i)))
+	}
+	l.Close()
+
+	// simulate a torn write at the end of the segment
+	seg := filepath.Join(dir, fmt.Sprintf("%020d%s", 0, kSpillSegmentSuffix))
+	f, err := os.OpenFile(seg, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 9, 1, 2})
+	f.Close()
+
+	if l, err = newSpillLog(dir, 1<<20, 1<<20, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Append(0, []byte("msg-3"))
+
+	msgs := drainSpill(t, l)
+	if len(msgs) != 4 || msgs[0] != "msg-0" || msgs[3] != "msg-3" {
+		t.Errorf("unexpected entries after reload: %v", msgs)
+	}
+	if l.NumDropped() != 1 {
+		t.Errorf("expect the torn entry to be dropped, got %d", l.NumDropped())
+	}
+}
+
+func TestSpillLogLimits(t *testing.T) {
+	now := time.Now()
+	spillNow = func() time.Time { return now }
+	defer func() { spillNow = time.Now }()
+
+	l, err := newSpillLog(t.TempDir(), 64, 256, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 20; i++ {
+		l.Append(0, []byte(fmt.Sprintf("msg-%02d", i)))
+	}
+	if l.totalBytes > 256 || l.NumDropped() == 0 {
+		t.Errorf("expect the oldest segments to be dropped, size %d dropped %d", l.totalBytes, l.NumDropped())
+	}
+	msgs := drainSpill(t, l)
+	if len(msgs) == 0 || msgs[len(msgs)-1] != "msg-19" {
+		t.Errorf("expect the newest entries to be kept: %v", msgs)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.Append(0, []byte(fmt.Sprintf("old-%d", i)))
+	}
+	now = now.Add(2 * time.Minute)
+	l.Append(0, []byte("new-0"))
+	if msgs = drainSpill(t, l); len(msgs) == 0 || msgs[0] == "old-0" || msgs[len(msgs)-1] != "new-0" {
+		t.Errorf("expect the aged segment to be dropped: %v", msgs)
+	}
+}
+
+func TestReplicateBehindReplay(t *testing.T) {
+	l, err := newSpillLog(t.TempDir(), 1<<20, 1<<20, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	r := &replicationProcessorT{targetName: "t", spill: l}
+	if r.hasBacklog() {
+		t.Error("expect no backlog")
+	}
+	// the spill is empty, but a replayed request is not acked yet
+	atomic.StoreInt32(&r.numReplaying, 1)
+	if !r.hasBacklog() {
+		t.Error("expect the replayed request in flight to be a backlog")
+	}
+	var op proto.OperationalMessage
+	op.SetRequest(proto.OpCodeSet, []byte("key"), []byte("ns"), nil, 100)
+	var msg proto.RawMessage
+	if err = op.Encode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	r.replicate(0, 0, &msg, nil, nil)
+	if l.Empty() {
+		t.Error("expect the new request to be spilled behind the replayed one")
+	}
+	atomic.StoreInt32(&r.numReplaying, 0)
+	if !r.hasBacklog() {
+		t.Error("expect the spilled request to be a backlog")
+	}
+}
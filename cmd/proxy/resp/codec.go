@@ -0,0 +1,222 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package resp serves the Redis clients on the proxy listeners with Protocol
+// "resp", translating a subset of the Redis commands into Juno requests.
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+)
+
+const (
+	kMaxNumArgs       = 16 * 1024
+	kMaxCommandLength = 64 * 1024 * 1024 // of the bulk strings of a command
+	kMaxInlineSize    = 64 * 1024
+)
+
+// errProtocol is returned for a malformed command, after which the
+// connection is closed.
+type errProtocol string
+
+func (e errProtocol) Error() string {
+	return "Protocol error: " + string(e)
+}
+
+// reader reads the commands, sent either as arrays of bulk strings or inline.
+type reader struct {
+	r       *bufio.Reader
+	maxBulk int
+}
+
+func newReader(r io.Reader, size int) *reader {
+	return &reader{r: bufio.NewReaderSize(r, size), maxBulk: maxBulkLength()}
+}
+
+// maxBulkLength returns the length of the largest argument a command may
+// need, a value or a key with its namespace prefix.
+func maxBulkLength() int {
+	keyLen := config.Conf.MaxNamespaceLength + len(config.Conf.Resp.NamespaceSeparator) + config.Conf.MaxKeyLength
+	if config.Conf.MaxPayloadLength > keyLen {
+		return config.Conf.MaxPayloadLength
+	}
+	return keyLen
+}
+
+// buffered returns whether a pipelined command has already been received.
+func (r *reader) buffered() bool {
+	return r.r.Buffered() != 0
+}
+
+func (r *reader) readLine() (line []byte, err error) {
+	if line, err = r.r.ReadSlice('\n'); err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			err = errProtocol("too big inline request")
+		}
+		return
+	}
+	if len(line) > kMaxInlineSize {
+		err = errProtocol("too big inline request")
+		return
+	}
+	line = bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'})
+	return
+}
+
+// readCommand returns the next command and its arguments. An empty inline
+// command returns no argument.
+func (r *reader) readCommand() (args [][]byte, err error) {
+	var line []byte
+	if line, err = r.readLine(); err != nil {
+		return
+	}
+	if len(line) == 0 || line[0] != '*' {
+		for _, f := range bytes.Fields(line) {
+			args = append(args, append([]byte(nil), f...))
+		}
+		return
+	}
+	var n int
+	if n, err = parseLength(line[1:], kMaxNumArgs, "multibulk"); err != nil || n <= 0 {
+		return
+	}
+	args = make([][]byte, n)
+	total := 0
+	for i := range args {
+		if line, err = r.readLine(); err != nil {
+			return
+		}
+		if len(line) == 0 || line[0] != '$' {
+			err = errProtocol("expected '$', got '" + string(line[:minInt(len(line), 1)]) + "'")
+			return
+		}
+		var sz int
+		if sz, err = parseLength(line[1:], r.maxBulk, "bulk"); err != nil {
+			return
+		}
+		if sz < 0 {
+			err = errProtocol("invalid bulk length")
+			return
+		}
+		if total += sz; total > kMaxCommandLength {
+			err = errProtocol("too big command")
+			return
+		}
+		// the buffer grows with the data received, not with the length announced
+		var buf bytes.Buffer
+		if _, err = io.CopyN(&buf, r.r, int64(sz+2)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return
+		}
+		args[i] = buf.Bytes()
+		if args[i][sz] != '\r' || args[i][sz+1] != '\n' {
+			err = errProtocol("bulk not terminated by CRLF")
+			return
+		}
+		args[i] = args[i][:sz]
+	}
+	return
+}
+
+func parseLength(b []byte, max int, what string) (n int, err error) {
+	if n, err = strconv.Atoi(string(b)); err != nil || n > max {
+		err = errProtocol("invalid " + what + " length")
+	}
+	return
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// writer writes the replies in RESP2, or RESP3 after a HELLO 3.
+type writer struct {
+	w     *bufio.Writer
+	resp3 bool
+}
+
+func newWriter(w io.Writer, size int) *writer {
+	return &writer{w: bufio.NewWriterSize(w, size)}
+}
+
+func (w *writer) flush() error {
+	return w.w.Flush()
+}
+
+func (w *writer) writeLine(prefix byte, s string) {
+	w.w.WriteByte(prefix)
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) writeSimple(s string) {
+	w.writeLine('+', s)
+}
+
+// writeError writes msg, starting with the error code, e.g. "ERR syntax error".
+func (w *writer) writeError(msg string) {
+	w.writeLine('-', msg)
+}
+
+func (w *writer) writeInt(n int64) {
+	w.writeLine(':', strconv.FormatInt(n, 10))
+}
+
+func (w *writer) writeBulk(b []byte) {
+	w.writeLine('$', strconv.Itoa(len(b)))
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+func (w *writer) writeBulkString(s string) {
+	w.writeBulk([]byte(s))
+}
+
+func (w *writer) writeNull() {
+	if w.resp3 {
+		w.w.WriteString("_\r\n")
+	} else {
+		w.w.WriteString("$-1\r\n")
+	}
+}
+
+func (w *writer) writeArrayLen(n int) {
+	w.writeLine('*', strconv.Itoa(n))
+}
+
+// writeMapLen writes the header of a map of n pairs, an array of 2n
+// elements in RESP2.
+func (w *writer) writeMapLen(n int) {
+	if w.resp3 {
+		w.writeLine('%', strconv.Itoa(n))
+	} else {
+		w.writeArrayLen(2 * n)
+	}
+}
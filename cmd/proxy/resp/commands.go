@@ -0,0 +1,472 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package resp
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/gateway"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/version"
+)
+
+const (
+	kCounterUDFName = "sc"
+	kMaxIncrTries   = 5
+)
+
+type command struct {
+	// number of arguments including the command name, or -n for at least n
+	arity   int
+	execute func(s *session, args [][]byte)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"get":     {2, (*session).get},
+		"set":     {-3, (*session).set},
+		"del":     {-2, (*session).del},
+		"exists":  {-2, (*session).exists},
+		"expire":  {3, (*session).expire},
+		"ttl":     {2, (*session).ttl},
+		"incr":    {2, (*session).incr},
+		"incrby":  {3, (*session).incrBy},
+		"decr":    {2, (*session).decr},
+		"decrby":  {3, (*session).decrBy},
+		"mget":    {-2, (*session).mget},
+		"mset":    {-3, (*session).mset},
+		"select":  {2, (*session).selectNamespace},
+		"ping":    {-1, (*session).ping},
+		"echo":    {2, (*session).echo},
+		"hello":   {-1, (*session).hello},
+		"quit":    {-1, (*session).quit},
+		"command": {-1, (*session).command},
+		"client":  {-2, (*session).client},
+	}
+}
+
+func (s *session) execute(args [][]byte) {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		s.writer.writeError("ERR unknown command '" + string(args[0]) + "'")
+		return
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || len(args) < -cmd.arity {
+		s.writer.writeError("ERR wrong number of arguments for '" + name + "' command")
+		return
+	}
+	cmd.execute(s, args)
+}
+
+func (s *session) writeStatusError(st proto.OpStatus) {
+	s.writer.writeError("ERR " + st.String())
+}
+
+func (s *session) writeSyntaxError() {
+	s.writer.writeError("ERR syntax error")
+}
+
+func (s *session) writeNotIntegerError() {
+	s.writer.writeError("ERR value is not an integer or out of range")
+}
+
+// GET key
+func (s *session) get(args [][]byte) {
+	res, err := s.process(s.newRequest(proto.OpCodeGet, args[1], nil, 0))
+	switch {
+	case err != nil:
+		s.writer.writeError("ERR " + err.Error())
+	case gateway.IsOK(res.Status):
+		s.writer.writeBulk(res.Value)
+	case res.Status == proto.OpStatusNoKey:
+		s.writer.writeNull()
+	default:
+		s.writeStatusError(res.Status)
+	}
+}
+
+// SET key value [NX | XX] [EX seconds | PX milliseconds]
+func (s *session) set(args [][]byte) {
+	op := proto.OpCodeSet
+	var ttl uint32
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); opt {
+		case "NX", "XX":
+			if op != proto.OpCodeSet {
+				s.writeSyntaxError()
+				return
+			}
+			if opt == "NX" {
+				op = proto.OpCodeCreate
+			} else {
+				op = proto.OpCodeUpdate
+			}
+		case "EX", "PX":
+			if ttl != 0 || i+1 == len(args) {
+				s.writeSyntaxError()
+				return
+			}
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				s.writeNotIntegerError()
+				return
+			}
+			if opt == "PX" {
+				n = (n + 999) / 1000
+			}
+			if n <= 0 || n > math.MaxUint32 {
+				s.writer.writeError("ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = uint32(n)
+		default:
+			s.writeSyntaxError()
+			return
+		}
+	}
+	res, err := s.process(s.newRequest(op, args[1], args[2], ttl))
+	switch {
+	case err != nil:
+		s.writer.writeError("ERR " + err.Error())
+	case gateway.IsOK(res.Status):
+		s.writer.writeSimple("OK")
+	case op == proto.OpCodeCreate && res.Status == proto.OpStatusDupKey,
+		op == proto.OpCodeUpdate && res.Status == proto.OpStatusNoKey:
+		s.writer.writeNull()
+	default:
+		s.writeStatusError(res.Status)
+	}
+}
+
+// countOK sends a request with the op for each of the keys, and replies with
+// the number of successful ones.
+func (s *session) countOK(op proto.OpCode, keys [][]byte) {
+	requests := make([]*proto.OperationalMessage, len(keys))
+	for i, key := range keys {
+		requests[i] = s.newRequest(op, key, nil, 0)
+	}
+	results, err := s.processAll(requests)
+	if err != nil {
+		s.writer.writeError("ERR " + err.Error())
+		return
+	}
+	var n int64
+	for _, res := range results {
+		if gateway.IsOK(res.Status) {
+			n++
+		} else if res.Status != proto.OpStatusNoKey {
+			s.writeStatusError(res.Status)
+			return
+		}
+	}
+	s.writer.writeInt(n)
+}
+
+// DEL key [key ...]
+func (s *session) del(args [][]byte) {
+	s.countOK(proto.OpCodeDestroy, args[1:])
+}
+
+// EXISTS key [key ...]
+func (s *session) exists(args [][]byte) {
+	s.countOK(proto.OpCodeExists, args[1:])
+}
+
+// EXPIRE key seconds
+func (s *session) expire(args [][]byte) {
+	seconds, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		s.writeNotIntegerError()
+		return
+	}
+	if seconds <= 0 {
+		// the key is deleted, as by Redis
+		var res gateway.Result
+		if res, err = s.process(s.newRequest(proto.OpCodeExists, args[1], nil, 0)); err == nil && gateway.IsOK(res.Status) {
+			res, err = s.process(s.newRequest(proto.OpCodeDestroy, args[1], nil, 0))
+		}
+		s.writeTouched(res, err)
+		return
+	}
+	if seconds > math.MaxUint32 {
+		s.writer.writeError("ERR invalid expire time in 'expire' command")
+		return
+	}
+	res, err := s.process(s.newRequest(proto.OpCodeTouch, args[1], nil, uint32(seconds)))
+	s.writeTouched(res, err)
+}
+
+func (s *session) writeTouched(res gateway.Result, err error) {
+	switch {
+	case err != nil:
+		s.writer.writeError("ERR " + err.Error())
+	case gateway.IsOK(res.Status):
+		s.writer.writeInt(1)
+	case res.Status == proto.OpStatusNoKey:
+		s.writer.writeInt(0)
+	default:
+		s.writeStatusError(res.Status)
+	}
+}
+
+// TTL key
+func (s *session) ttl(args [][]byte) {
+	res, err := s.process(s.newRequest(proto.OpCodeGetMeta, args[1], nil, 0))
+	switch {
+	case err != nil:
+		s.writer.writeError("ERR " + err.Error())
+	case gateway.IsOK(res.Status):
+		ttl := int64(res.ExpirationTime) - time.Now().Unix()
+		if ttl < 0 {
+			ttl = 0
+		}
+		s.writer.writeInt(ttl)
+	case res.Status == proto.OpStatusNoKey:
+		s.writer.writeInt(-2)
+	default:
+		s.writeStatusError(res.Status)
+	}
+}
+
+// INCR key
+func (s *session) incr(args [][]byte) {
+	s.incrementBy(args[1], 1)
+}
+
+// DECR key
+func (s *session) decr(args [][]byte) {
+	s.incrementBy(args[1], -1)
+}
+
+// INCRBY key increment
+func (s *session) incrBy(args [][]byte) {
+	if delta, err := strconv.ParseInt(string(args[2]), 10, 32); err == nil {
+		s.incrementBy(args[1], delta)
+	} else {
+		s.writeNotIntegerError()
+	}
+}
+
+// DECRBY key decrement
+func (s *session) decrBy(args [][]byte) {
+	if delta, err := strconv.ParseInt(string(args[2]), 10, 32); err == nil && delta != math.MinInt32 {
+		s.incrementBy(args[1], -delta)
+	} else {
+		s.writeNotIntegerError()
+	}
+}
+
+// incrementBy adds delta to the 4-byte big-endian counter of the key. The new
+// value is computed by the counter UDF on a UDFGet, and written with an Update
+// conditional on the version read, retried on a conflict. A counter not
+// existing is created with the value delta.
+func (s *session) incrementBy(key []byte, delta int64) {
+	params := make([]byte, 4)
+	binary.BigEndian.PutUint32(params, uint32(int32(delta)))
+
+	var res gateway.Result
+	var err error
+	for i := 0; i < kMaxIncrTries; i++ {
+		request := s.newRequest(proto.OpCodeUDFGet, key, params, 0)
+		request.SetUDFName([]byte(kCounterUDFName))
+		if res, err = s.process(request); err != nil {
+			break
+		}
+		if res.Status == proto.OpStatusNoKey {
+			if res, err = s.process(s.newRequest(proto.OpCodeCreate, key, params, 0)); err != nil {
+				break
+			}
+			if gateway.IsOK(res.Status) {
+				s.writer.writeInt(delta)
+				return
+			}
+			if res.Status == proto.OpStatusDupKey {
+				continue
+			}
+			break
+		}
+		if !gateway.IsOK(res.Status) {
+			break
+		}
+		if len(res.Value) != 4 {
+			s.writeNotIntegerError()
+			return
+		}
+		value := res.Value
+		request = s.newRequest(proto.OpCodeUpdate, key, value, 0)
+		request.SetVersion(res.Version)
+		request.SetCreationTime(res.CreationTime)
+		if res, err = s.process(request); err != nil {
+			break
+		}
+		if gateway.IsOK(res.Status) {
+			s.writer.writeInt(int64(int32(binary.BigEndian.Uint32(value))))
+			return
+		}
+		if res.Status != proto.OpStatusVersionConflict && res.Status != proto.OpStatusRecordLocked {
+			break
+		}
+	}
+	if err != nil {
+		s.writer.writeError("ERR " + err.Error())
+	} else {
+		s.writeStatusError(res.Status)
+	}
+}
+
+// MGET key [key ...]
+func (s *session) mget(args [][]byte) {
+	keys := args[1:]
+	requests := make([]*proto.OperationalMessage, len(keys))
+	for i, key := range keys {
+		requests[i] = s.newRequest(proto.OpCodeGet, key, nil, 0)
+	}
+	results, err := s.processAll(requests)
+	if err != nil {
+		s.writer.writeError("ERR " + err.Error())
+		return
+	}
+	for _, res := range results {
+		if !gateway.IsOK(res.Status) && res.Status != proto.OpStatusNoKey {
+			s.writeStatusError(res.Status)
+			return
+		}
+	}
+	s.writer.writeArrayLen(len(results))
+	for _, res := range results {
+		if gateway.IsOK(res.Status) {
+			s.writer.writeBulk(res.Value)
+		} else {
+			s.writer.writeNull()
+		}
+	}
+}
+
+// MSET key value [key value ...]
+func (s *session) mset(args [][]byte) {
+	if len(args)%2 == 0 {
+		s.writer.writeError("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+	requests := make([]*proto.OperationalMessage, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		requests = append(requests, s.newRequest(proto.OpCodeSet, args[i], args[i+1], 0))
+	}
+	results, err := s.processAll(requests)
+	if err != nil {
+		s.writer.writeError("ERR " + err.Error())
+		return
+	}
+	for _, res := range results {
+		if !gateway.IsOK(res.Status) {
+			s.writeStatusError(res.Status)
+			return
+		}
+	}
+	s.writer.writeSimple("OK")
+}
+
+// SELECT namespace
+func (s *session) selectNamespace(args [][]byte) {
+	if len(args[1]) == 0 {
+		s.writer.writeError("ERR invalid namespace")
+		return
+	}
+	s.namespace = string(args[1])
+	s.writer.writeSimple("OK")
+}
+
+// PING [message]
+func (s *session) ping(args [][]byte) {
+	switch len(args) {
+	case 1:
+		s.writer.writeSimple("PONG")
+	case 2:
+		s.writer.writeBulk(args[1])
+	default:
+		s.writer.writeError("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+// ECHO message
+func (s *session) echo(args [][]byte) {
+	s.writer.writeBulk(args[1])
+}
+
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (s *session) hello(args [][]byte) {
+	if len(args) > 1 {
+		switch string(args[1]) {
+		case "2":
+			s.writer.resp3 = false
+		case "3":
+			s.writer.resp3 = true
+		default:
+			s.writer.writeError("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+	protover := int64(2)
+	if s.writer.resp3 {
+		protover = 3
+	}
+	s.writer.writeMapLen(6)
+	s.writer.writeBulkString("server")
+	s.writer.writeBulkString("juno")
+	s.writer.writeBulkString("version")
+	s.writer.writeBulkString(version.Version)
+	s.writer.writeBulkString("proto")
+	s.writer.writeInt(protover)
+	s.writer.writeBulkString("mode")
+	s.writer.writeBulkString("standalone")
+	s.writer.writeBulkString("role")
+	s.writer.writeBulkString("master")
+	s.writer.writeBulkString("modules")
+	s.writer.writeArrayLen(0)
+}
+
+// QUIT
+func (s *session) quit(args [][]byte) {
+	s.writer.writeSimple("OK")
+	s.closing = true
+}
+
+// COMMAND ..., with no command documented
+func (s *session) command(args [][]byte) {
+	s.writer.writeArrayLen(0)
+}
+
+// CLIENT SETNAME | SETINFO ..., accepted and ignored
+func (s *session) client(args [][]byte) {
+	switch strings.ToUpper(string(args[1])) {
+	case "SETNAME", "SETINFO":
+		s.writer.writeSimple("OK")
+	default:
+		s.writer.writeError("ERR unsupported CLIENT subcommand '" + string(args[1]) + "'")
+	}
+}
@@ -0,0 +1,297 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	goio "io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/udf"
+)
+
+type fakeRecord struct {
+	value          []byte
+	version        uint32
+	expirationTime uint32
+}
+
+// fakeHandler processes the requests on an in memory map, as the proxy would.
+type fakeHandler struct {
+	mtx     sync.Mutex
+	records map[string]*fakeRecord
+}
+
+func (h *fakeHandler) Init()                                             {}
+func (h *fakeHandler) Finish()                                           {}
+func (h *fakeHandler) GetReqCtxCreator() io.InboundRequestContextCreator { return nil }
+func (h *fakeHandler) OnKeepAlive(c *io.Connector, r io.IRequestContext) error {
+	return nil
+}
+
+func (h *fakeHandler) Process(reqCtx io.IRequestContext) error {
+	var request proto.OperationalMessage
+	if err := request.Decode(reqCtx.GetMessage()); err != nil {
+		return err
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	k := string(request.GetNamespace()) + "/" + string(request.GetKey())
+	rec := h.records[k]
+	value, _ := request.GetPayload().GetClearValue()
+	ttl := request.GetTimeToLive()
+	if ttl == 0 {
+		ttl = 3600
+	}
+	resp := request.CreateResponse()
+	st := proto.OpStatusNoError
+	switch op := request.GetOpCode(); {
+	case op == proto.OpCodeCreate && rec != nil:
+		st = proto.OpStatusDupKey
+	case op == proto.OpCodeCreate || op == proto.OpCodeSet:
+		rec = &fakeRecord{value: value, expirationTime: uint32(time.Now().Unix()) + ttl}
+		h.records[k] = rec
+	case rec == nil:
+		if op != proto.OpCodeDestroy {
+			st = proto.OpStatusNoKey
+		}
+	case op == proto.OpCodeUpdate:
+		if v := request.GetVersion(); v != 0 && v != rec.version {
+			st = proto.OpStatusVersionConflict
+		} else {
+			rec.value = value
+			rec.version++
+		}
+	case op == proto.OpCodeDestroy:
+		delete(h.records, k)
+	case op == proto.OpCodeTouch:
+		rec.expirationTime = uint32(time.Now().Unix()) + ttl
+	case op == proto.OpCodeGet || op == proto.OpCodeUDFGet:
+		v := rec.value
+		if op == proto.OpCodeUDFGet {
+			v, _ = udf.GetUDFManager().GetUDF(string(request.GetUDFName())).Call(nil, v, value)
+		}
+		var payload proto.Payload
+		payload.SetWithClearValue(v)
+		resp.SetPayload(&payload)
+	}
+	if rec != nil && st == proto.OpStatusNoError {
+		resp.SetVersion(rec.version)
+		resp.SetCreationTime(1)
+		resp.SetExpirationTime(rec.expirationTime)
+	}
+	resp.SetOpStatus(st)
+	var raw proto.RawMessage
+	resp.Encode(&raw)
+	reqCtx.Reply(io.NewInboundRespose(request.GetOpCode(), &raw))
+	return nil
+}
+
+func TestReadCommand(t *testing.T) {
+	r := newReader(strings.NewReader("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$0\r\n\r\nget  k\r\n\r\n*1\r\n$4\r\nPING\n"), 64)
+	expected := [][]string{{"SET", "k", ""}, {"get", "k"}, nil}
+	for _, exp := range expected {
+		args, err := r.readCommand()
+		if err != nil || len(args) != len(exp) {
+			t.Fatalf("expect %q, got %q %v", exp, args, err)
+		}
+		for i := range exp {
+			if string(args[i]) != exp[i] {
+				t.Fatalf("expect %q, got %q", exp, args)
+			}
+		}
+	}
+	if _, err := r.readCommand(); err == nil {
+		t.Fatal("expect a protocol error on a bulk not terminated by CRLF")
+	}
+	if _, err := newReader(strings.NewReader("*1\r\n:1\r\n"), 64).readCommand(); err == nil {
+		t.Fatal("expect a protocol error on a non bulk argument")
+	}
+}
+
+func TestReadCommandLimits(t *testing.T) {
+	tooBig := fmt.Sprintf("*2\r\n$3\r\nGET\r\n$%d\r\n", config.Conf.MaxPayloadLength+1)
+	tooMany := fmt.Sprintf("*%d\r\n", kMaxNumArgs+1)
+	for _, cmd := range []string{tooBig, tooMany} {
+		_, err := newReader(strings.NewReader(cmd), 64).readCommand()
+		var perr errProtocol
+		if !errors.As(err, &perr) {
+			t.Errorf("expect a protocol error on %q, got %v", cmd, err)
+		}
+	}
+
+	// a bulk shorter than announced
+	cmd := fmt.Sprintf("*2\r\n$3\r\nSET\r\n$%d\r\nabc", config.Conf.MaxPayloadLength)
+	if _, err := newReader(strings.NewReader(cmd), 64).readCommand(); err != goio.ErrUnexpectedEOF {
+		t.Errorf("expect an unexpected EOF, got %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	udf.Init("")
+	config.Conf.Resp.NamespaceSeparator = ":"
+	defer func() { config.Conf.Resp.NamespaceSeparator = "" }()
+
+	iocfg := io.DefaultInboundConfig
+	client, server := net.Pipe()
+	go Serve(server, iocfg, &fakeHandler{records: make(map[string]*fakeRecord)})
+	defer client.Close()
+	rd := bufio.NewReader(client)
+
+	tests := []struct {
+		cmd      string
+		expected string
+	}{
+		{"PING", "+PONG\r\n"},
+		{"GET k", "$-1\r\n"},
+		{"SET k v EX 100", "+OK\r\n"},
+		{"SET k v2 NX", "$-1\r\n"},
+		{"SET k2 v XX", "$-1\r\n"},
+		{"SET k v NX XX", "-ERR syntax error\r\n"},
+		{"GET k", "$1\r\nv\r\n"},
+		{"GET ns:k", "$-1\r\n"},
+		{"SELECT ns", "+OK\r\n"},
+		{"SET k nsv", "+OK\r\n"},
+		{"MGET resp:k k missing", "*3\r\n$1\r\nv\r\n$3\r\nnsv\r\n$-1\r\n"},
+		{"EXISTS k resp:k missing", ":2\r\n"},
+		{"EXPIRE missing 10", ":0\r\n"},
+		{"EXPIRE k 10", ":1\r\n"},
+		{"TTL missing", ":-2\r\n"},
+		{"INCR c", ":1\r\n"},
+		{"INCRBY c 10", ":11\r\n"},
+		{"DECRBY c 20", ":-9\r\n"},
+		{"INCR k", "-ERR value is not an integer or out of range\r\n"},
+		{"MSET a 1 b", "-ERR wrong number of arguments for 'mset' command\r\n"},
+		{"MSET a 1 b 2", "+OK\r\n"},
+		{"DEL a b", ":2\r\n"},
+		{"MGET a b", "*2\r\n$-1\r\n$-1\r\n"},
+		{"HELLO 3", "%6\r\n"},
+		{"GET missing", "_\r\n"},
+		{"FOO", "-ERR unknown command 'FOO'\r\n"},
+		{"QUIT", "+OK\r\n"},
+	}
+	for _, test := range tests {
+		if _, err := client.Write([]byte(test.cmd + "\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		for got.Len() < len(test.expected) {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				t.Fatalf("%s: %s", test.cmd, err)
+			}
+			got.WriteString(line)
+		}
+		if got.String() != test.expected {
+			t.Fatalf("%s: expect %q, got %q", test.cmd, test.expected, got.String())
+		}
+		if test.cmd == "HELLO 3" {
+			// the rest of the map, ending with the empty modules
+			for {
+				line, err := rd.ReadString('\n')
+				if err != nil {
+					t.Fatal(err)
+				}
+				if line == "*0\r\n" {
+					break
+				}
+			}
+		}
+	}
+}
+
+// blockingHandler holds the requests until released.
+type blockingHandler struct {
+	fakeHandler
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Process(reqCtx io.IRequestContext) error {
+	h.started <- struct{}{}
+	<-h.release
+	return h.fakeHandler.Process(reqCtx)
+}
+
+func TestShutdownDuringCommand(t *testing.T) {
+	io.RegisterConnServer("resp", Serve)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	iocfg := io.DefaultInboundConfig
+	iocfg.IdleTimeout.Duration = time.Minute
+	h := &blockingHandler{
+		fakeHandler: fakeHandler{records: make(map[string]*fakeRecord)},
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	lsnr, err := io.NewListener(io.ListenerConfig{
+		ServiceEndpoint: io.ServiceEndpoint{Addr: addr},
+		Protocol:        "resp",
+	}, iocfg, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for lsnr.AcceptAndServe() == nil {
+		}
+	}()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte("SET k v\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-h.started
+	lsnr.Shutdown()
+	close(h.release)
+
+	// the command in progress is replied, then the connection is closed
+	// instead of waiting for the idle timeout
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	got, err := goio.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "+OK\r\n" {
+		t.Errorf("expect %q, got %q", "+OK\r\n", got)
+	}
+	lsnr.WaitForShutdownToComplete(time.Second)
+	if n := lsnr.GetNumActiveConnections(); n != 0 {
+		t.Errorf("%d connections still open", n)
+	}
+}
@@ -0,0 +1,126 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package resp
+
+import (
+	"context"
+	"errors"
+	goio "io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/gateway"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+type (
+	// session is the state of a connection
+	session struct {
+		conn       net.Conn
+		iocfg      io.InboundConfig
+		reqHandler io.IRequestHandler
+		reader     *reader
+		writer     *writer
+		namespace  string
+		appName    []byte
+		closing    bool
+	}
+)
+
+// Serve serves the commands of a Redis client until the connection is closed,
+// idle for longer than IdleTimeout or a protocol error.
+func Serve(conn net.Conn, iocfg io.InboundConfig, reqHandler io.IRequestHandler) {
+	conf := &config.Conf.Resp
+	s := &session{
+		conn:       conn,
+		iocfg:      iocfg,
+		reqHandler: reqHandler,
+		reader:     newReader(conn, iocfg.IOBufSize),
+		writer:     newWriter(conn, iocfg.IOBufSize),
+		namespace:  conf.Namespace,
+		appName:    []byte(conf.AppName),
+	}
+	for !s.closing {
+		if iocfg.IdleTimeout.Duration != 0 {
+			conn.SetReadDeadline(time.Now().Add(iocfg.IdleTimeout.Duration))
+		}
+		args, err := s.reader.readCommand()
+		if err != nil {
+			var perr errProtocol
+			if errors.As(err, &perr) {
+				s.writer.writeError("ERR " + perr.Error())
+				s.flush()
+			} else if err != goio.EOF && !errors.Is(err, net.ErrClosed) {
+				glog.Debugf("resp connection %s: %s", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		s.execute(args)
+		// replies to pipelined commands are flushed together
+		if !s.reader.buffered() {
+			if err = s.flush(); err != nil {
+				glog.Debugf("resp connection %s: %s", conn.RemoteAddr(), err)
+				return
+			}
+		}
+	}
+	s.flush()
+}
+
+func (s *session) flush() error {
+	if s.iocfg.WriteTimeout.Duration != 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.iocfg.WriteTimeout.Duration))
+	}
+	return s.writer.flush()
+}
+
+// namespaceAndKey returns the namespace given by the key prefix, if any, or the
+// one of the session.
+func (s *session) namespaceAndKey(key []byte) (ns []byte, k []byte) {
+	sep := config.Conf.Resp.NamespaceSeparator
+	if len(sep) != 0 {
+		if i := strings.Index(string(key), sep); i > 0 {
+			return key[:i], key[i+len(sep):]
+		}
+	}
+	return []byte(s.namespace), key
+}
+
+// newRequest returns a request for the key, in the namespace of the key.
+func (s *session) newRequest(op proto.OpCode, key []byte, value []byte, ttl uint32) *proto.OperationalMessage {
+	ns, k := s.namespaceAndKey(key)
+	return gateway.NewRequest(op, ns, k, value, ttl, s.conn.RemoteAddr(), s.appName)
+}
+
+func (s *session) process(request *proto.OperationalMessage) (gateway.Result, error) {
+	return gateway.Process(context.Background(), s.reqHandler, request, s.iocfg.RequestTimeout.Duration)
+}
+
+func (s *session) processAll(requests []*proto.OperationalMessage) ([]gateway.Result, error) {
+	return gateway.ProcessAll(context.Background(), s.reqHandler, requests, s.iocfg.RequestTimeout.Duration)
+}
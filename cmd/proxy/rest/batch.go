@@ -0,0 +1,128 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/gateway"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+type (
+	batchRequest struct {
+		Op            string `json:"op"` // GET, HEAD, PUT, POST or DELETE, as for a key
+		Namespace     string `json:"ns"`
+		Key           string `json:"key"`
+		Value         []byte `json:"value,omitempty"`
+		TTL           uint32 `json:"ttl,omitempty"`
+		Version       uint32 `json:"version,omitempty"` // a PUT with a version is an Update conditional on it
+		CorrelationID string `json:"correlationId,omitempty"`
+	}
+
+	batchResponse struct {
+		Status    int    `json:"status"`
+		Error     string `json:"error,omitempty"`
+		Value     []byte `json:"value,omitempty"`
+		Version   uint32 `json:"version,omitempty"`
+		TTL       int64  `json:"ttl,omitempty"`
+		ValueSize uint32 `json:"valueSize,omitempty"`
+	}
+)
+
+var batchOpCodes = map[string]proto.OpCode{
+	http.MethodGet:    proto.OpCodeGet,
+	http.MethodHead:   proto.OpCodeGetMeta,
+	http.MethodPut:    proto.OpCodeSet,
+	http.MethodPost:   proto.OpCodeCreate,
+	http.MethodDelete: proto.OpCodeDestroy,
+}
+
+// serveBatch processes the requests of the batch in parallel, and replies with
+// the responses in the same order.
+func (h *handler) serveBatch(w http.ResponseWriter, r *http.Request) {
+	maxSize := config.Conf.Rest.MaxBatchSize
+	// base64 values plus the other fields
+	maxBody := int64(maxSize) * int64(config.Conf.MaxPayloadLength*4/3+1024)
+
+	var batch struct {
+		Requests []batchRequest `json:"requests"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&batch); err != nil {
+		writeError(w, badRequest("%s", err))
+		return
+	}
+	if len(batch.Requests) > maxSize {
+		writeError(w, badRequest("more than %d requests", maxSize))
+		return
+	}
+	requests := make([]*proto.OperationalMessage, len(batch.Requests))
+	for i, req := range batch.Requests {
+		op, ok := batchOpCodes[strings.ToUpper(req.Op)]
+		if !ok {
+			writeError(w, badRequest("request %d: invalid op %q", i, req.Op))
+			return
+		}
+		if op == proto.OpCodeSet && req.Version != 0 {
+			op = proto.OpCodeUpdate
+		}
+		requests[i] = gateway.NewRequest(op, []byte(req.Namespace), []byte(req.Key), req.Value, req.TTL, h.remoteAddr, h.appName)
+		if req.Version != 0 {
+			requests[i].SetVersion(req.Version)
+		}
+		if len(req.CorrelationID) != 0 {
+			requests[i].SetCorrelationID([]byte(req.CorrelationID))
+		}
+	}
+	results, err := gateway.ProcessAll(r.Context(), h.reqHandler, requests, h.timeout)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := struct {
+		Responses []batchResponse `json:"responses"`
+	}{make([]batchResponse, len(results))}
+	for i := range results {
+		res := &results[i]
+		out := &resp.Responses[i]
+		if out.Status = httpStatus(res.Status); out.Status != http.StatusOK {
+			out.Error = res.Status.String()
+			continue
+		}
+		out.Version = res.Version
+		if res.ExpirationTime != 0 {
+			out.TTL = remainingTTL(res.ExpirationTime)
+		}
+		switch requests[i].GetOpCode() {
+		case proto.OpCodeGet:
+			out.Value = res.Value
+		case proto.OpCodeGetMeta:
+			out.ValueSize = res.ValueSize
+		case proto.OpCodeCreate:
+			out.Status = http.StatusCreated
+		default:
+			out.Status = http.StatusNoContent
+		}
+	}
+	writeJSON(w, http.StatusOK, &resp)
+}
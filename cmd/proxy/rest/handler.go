@@ -0,0 +1,269 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/gateway"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+const (
+	kHeaderTTL           = "X-Juno-TTL"
+	kHeaderCorrelationID = "X-Juno-Correlation-ID"
+	kKeyPathPrefix       = "/v1/ns/"
+	kBatchPath           = "/v1/batch"
+)
+
+type handler struct {
+	reqHandler io.IRequestHandler
+	timeout    time.Duration
+	remoteAddr net.Addr
+	appName    []byte
+}
+
+// httpError is an error replied with the HTTP status code
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, a...)}
+}
+
+// httpStatus returns the HTTP status code of an op status
+func httpStatus(st proto.OpStatus) int {
+	switch st {
+	case proto.OpStatusNoError, proto.OpStatusInconsistent:
+		return http.StatusOK
+	case proto.OpStatusNoKey:
+		return http.StatusNotFound
+	case proto.OpStatusDupKey:
+		return http.StatusConflict
+	case proto.OpStatusVersionConflict:
+		return http.StatusPreconditionFailed
+	case proto.OpStatusBadParam, proto.OpStatusBadMsg:
+		return http.StatusBadRequest
+	case proto.OpStatusRecordLocked, proto.OpStatusBusy, proto.OpStatusNoStorageServer:
+		return http.StatusServiceUnavailable
+	case proto.OpStatusReqProcTimeout:
+		return http.StatusGatewayTimeout
+	case proto.OpStatusNotSupported:
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var herr *httpError
+	if errors.As(err, &herr) {
+		code = herr.code
+	}
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	switch {
+	case path == kBatchPath:
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &httpError{http.StatusMethodNotAllowed, "method not allowed"})
+			return
+		}
+		h.serveBatch(w, r)
+	case strings.HasPrefix(path, kKeyPathPrefix):
+		h.serveKey(w, r, path[len(kKeyPathPrefix):])
+	default:
+		writeError(w, &httpError{http.StatusNotFound, "not found"})
+	}
+}
+
+// parseKeyPath returns the namespace and the key of {namespace}/keys/{key},
+// each path escaped.
+func parseKeyPath(path string) (ns string, key string, err error) {
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) != 3 || parts[1] != "keys" || len(parts[0]) == 0 || len(parts[2]) == 0 {
+		err = &httpError{http.StatusNotFound, "not found"}
+		return
+	}
+	if ns, err = url.PathUnescape(parts[0]); err == nil {
+		key, err = url.PathUnescape(parts[2])
+	}
+	if err != nil {
+		err = badRequest("%s", err)
+	}
+	return
+}
+
+func parseTTL(s string) (ttl uint32, err error) {
+	if len(s) != 0 {
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, 32); err != nil {
+			err = badRequest("invalid %s %q", kHeaderTTL, s)
+		}
+		ttl = uint32(n)
+	}
+	return
+}
+
+// parseIfMatch returns the version of the If-Match header, 0 for *.
+func parseIfMatch(s string) (version uint32, err error) {
+	if s == "*" {
+		return
+	}
+	var n uint64
+	if n, err = strconv.ParseUint(strings.Trim(strings.TrimPrefix(s, "W/"), `"`), 10, 32); err != nil || n == 0 {
+		err = badRequest("invalid If-Match %q", s)
+	}
+	version = uint32(n)
+	return
+}
+
+// serveKey serves
+//
+//	GET    get the value, extending the TTL to X-Juno-TTL if given
+//	HEAD   get the metadata only
+//	PUT    set the value, or update it with If-Match, or create it with If-None-Match: *
+//	POST   create the value
+//	DELETE destroy the value
+func (h *handler) serveKey(w http.ResponseWriter, r *http.Request, path string) {
+	ns, key, err := parseKeyPath(path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var ttl uint32
+	if ttl, err = parseTTL(r.Header.Get(kHeaderTTL)); err != nil {
+		writeError(w, err)
+		return
+	}
+	var op proto.OpCode
+	var version uint32
+	var value []byte
+	switch r.Method {
+	case http.MethodGet:
+		op = proto.OpCodeGet
+	case http.MethodHead:
+		op = proto.OpCodeGetMeta
+	case http.MethodDelete:
+		op = proto.OpCodeDestroy
+	case http.MethodPost:
+		op = proto.OpCodeCreate
+	case http.MethodPut:
+		op = proto.OpCodeSet
+		if ifMatch := r.Header.Get("If-Match"); len(ifMatch) != 0 {
+			op = proto.OpCodeUpdate
+			version, err = parseIfMatch(ifMatch)
+		} else if r.Header.Get("If-None-Match") == "*" {
+			op = proto.OpCodeCreate
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST, DELETE")
+		err = &httpError{http.StatusMethodNotAllowed, "method not allowed"}
+	}
+	if err == nil && (op == proto.OpCodeSet || op == proto.OpCodeUpdate || op == proto.OpCodeCreate) {
+		value, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, int64(config.Conf.MaxPayloadLength)))
+		if err != nil {
+			err = &httpError{http.StatusRequestEntityTooLarge, err.Error()}
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	request := gateway.NewRequest(op, []byte(ns), []byte(key), value, ttl, h.remoteAddr, h.appName)
+	if version != 0 {
+		request.SetVersion(version)
+	}
+	if cid := r.Header.Get(kHeaderCorrelationID); len(cid) != 0 {
+		request.SetCorrelationID([]byte(cid))
+		w.Header().Set(kHeaderCorrelationID, cid)
+	}
+	res, err := gateway.Process(r.Context(), h.reqHandler, request, h.timeout)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	code := httpStatus(res.Status)
+	if code != http.StatusOK {
+		writeError(w, &httpError{code, res.Status.String()})
+		return
+	}
+	setMetaHeaders(w.Header(), &res)
+	switch op {
+	case proto.OpCodeGet:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(res.Value)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(res.Value)
+	case proto.OpCodeGetMeta:
+		w.Header().Set("Content-Length", strconv.FormatUint(uint64(res.ValueSize), 10))
+		w.WriteHeader(http.StatusOK)
+	case proto.OpCodeCreate:
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// setMetaHeaders sets the ETag to the record version, and X-Juno-TTL to the
+// remaining TTL.
+func setMetaHeaders(header http.Header, res *gateway.Result) {
+	if res.Version != 0 {
+		header.Set("ETag", strconv.Quote(strconv.FormatUint(uint64(res.Version), 10)))
+	}
+	if res.ExpirationTime != 0 {
+		header.Set(kHeaderTTL, strconv.FormatInt(remainingTTL(res.ExpirationTime), 10))
+	}
+}
+
+func remainingTTL(expirationTime uint32) int64 {
+	if ttl := int64(expirationTime) - time.Now().Unix(); ttl > 0 {
+		return ttl
+	}
+	return 0
+}
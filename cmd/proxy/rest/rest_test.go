@@ -0,0 +1,164 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// fakeHandler processes the requests on an in memory map of versioned values.
+type fakeHandler struct {
+	mtx     sync.Mutex
+	records map[string]*proto.OperationalMessage
+}
+
+func (h *fakeHandler) Init()                                             {}
+func (h *fakeHandler) Finish()                                           {}
+func (h *fakeHandler) GetReqCtxCreator() io.InboundRequestContextCreator { return nil }
+func (h *fakeHandler) OnKeepAlive(c *io.Connector, r io.IRequestContext) error {
+	return nil
+}
+
+func (h *fakeHandler) Process(reqCtx io.IRequestContext) error {
+	request := &proto.OperationalMessage{}
+	if err := request.Decode(reqCtx.GetMessage()); err != nil {
+		return err
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	k := string(request.GetNamespace()) + "/" + string(request.GetKey())
+	rec := h.records[k]
+	st := proto.OpStatusNoError
+	switch op := request.GetOpCode(); {
+	case op == proto.OpCodeCreate && rec != nil:
+		st = proto.OpStatusDupKey
+	case op == proto.OpCodeSet || op == proto.OpCodeCreate:
+		request.SetVersion(1)
+		h.records[k], rec = request, request
+	case rec == nil:
+		st = proto.OpStatusNoKey
+	case op == proto.OpCodeUpdate && request.GetVersion() != 0 && request.GetVersion() != rec.GetVersion():
+		st = proto.OpStatusVersionConflict
+	case op == proto.OpCodeUpdate:
+		request.SetVersion(rec.GetVersion() + 1)
+		h.records[k], rec = request, request
+	case op == proto.OpCodeDestroy:
+		delete(h.records, k)
+	}
+	resp := request.CreateResponse()
+	if rec != nil && st == proto.OpStatusNoError {
+		resp.SetVersion(rec.GetVersion())
+		resp.SetExpirationTime(uint32(time.Now().Unix()) + 100)
+		if request.GetOpCode() == proto.OpCodeGet {
+			resp.SetPayload(rec.GetPayload())
+		}
+	}
+	resp.SetOpStatus(st)
+	var raw proto.RawMessage
+	resp.Encode(&raw)
+	reqCtx.Reply(io.NewInboundRespose(request.GetOpCode(), &raw))
+	return nil
+}
+
+func TestServe(t *testing.T) {
+	lsnr, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lsnr.Close()
+	reqHandler := &fakeHandler{records: make(map[string]*proto.OperationalMessage)}
+	go func() {
+		for {
+			conn, err := lsnr.Accept()
+			if err != nil {
+				return
+			}
+			go Serve(conn, io.DefaultInboundConfig, reqHandler)
+		}
+	}()
+	url := "http://" + lsnr.Addr().String()
+
+	do := func(method string, path string, body string, header map[string]string, expected int) *http.Response {
+		req, _ := http.NewRequest(method, url+path, bytes.NewBufferString(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("%s %s: expect %d, got %d", method, path, expected, resp.StatusCode)
+		}
+		return resp
+	}
+	do("GET", "/v1/ns/ns/keys/a%2Fb", "", nil, http.StatusNotFound)
+	do("POST", "/v1/ns/ns/keys/a%2Fb", "v1", nil, http.StatusCreated)
+	do("PUT", "/v1/ns/ns/keys/a%2Fb", "v1", map[string]string{"If-None-Match": "*"}, http.StatusConflict)
+	resp := do("PUT", "/v1/ns/ns/keys/a%2Fb", "v2", map[string]string{"If-Match": `"1"`, kHeaderCorrelationID: "c1"}, http.StatusNoContent)
+	if resp.Header.Get("ETag") != `"2"` || resp.Header.Get(kHeaderCorrelationID) != "c1" {
+		t.Fatalf("unexpected headers %v", resp.Header)
+	}
+	do("PUT", "/v1/ns/ns/keys/a%2Fb", "v3", map[string]string{"If-Match": `"1"`}, http.StatusPreconditionFailed)
+	do("PUT", "/v1/ns/ns/keys/a%2Fb", "v3", map[string]string{kHeaderTTL: "x"}, http.StatusBadRequest)
+	do("PATCH", "/v1/ns/ns/keys/a%2Fb", "", nil, http.StatusMethodNotAllowed)
+	do("GET", "/v2/ns/ns/keys/a", "", nil, http.StatusNotFound)
+
+	req, _ := http.NewRequest("GET", url+"/v1/ns/ns/keys/a%2Fb", nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	value, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(value) != "v2" || resp.Header.Get("ETag") != `"2"` || resp.Header.Get(kHeaderTTL) == "" {
+		t.Fatalf("unexpected response %q %v", value, resp.Header)
+	}
+
+	// the requests of a batch are processed in parallel
+	do("POST", "/v1/ns/ns/keys/d", "v", nil, http.StatusCreated)
+	batch := `{"requests":[{"op":"get","ns":"ns","key":"a/b"},{"op":"put","ns":"ns","key":"c","value":"djE="},` +
+		`{"op":"delete","ns":"ns","key":"d"},{"op":"head","ns":"ns","key":"missing"}]}`
+	if resp, err = http.Post(url+kBatchPath, "application/json", bytes.NewBufferString(batch)); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Responses []batchResponse `json:"responses"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	resp.Body.Close()
+	if err != nil || len(out.Responses) != 4 {
+		t.Fatalf("unexpected batch response %v %v", out, err)
+	}
+	if r := out.Responses; r[0].Status != 200 || string(r[0].Value) != "v2" || r[1].Status != 204 || r[2].Status != 204 || r[3].Status != 404 {
+		t.Fatalf("unexpected batch response %+v", r)
+	}
+	do("POST", kBatchPath, `{"requests":[{"op":"patch"}]}`, nil, http.StatusBadRequest)
+}
@@ -0,0 +1,81 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package rest serves the HTTP/JSON key-value API on the proxy listeners with
+// Protocol "http", translating the HTTP requests into Juno requests.
+package rest
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/io"
+)
+
+// connListener returns the connection once from Accept, and then blocks
+// until the connection is closed, so that the http.Server serves only it.
+type connListener struct {
+	conn     net.Conn
+	accepted bool
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	if !l.accepted {
+		l.accepted = true
+		return l.conn, nil
+	}
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Serve serves the HTTP requests of a connection until it is closed, or idle
+// for longer than IdleTimeout.
+func Serve(conn net.Conn, iocfg io.InboundConfig, reqHandler io.IRequestHandler) {
+	l := &connListener{conn: conn, done: make(chan struct{})}
+	srv := &http.Server{
+		Handler: &handler{
+			reqHandler: reqHandler,
+			timeout:    iocfg.RequestTimeout.Duration,
+			remoteAddr: conn.RemoteAddr(),
+			appName:    []byte(config.Conf.Rest.AppName),
+		},
+		ReadTimeout:  iocfg.ReadTimeout.Duration,
+		WriteTimeout: iocfg.RequestTimeout.Duration + iocfg.WriteTimeout.Duration,
+		IdleTimeout:  iocfg.IdleTimeout.Duration,
+		ConnState: func(c net.Conn, st http.ConnState) {
+			if st == http.StateClosed || st == http.StateHijacked {
+				l.Close()
+			}
+		},
+	}
+	srv.Serve(l)
+}
@@ -0,0 +1,145 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package shadow
+
+import (
+	"context"
+	goio "io"
+	"time"
+
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+var _ io.IRequestContext = (*requestT)(nil)
+
+// requestT is a request mirrored to the shadow cluster.
+type requestT struct {
+	util.QueItemBase
+	message   proto.RawMessage
+	timeSent  time.Time
+	op        string
+	read      bool
+	requestId string
+	namespace string
+	key       string
+	primary   Result
+}
+
+func (r *requestT) GetMessage() *proto.RawMessage {
+	return &r.message
+}
+
+func (r *requestT) GetCtx() context.Context {
+	return nil
+}
+
+func (r *requestT) Cancel() {
+}
+
+func (r *requestT) Read(reader goio.Reader) (n int, err error) {
+	// not implemented
+	return 0, nil
+}
+
+func (r *requestT) WriteWithOpaque(opaque uint32, w goio.Writer) (n int, err error) {
+	var msg proto.RawMessage
+	msg.ShallowCopy(&r.message)
+	msg.SetOpaque(opaque)
+	n, err = msg.Write(w)
+	return
+}
+
+func (r *requestT) Reply(resp io.IResponseContext) {
+	switch status := resp.GetStatus(); status {
+	case proto.StatusOk:
+		var msg proto.OperationalMessage
+		if err := msg.Decode(resp.GetMessage()); err != nil {
+			count(r.op, func(c *Counts) { c.Errors++ })
+			break
+		}
+		shadow := Result{
+			Status:  msg.GetOpStatus().String(),
+			Version: msg.GetVersion(),
+			Digest:  Digest(msg.GetPayload()),
+		}
+		r.compare(shadow)
+	case proto.StatusRBExpire:
+		count(r.op, func(c *Counts) { c.Timeouts++ })
+	default:
+		count(r.op, func(c *Counts) { c.Errors++ })
+	}
+	resp.OnComplete()
+	r.OnComplete()
+}
+
+// compare counts the shadow result as a match or a mismatch, and logs the
+// mismatch if sampled. The values are only compared for the reads.
+func (r *requestT) compare(shadow Result) {
+	kind := ""
+	if shadow.Status != r.primary.Status {
+		kind = kDiffStatus
+	} else if r.read && shadow.Digest != r.primary.Digest {
+		kind = kDiffValue
+	}
+	count(r.op, func(c *Counts) {
+		switch kind {
+		case kDiffStatus:
+			c.StatusMismatches++
+		case kDiffValue:
+			c.ValueMismatches++
+		default:
+			c.Matched++
+		}
+	})
+	if len(kind) != 0 {
+		addDiff(&Diff{
+			Time:      r.timeSent,
+			RequestId: r.requestId,
+			Op:        r.op,
+			Namespace: r.namespace,
+			Key:       r.key,
+			Kind:      kind,
+			Primary:   r.primary,
+			Shadow:    shadow,
+			ElapsedUs: time.Since(r.timeSent).Microseconds(),
+		})
+	}
+}
+
+func (r *requestT) OnComplete() {
+	r.message.ReleaseBuffer()
+}
+
+func (r *requestT) OnCleanup() {
+	r.Reply(io.NewErrorOutboundResponse(proto.StatusRBCleanup))
+}
+
+func (r *requestT) OnExpiration() {
+	r.Reply(io.NewErrorOutboundResponse(proto.StatusRBExpire))
+}
+
+func (r *requestT) GetReceiveTime() time.Time {
+	return r.timeSent
+}
+
+func (r *requestT) SetTimeout(parent context.Context, timeout time.Duration) {
+}
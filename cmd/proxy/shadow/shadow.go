@@ -0,0 +1,206 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package shadow mirrors a sample of the client requests to the proxy of a
+// shadow cluster, fire-and-forget, through an outbound processor of its own.
+// The responses are compared with the ones replied to the clients as they
+// arrive, and the mismatches are counted per opcode, and sampled to
+// shadowdiff.<worker id>.log in the state log directory.
+package shadow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kSampleRange = 1000000 // the keys are sampled in millionths
+)
+
+type (
+	// Result is what a cluster responded to a request.
+	Result struct {
+		Status  string
+		Version uint32 `json:",omitempty"`
+		Digest  string `json:",omitempty"` // hex SHA-256 of the value, if any
+	}
+)
+
+var (
+	enabled        bool
+	readThreshold  uint64
+	writeThreshold uint64
+	namespaces     map[string]bool
+	timeout        time.Duration
+	processor      *io.OutboundProcessor
+)
+
+// Initialize is the initializer registered with initmgr. It expects the
+// shadow config, the state log directory and the worker id.
+func Initialize(args ...interface{}) (err error) {
+	if len(args) < 3 {
+		err = fmt.Errorf("shadow config, log directory and worker id expected")
+		glog.Error(err)
+		return
+	}
+	conf, ok1 := args[0].(*config.ShadowConfig)
+	dir, ok2 := args[1].(string)
+	wid, ok3 := args[2].(int)
+	if !ok1 || !ok2 || !ok3 {
+		err = fmt.Errorf("wrong argument type")
+		glog.Error(err)
+		return
+	}
+	err = Init(conf, dir, wid)
+	return
+}
+
+// Init starts mirroring the requests if configured. It is to be called
+// before any request is processed.
+func Init(conf *config.ShadowConfig, dir string, wid int) (err error) {
+	if !conf.Enabled() {
+		glog.Info("shadowing disabled")
+		return
+	}
+	if err = initStats(conf, dir, wid); err != nil {
+		glog.Errorf("failed to open shadow diff log: %s", err.Error())
+		return
+	}
+	readThreshold = uint64(conf.ReadPercent * kSampleRange / 100)
+	writeThreshold = uint64(conf.WritePercent * kSampleRange / 100)
+	if len(conf.Namespaces) != 0 {
+		namespaces = make(map[string]bool, len(conf.Namespaces))
+		for _, ns := range conf.Namespaces {
+			namespaces[ns] = true
+		}
+	}
+	timeout = conf.Timeout.Duration
+	processor = io.NewOutbProcessor(conf.ServiceEndpoint, &conf.IO, true)
+	enabled = true
+	glog.Infof("shadowing %g%% of the reads and %g%% of the writes to %s", conf.ReadPercent, conf.WritePercent, conf.Addr)
+	return
+}
+
+func Finalize() {
+	if processor != nil {
+		processor.Shutdown()
+		processor.WaitShutdown()
+	}
+	closeStats()
+}
+
+func Enabled() bool {
+	return enabled
+}
+
+func isRead(op proto.OpCode) bool {
+	switch op {
+	case proto.OpCodeGet, proto.OpCodeUDFGet, proto.OpCodeExists, proto.OpCodeGetMeta:
+		return true
+	}
+	return false
+}
+
+func isWrite(op proto.OpCode) bool {
+	switch op {
+	case proto.OpCodeCreate, proto.OpCodeUpdate, proto.OpCodeSet, proto.OpCodeDestroy, proto.OpCodeUDFSet, proto.OpCodeTouch:
+		return true
+	}
+	return false
+}
+
+// keySample maps the key to [0, kSampleRange), the same way on all the
+// proxies.
+func keySample(ns []byte, key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(ns)
+	h.Write([]byte{0})
+	h.Write(key)
+	return h.Sum64() % kSampleRange
+}
+
+// IsShadowed returns whether the client request is to be mirrored. The
+// requests from replication are not.
+func IsShadowed(req *proto.OperationalMessage) bool {
+	if !enabled || req.IsForReplication() {
+		return false
+	}
+	var threshold uint64
+	if op := req.GetOpCode(); isRead(op) {
+		threshold = readThreshold
+	} else if isWrite(op) {
+		threshold = writeThreshold
+	} else {
+		return false
+	}
+	if namespaces != nil && !namespaces[string(req.GetNamespace())] {
+		return false
+	}
+	return keySample(req.GetNamespace(), req.GetKey()) < threshold
+}
+
+// Digest returns the hex SHA-256 of the value of the payload as replied to
+// the client, or an empty string if there is none.
+func Digest(payload *proto.Payload) string {
+	if payload.GetLength() == 0 {
+		return ""
+	}
+	h := sha256.Sum256(payload.GetData())
+	return hex.EncodeToString(h[:])
+}
+
+// Mirror sends the request to the shadow cluster, without waiting for the
+// response, which is compared with the result replied to the client.
+func Mirror(req *proto.OperationalMessage, primary Result) {
+	if !IsShadowed(req) {
+		return
+	}
+	op := req.GetOpCodeText()
+	r := &requestT{
+		timeSent:  time.Now(),
+		op:        op,
+		read:      isRead(req.GetOpCode()),
+		requestId: req.GetRequestIDString(),
+		namespace: string(req.GetNamespace()),
+		key:       util.ToPrintableString(req.GetKey()),
+		primary:   primary,
+	}
+	if err := req.Encode(&r.message); err != nil {
+		glog.Warningf("failed to encode shadow request: %s", err.Error())
+		count(op, func(c *Counts) { c.Errors++ })
+		return
+	}
+	r.SetQueTimeout(timeout)
+	if err := processor.SendRequest(r); err != nil {
+		count(op, func(c *Counts) { c.Dropped++ })
+		r.OnComplete()
+		return
+	}
+	count(op, func(c *Counts) { c.Sent++ })
+}
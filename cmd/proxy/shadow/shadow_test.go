@@ -0,0 +1,190 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package shadow
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+// serveShadow replies to the requests with NoError, and with "value" to the
+// reads.
+func serveShadow(t *testing.T, ln net.Listener) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		var raw proto.RawMessage
+		if _, err := raw.Read(conn); err != nil {
+			return
+		}
+		var req proto.OperationalMessage
+		if err := req.Decode(&raw); err != nil {
+			t.Error(err)
+			return
+		}
+		resp := req.CreateResponse()
+		resp.SetOpStatus(proto.OpStatusNoError)
+		if isRead(req.GetOpCode()) {
+			var payload proto.Payload
+			payload.SetWithClearValue([]byte("value"))
+			resp.SetPayload(&payload)
+		}
+		var out proto.RawMessage
+		resp.Encode(&out)
+		out.SetOpaque(raw.GetOpaque())
+		if _, err := out.Write(conn); err != nil {
+			return
+		}
+	}
+}
+
+func newRequest(op proto.OpCode, ns string, key string) *proto.OperationalMessage {
+	req := &proto.OperationalMessage{}
+	req.SetAsRequest()
+	req.SetOpCode(op)
+	req.SetNamespace([]byte(ns))
+	req.SetKey([]byte(key))
+	req.SetNewRequestID()
+	return req
+}
+
+func TestShadow(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveShadow(t, ln)
+
+	conf := config.ShadowConfig{
+		ServiceEndpoint:   io.ServiceEndpoint{Addr: ln.Addr().String()},
+		ReadPercent:       100,
+		WritePercent:      50,
+		Namespaces:        []string{"ns"},
+		Timeout:           util.Duration{Duration: time.Second},
+		DiffSamplePercent: 100,
+		MaxDiffs:          10,
+		MaxFileSize:       1 << 20,
+		IO:                io.DefaultOutboundConfig,
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(&conf, t.TempDir(), 1); err != nil {
+		t.Fatal(err)
+	}
+	defer Finalize()
+
+	if IsShadowed(newRequest(proto.OpCodeGet, "other", "k")) {
+		t.Error("namespace not configured shadowed")
+	}
+	replicated := newRequest(proto.OpCodeGet, "ns", "k")
+	replicated.SetAsReplication()
+	if IsShadowed(replicated) {
+		t.Error("replication request shadowed")
+	}
+	numWrites := 0
+	for i := 0; i < 1000; i++ {
+		if IsShadowed(newRequest(proto.OpCodeSet, "ns", fmt.Sprintf("key%d", i))) {
+			numWrites++
+		}
+	}
+	if numWrites < 400 || numWrites > 600 {
+		t.Errorf("%d of 1000 writes shadowed with WritePercent 50", numWrites)
+	}
+
+	var payload proto.Payload
+	payload.SetWithClearValue([]byte("value"))
+	digest := Digest(&payload)
+	for start := time.Now(); processor.GetNumConnections() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("not connected to the shadow proxy")
+		}
+	}
+	Mirror(newRequest(proto.OpCodeGet, "ns", "match"), Result{Status: proto.OpStatusNoError.String(), Digest: digest})
+	Mirror(newRequest(proto.OpCodeGet, "ns", "value"), Result{Status: proto.OpStatusNoError.String(), Digest: "x"})
+	Mirror(newRequest(proto.OpCodeGet, "ns", "status"), Result{Status: proto.OpStatusNoKey.String()})
+
+	var c Counts
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if p, ok := GetReport().Ops["Get"]; ok {
+			c = *p
+		}
+		if c.Matched+c.ValueMismatches+c.StatusMismatches == 3 || time.Since(start) > 5*time.Second {
+			break
+		}
+	}
+	if c.Sent != 3 || c.Matched != 1 || c.ValueMismatches != 1 || c.StatusMismatches != 1 {
+		t.Errorf("unexpected counts %+v", c)
+	}
+	rep := GetReport()
+	if len(rep.Diffs) != 2 || rep.Diffs[0].Worker != 1 {
+		t.Errorf("unexpected diffs %+v", rep.Diffs)
+	}
+
+	merged := Merge([]Report{rep, rep})
+	if merged.Ops["Get"].Sent != 6 || len(merged.Diffs) != 4 {
+		t.Errorf("unexpected merged report %+v", merged)
+	}
+}
+
+func TestInitStats(t *testing.T) {
+	conf := config.ShadowConfig{DiffSamplePercent: 100, MaxDiffs: -1, MaxFileSize: 1 << 20}
+	if err := initStats(&conf, t.TempDir(), 0); err == nil {
+		t.Error("negative MaxDiffs accepted")
+	}
+
+	conf.MaxDiffs = 10
+	dirs := []string{t.TempDir(), t.TempDir()}
+	for _, dir := range dirs {
+		if err := initStats(&conf, dir, 2); err != nil {
+			t.Fatal(err)
+		}
+		addDiff(&Diff{Op: "Get", Kind: kDiffStatus})
+	}
+	closeStats()
+	if rep := GetReport(); len(rep.Diffs) != 1 {
+		t.Errorf("%d diffs kept after reinit, 1 expected", len(rep.Diffs))
+	}
+	for _, dir := range dirs {
+		name := filepath.Join(dir, "shadowdiff.2.log")
+		var b []byte
+		for i := 0; i < 100 && len(b) == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+			b, _ = os.ReadFile(name)
+		}
+		if strings.Count(string(b), "\n") != 1 {
+			t.Errorf("unexpected content %q of %s", b, name)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package shadow
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kWriteQueueLen = 1024 // diffs are dropped from the file if the writer falls behind
+
+	kDiffStatus = "Status"
+	kDiffValue  = "Value"
+)
+
+type (
+	// Counts are the numbers of the requests mirrored and of their outcomes.
+	Counts struct {
+		Sent             uint64
+		Dropped          uint64 // not sent, the shadow cluster not connected or the queue full
+		Errors           uint64
+		Timeouts         uint64
+		Matched          uint64
+		StatusMismatches uint64
+		ValueMismatches  uint64 // same status, but a different value read
+	}
+
+	// Diff is a response of the shadow cluster not matching the one replied
+	// to the client.
+	Diff struct {
+		Time      time.Time // when the request was mirrored
+		Worker    int
+		RequestId string
+		Op        string
+		Namespace string
+		Key       string
+		Kind      string // Status or Value
+		Primary   Result
+		Shadow    Result
+		ElapsedUs int64 // until the shadow response
+	}
+
+	// Report is served on /shadow.
+	Report struct {
+		Ops   map[string]*Counts // by opcode
+		Diffs []Diff             // the latest first
+	}
+)
+
+var (
+	workerId      int
+	diffPercent   float64
+	chWrite       chan []byte
+	mtx           sync.Mutex
+	opCounts      = map[string]*Counts{}
+	diffs         []*Diff
+	nextDiffIndex int
+)
+
+// initStats resets the counts and the diffs kept, and opens the diff log of
+// the worker, shadowdiff.<worker id>.log, in place of the one opened before.
+func initStats(conf *config.ShadowConfig, dir string, wid int) (err error) {
+	if conf.MaxDiffs < 0 {
+		return fmt.Errorf("Shadow.MaxDiffs %d negative", conf.MaxDiffs)
+	}
+	closeStats()
+	file := util.NewRotatingFile(dir, fmt.Sprintf("shadowdiff.%d.", wid), conf.MaxFileSize, conf.MaxFiles)
+	if err = file.Open(); err != nil {
+		return
+	}
+	ch := make(chan []byte, kWriteQueueLen)
+	go func() {
+		for b := range ch {
+			file.Write(b)
+		}
+		file.Close()
+	}()
+
+	mtx.Lock()
+	workerId = wid
+	diffPercent = conf.DiffSamplePercent
+	opCounts = map[string]*Counts{}
+	diffs = make([]*Diff, conf.MaxDiffs)
+	nextDiffIndex = 0
+	chWrite = ch
+	mtx.Unlock()
+	return
+}
+
+// closeStats stops the writer of the diff log, which closes the file once
+// the diffs queued are written.
+func closeStats() {
+	mtx.Lock()
+	if chWrite != nil {
+		close(chWrite)
+		chWrite = nil
+	}
+	mtx.Unlock()
+}
+
+func count(op string, f func(c *Counts)) {
+	mtx.Lock()
+	c, ok := opCounts[op]
+	if !ok {
+		c = &Counts{}
+		opCounts[op] = c
+	}
+	f(c)
+	mtx.Unlock()
+}
+
+// addDiff logs the diff to the file, and keeps it for /shadow, if sampled.
+func addDiff(d *Diff) {
+	if diffPercent < 100 && rand.Float64()*100 >= diffPercent {
+		return
+	}
+	mtx.Lock()
+	d.Worker = workerId
+	if chWrite != nil {
+		if b, err := json.Marshal(d); err == nil {
+			select {
+			case chWrite <- append(b, '\n'):
+			default:
+			}
+		}
+	}
+	if len(diffs) != 0 {
+		diffs[nextDiffIndex] = d
+		nextDiffIndex = (nextDiffIndex + 1) % len(diffs)
+	}
+	mtx.Unlock()
+}
+
+// GetReport returns the counts and the diffs kept of the worker.
+func GetReport() (rep Report) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	rep.Ops = make(map[string]*Counts, len(opCounts))
+	for op, c := range opCounts {
+		cnt := *c
+		rep.Ops[op] = &cnt
+	}
+	n := len(diffs)
+	rep.Diffs = []Diff{}
+	for i := 1; i <= n; i++ {
+		if d := diffs[(nextDiffIndex-i+n)%n]; d != nil {
+			rep.Diffs = append(rep.Diffs, *d)
+		}
+	}
+	return
+}
+
+func (c *Counts) add(other *Counts) {
+	c.Sent += other.Sent
+	c.Dropped += other.Dropped
+	c.Errors += other.Errors
+	c.Timeouts += other.Timeouts
+	c.Matched += other.Matched
+	c.StatusMismatches += other.StatusMismatches
+	c.ValueMismatches += other.ValueMismatches
+}
+
+// Merge sums up the reports of the workers.
+func Merge(reports []Report) (rep Report) {
+	rep.Ops = map[string]*Counts{}
+	rep.Diffs = []Diff{}
+	for i := range reports {
+		for op, c := range reports[i].Ops {
+			if _, ok := rep.Ops[op]; !ok {
+				rep.Ops[op] = &Counts{}
+			}
+			rep.Ops[op].add(c)
+		}
+		rep.Diffs = append(rep.Diffs, reports[i].Diffs...)
+	}
+	sort.SliceStable(rep.Diffs, func(i, j int) bool {
+		return rep.Diffs[i].Time.After(rep.Diffs[j].Time)
+	})
+	return
+}
+
+// HttpHandler serves the report of the worker.
+func HttpHandler(w http.ResponseWriter, r *http.Request) {
+	ServeReport(w, r, GetReport())
+}
+
+// ServeReport writes the report as JSON, with the diffs of the "op" query
+// parameter, and the first "n" ones, if given.
+func ServeReport(w http.ResponseWriter, r *http.Request, rep Report) {
+	query := r.URL.Query()
+	n := -1
+	if s := query.Get("n"); len(s) != 0 {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	op := query.Get("op")
+	filtered := []Diff{}
+	for _, d := range rep.Diffs {
+		if n >= 0 && len(filtered) >= n {
+			break
+		}
+		if len(op) == 0 || d.Op == op {
+			filtered = append(filtered, d)
+		}
+	}
+	rep.Diffs = filtered
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(rep)
+}
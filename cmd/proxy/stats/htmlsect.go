@@ -131,7 +131,9 @@ func (s *htmlSectReplicationStatsT) Body() template.HTML {
 	numTargets := len(targets)
 	if numTargets != 0 && len(repStats) == numTargets {
 		fmt.Fprint(&buf, `<div id="id-replicator-info"><table title="replicator-info">`)
-		fmt.Fprint(&buf, "<tr><th>Target</th><th>Connections</th><th>Queue Size</th><th>Max Queue Size</th><th>Drop Count</th><th>Error Count</th></tr>\n")
+		fmt.Fprint(&buf, "<tr><th>Target</th><th>Connections</th><th>Queue Size</th><th>Max Queue Size</th><th>Drop Count</th><th>Error Count</th>"+
+			"<th>Lag</th><th>In Flight</th><th>Ack Rate</th><th>Last Success</th><th>Alarms</th>"+
+			"<th>Spill Backlog</th><th>Spill Oldest Age</th><th>Spill Replay Rate</th></tr>\n")
 		for i := 0; i < numTargets; i++ {
 			fmt.Fprintf(&buf, "<tr>")
 			if repStats[i].NumConnections != 0 {
@@ -148,7 +150,33 @@ func (s *htmlSectReplicationStatsT) Body() template.HTML {
 			}
 			fmt.Fprintf(&buf, "<td>%d</td>", repStats[i].MaxSzQueue)
 			fmt.Fprintf(&buf, "<td>%d</td>", repStats[i].NumDrops)
-			fmt.Fprintf(&buf, "<td>%d</td></tr>\n", repStats[i].NumErrors)
+			fmt.Fprintf(&buf, "<td>%d</td>", repStats[i].NumErrors)
+			if repStats[i].Alarms&shmstats.RepAlarmLag == 0 {
+				fmt.Fprintf(&buf, "<td>%dms</td>", repStats[i].LagMs)
+			} else {
+				fmt.Fprintf(&buf, "<td style=\"background-color:#F29A38\">%dms</td>", repStats[i].LagMs)
+			}
+			fmt.Fprintf(&buf, "<td>%d</td><td>%d</td>", repStats[i].NumInFlight, repStats[i].AckRate)
+			if repStats[i].LastSuccess == 0 {
+				fmt.Fprintf(&buf, "<td>-</td>")
+			} else {
+				fmt.Fprintf(&buf, "<td>%s</td>", time.Unix(repStats[i].LastSuccess, 0).Format("2006-01-02 15:04:05"))
+			}
+			if repStats[i].Alarms == 0 {
+				fmt.Fprintf(&buf, "<td></td>")
+			} else {
+				fmt.Fprintf(&buf, "<td style=\"background-color:#F29A38\">%s</td>", shmstats.RepAlarmText(repStats[i].Alarms))
+			}
+			if targets[i].Spill != 0 {
+				if repStats[i].SpillBytes == 0 {
+					fmt.Fprintf(&buf, "<td>0</td>")
+				} else {
+					fmt.Fprintf(&buf, "<td style=\"background-color:#F29A38\">%d</td>", repStats[i].SpillBytes)
+				}
+				fmt.Fprintf(&buf, "<td>%ds</td><td>%d</td></tr>\n", repStats[i].SpillOldestAge, repStats[i].SpillReplayRate)
+			} else {
+				fmt.Fprintf(&buf, "<td>-</td><td>-</td><td>-</td></tr>\n")
+			}
 		}
 		fmt.Fprint(&buf, "</table></div>")
 	}
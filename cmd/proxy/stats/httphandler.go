@@ -27,8 +27,11 @@ import (
 	"github.com/BurntSushi/toml"
 
 	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/shadow"
 	"github.com/paypal/junodb/cmd/proxy/stats/qry"
+	"github.com/paypal/junodb/cmd/proxy/stats/slowlog"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/version"
 )
 
@@ -118,4 +121,16 @@ func initStatsForWorker(workerId int) {
 	addPage("/stats", httpStatsHandler)
 	addPage("/debug/shardmgr", debugShardManagerStatsHandler)
 	addPage("/debug/config", debugConfigHandler)
+
+	hotkey.Initialize(&config.Conf.HotKeys)
+	if hotkey.Enabled() {
+		addPage("/hotkeys", hotkey.HttpHandler)
+	}
+	slowlog.Initialize(&config.Conf.ReqProc.SlowLog, config.Conf.StateLogDir, workerId)
+	if slowlog.Enabled() {
+		addPage("/slowlog", slowlog.HttpHandler)
+	}
+	if config.Conf.Shadow.Enabled() {
+		addPage("/shadow", shadow.HttpHandler)
+	}
 }
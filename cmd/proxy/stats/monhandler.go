@@ -21,6 +21,7 @@ package stats
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
@@ -34,8 +35,11 @@ import (
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
 	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/shadow"
 	"github.com/paypal/junodb/cmd/proxy/stats/shmstats"
+	"github.com/paypal/junodb/cmd/proxy/stats/slowlog"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/version"
 )
 
@@ -105,6 +109,15 @@ func (h *HandlerForMonitor) ListenAndServe(addr string) error {
 	HttpServerMux.HandleFunc("/stats/json", h.httpJsonStatsHandler)
 	HttpServerMux.HandleFunc("/stats/text", h.httpTextStatsHandler)
 	HttpServerMux.HandleFunc("/version", version.HttpHandler)
+	if config.Conf.HotKeys.Enabled {
+		HttpServerMux.HandleFunc("/hotkeys", h.httpHotKeysHandler)
+	}
+	if config.Conf.ReqProc.SlowLog.Enabled {
+		HttpServerMux.HandleFunc("/slowlog", h.httpSlowLogHandler)
+	}
+	if config.Conf.Shadow.Enabled() {
+		HttpServerMux.HandleFunc("/shadow", h.httpShadowHandler)
+	}
 
 	HttpServerMux.HandleFunc("/cluster/", h.httpClusterConsoleHandler)
 	HttpServerMux.HandleFunc("/cluster/admin", h.dummyHandler)
@@ -229,6 +242,67 @@ func (h *HandlerForMonitor) getFromWorkerWithWorkerId(urlPath string, query url.
 	return
 }
 
+// httpHotKeysHandler merges the hot keys of all the workers, or of the worker
+// given by "wid".
+func (h *HandlerForMonitor) httpHotKeysHandler(w http.ResponseWriter, r *http.Request) {
+	workers := make([]int, 0, h.GetNumWorkers())
+	if wid := r.URL.Query().Get("wid"); wid != "" {
+		id, err := strconv.Atoi(wid)
+		if err != nil || id < 0 || id >= h.GetNumWorkers() {
+			http.Error(w, fmt.Sprintf("invalid wid %s", wid), http.StatusBadRequest)
+			return
+		}
+		workers = append(workers, id)
+	} else {
+		for i := 0; i < h.GetNumWorkers(); i++ {
+			workers = append(workers, i)
+		}
+	}
+	reports := make([]*hotkey.Report, 0, len(workers))
+	for _, id := range workers {
+		body, err := h.getFromWorkerWithWorkerId(r.URL.Path, url.Values{}, id)
+		rep := &hotkey.Report{}
+		if err == nil {
+			err = json.Unmarshal(body, rep)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("worker %d: %s", id, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, rep)
+	}
+	hotkey.ServeReport(w, r, hotkey.Merge(reports, config.Conf.HotKeys.TopK))
+}
+
+// httpSlowLogHandler writes the slow requests of all the workers, or of the
+// worker given by "wid", the latest first.
+func (h *HandlerForMonitor) httpSlowLogHandler(w http.ResponseWriter, r *http.Request) {
+	first, last := 0, h.GetNumWorkers()
+	if wid := r.URL.Query().Get("wid"); wid != "" {
+		id, err := strconv.Atoi(wid)
+		if err != nil || id < 0 || id >= h.GetNumWorkers() {
+			http.Error(w, fmt.Sprintf("invalid wid %s", wid), http.StatusBadRequest)
+			return
+		}
+		first, last = id, id+1
+	}
+	var entries []slowlog.Entry
+	for id := first; id < last; id++ {
+		body, err := h.getFromWorkerWithWorkerId(r.URL.Path, url.Values{}, id)
+		var workerEntries []slowlog.Entry
+		if err == nil {
+			err = json.Unmarshal(body, &workerEntries)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("worker %d: %s", id, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, workerEntries...)
+	}
+	slowlog.SortEntries(entries)
+	slowlog.ServeEntries(w, r, entries)
+}
+
 func (h *HandlerForMonitor) getFromWorker(urlPath string, query url.Values) (body []byte, err error) {
 	wid := query.Get("wid")
 	if wid != "" {
@@ -368,3 +442,31 @@ func (h *htmlSectAggReqProcStatsT) Body() template.HTML {
 
 	return template.HTML(buf.String())
 }
+
+// httpShadowHandler writes the shadow counts and diffs summed up over the
+// workers, or of the worker given by "wid".
+func (h *HandlerForMonitor) httpShadowHandler(w http.ResponseWriter, r *http.Request) {
+	first, last := 0, h.GetNumWorkers()
+	if wid := r.URL.Query().Get("wid"); wid != "" {
+		id, err := strconv.Atoi(wid)
+		if err != nil || id < 0 || id >= h.GetNumWorkers() {
+			http.Error(w, fmt.Sprintf("invalid wid %s", wid), http.StatusBadRequest)
+			return
+		}
+		first, last = id, id+1
+	}
+	var reports []shadow.Report
+	for id := first; id < last; id++ {
+		body, err := h.getFromWorkerWithWorkerId(r.URL.Path, url.Values{}, id)
+		var rep shadow.Report
+		if err == nil {
+			err = json.Unmarshal(body, &rep)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("worker %d: %s", id, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, rep)
+	}
+	shadow.ServeReport(w, r, shadow.Merge(reports))
+}
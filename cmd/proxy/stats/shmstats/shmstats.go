@@ -47,6 +47,13 @@ const (
 	kNumReservedAppNamespaceStats = 200
 )
 
+// The alarms of a replication target, set in ReplicatorStats.Alarms
+const (
+	RepAlarmLag uint16 = 1 << iota
+	RepAlarmQueue
+	RepAlarmNoSuccess
+)
+
 var (
 	shmStats ShmStatsManager
 )
@@ -64,6 +71,10 @@ type (
 		NumAlertShards       uint16
 		ProcCpuUsage         float32
 		MachCpuUsage         float32
+		NumHedgedReads       uint64
+		NumHedgeWins         uint64
+		HedgeRate            float32 // percentage of the reads hedged
+		HedgeWinRate         float32 // percentage of the hedged reads that won
 	}
 	WorkerStats struct {
 		Pid                 uint32
@@ -97,6 +108,7 @@ type (
 		Port     uint16
 		Type     uint16
 		CapQueue uint16
+		Spill    uint16
 		Addr     [256]byte
 		Name     [256]byte
 	}
@@ -110,11 +122,19 @@ type (
 		MaxNumConntions uint32
 	}
 	ReplicatorStats struct {
-		NumConnections uint16
-		SzQueue        uint16
-		MaxSzQueue     uint16
-		NumDrops       uint64
-		NumErrors      uint64
+		NumConnections  uint16
+		SzQueue         uint16
+		MaxSzQueue      uint16
+		NumDrops        uint64
+		NumErrors       uint64
+		SpillBytes      uint64
+		SpillOldestAge  uint32 // in seconds
+		SpillReplayRate uint32 // requests replayed per second
+		LagMs           uint32 // end-to-end replication lag in milliseconds
+		NumInFlight     uint32 // requests sent and not yet acknowledged
+		AckRate         uint32 // requests acknowledged per second
+		Alarms          uint16 // bit mask of the alarms raised
+		LastSuccess     int64  // unix time in seconds of the last ack
 	}
 	StatsByAppNamespace struct {
 		stats.AppNamespaceStats
@@ -196,6 +216,8 @@ func (s *ReqProcStats) PrettyPrint(w goio.Writer) {
 	fmt.Fprintf(w, "\tNumBadShards\t: %d\n", s.NumBadShards)
 	fmt.Fprintf(w, "\tNumWarnShards\t: %d\n", s.NumWarnShards)
 	fmt.Fprintf(w, "\tNumAlertShards\t: %d\n", s.NumAlertShards)
+	fmt.Fprintf(w, "\tNumHedgedReads\t: %d\n", s.NumHedgedReads)
+	fmt.Fprintf(w, "\tNumHedgeWins\t: %d\n", s.NumHedgeWins)
 }
 
 func (s *WorkerStats) PrettyPrint(w goio.Writer) {
@@ -403,6 +425,44 @@ func (m *workerStatsManagerT) SetReplicatorStats(targetId int, numConns uint16,
 	}
 }
 
+func (m *workerStatsManagerT) SetReplicatorSpillStats(targetId int, backlog uint64, oldestAge uint32, replayRate uint32) {
+	if targetId < len(m.repStats) {
+		if st := m.repStats[targetId]; st != nil {
+			st.SpillBytes = backlog
+			st.SpillOldestAge = oldestAge
+			st.SpillReplayRate = replayRate
+		}
+	}
+}
+
+// RepAlarmText returns the names of the alarms in the bit mask.
+func RepAlarmText(alarms uint16) string {
+	var names []string
+	if alarms&RepAlarmLag != 0 {
+		names = append(names, "Lag")
+	}
+	if alarms&RepAlarmQueue != 0 {
+		names = append(names, "Queue")
+	}
+	if alarms&RepAlarmNoSuccess != 0 {
+		names = append(names, "NoSuccess")
+	}
+	return strings.Join(names, ",")
+}
+
+func (m *workerStatsManagerT) SetReplicatorHealthStats(targetId int, lagMs uint32, numInFlight uint32,
+	ackRate uint32, lastSuccess int64, alarms uint16) {
+	if targetId < len(m.repStats) {
+		if st := m.repStats[targetId]; st != nil {
+			st.LagMs = lagMs
+			st.NumInFlight = numInFlight
+			st.AckRate = ackRate
+			st.LastSuccess = lastSuccess
+			st.Alarms = alarms
+		}
+	}
+}
+
 func (m *workerStatsManagerT) GetInboundConnStats() (stats []InboundConnStats) {
 	if m.stats != nil {
 		sz := len(m.connStats)
@@ -444,7 +504,9 @@ func (m workerStatsManagerT) MarshalJSON() ([]byte, error) {
 		if i != 0 {
 			buf.WriteByte(',')
 		}
-		fmt.Fprintf(&buf, `{"QueueSize":%d,"MaxQueueSize":%d}`, m.repStats[i].SzQueue, m.repStats[i].MaxSzQueue)
+		st := m.repStats[i]
+		fmt.Fprintf(&buf, `{"QueueSize":%d,"MaxQueueSize":%d,"LagMs":%d,"NumInFlight":%d,"AckRate":%d,"LastSuccess":%d,"Alarms":%d}`,
+			st.SzQueue, st.MaxSzQueue, st.LagMs, st.NumInFlight, st.AckRate, st.LastSuccess, st.Alarms)
 	}
 	buf.WriteByte(']')
 	buf.WriteString(`,"AppNsStats":[`)
@@ -682,6 +744,9 @@ func (s *ShmStatsManager) setServerStats(pid int) (err error) {
 				repTgt.Type = 1
 			}
 			repTgt.CapQueue = uint16(cfg.Replication.GetIoConfig(&r).ReqChanBufSize)
+			if r.HasSpill() {
+				repTgt.Spill = 1
+			}
 		} else {
 			return err
 		}
@@ -814,6 +879,11 @@ func (s *ShmStatsManager) PrettyPrint(iw goio.Writer, workerId string) {
 		for j := 0; j < nRepTgt; j++ {
 			fmt.Fprintf(w, "\tQueueSizeRepTarget_%d\t: %d\n", j, tgts[j].SzQueue)
 			fmt.Fprintf(w, "\tMaxQueueSizeRepTarget_%d\t: %d\n", j, tgts[j].MaxSzQueue)
+			fmt.Fprintf(w, "\tLagMsRepTarget_%d\t: %d\n", j, tgts[j].LagMs)
+			fmt.Fprintf(w, "\tInFlightRepTarget_%d\t: %d\n", j, tgts[j].NumInFlight)
+			fmt.Fprintf(w, "\tAckRateRepTarget_%d\t: %d\n", j, tgts[j].AckRate)
+			fmt.Fprintf(w, "\tLastSuccessRepTarget_%d\t: %d\n", j, tgts[j].LastSuccess)
+			fmt.Fprintf(w, "\tAlarmsRepTarget_%d\t: %d\n", j, tgts[j].Alarms)
 		}
 		for j := 0; j < int(worker.stats.NumAppNsStats); j++ {
 			d := worker.statsByNs[j]
@@ -853,6 +923,8 @@ func (m *ShmStatsManager) GetAggregatedReqProcStats() (s ReqProcStats) {
 			s.RequestsPerSecond += ws.RequestsPerSecond
 			totalProcTime += ws.RequestsPerSecond * ws.AvgReqProcTime
 			s.ReqProcErrsPerSecond += ws.ReqProcErrsPerSecond
+			s.NumHedgedReads += ws.NumHedgedReads
+			s.NumHedgeWins += ws.NumHedgeWins
 		}
 		s.AvgReqProcTime = uint32(float32(totalProcTime) / float32(s.RequestsPerSecond))
 	}
@@ -64,6 +64,10 @@ func (w *stateLogShmWriter) Write(now time.Time) error {
 				NumAlertShards:       uint16(atomic.LoadUint32(&statsNumAlertShards)),
 				ProcCpuUsage:         math.Float32frombits(atomic.LoadUint32((*uint32)(unsafe.Pointer(&statsProcCpuUsage)))),
 				MachCpuUsage:         math.Float32frombits(atomic.LoadUint32((*uint32)(unsafe.Pointer(&statsMachCpuUsage)))),
+				NumHedgedReads:       atomic.LoadUint64(&statsNumHedgedReads),
+				NumHedgeWins:         atomic.LoadUint64(&statsNumHedgeWins),
+				HedgeRate:            math.Float32frombits(atomic.LoadUint32((*uint32)(unsafe.Pointer(&statsHedgeRate)))),
+				HedgeWinRate:         math.Float32frombits(atomic.LoadUint32((*uint32)(unsafe.Pointer(&statsHedgeWinRate)))),
 			}
 			mgr.SetReqProcStats(stat)
 			stats.RangeAppNamespaceStats(func(index uint32, appNsKey []byte, st *stats.AppNamespaceStats) {
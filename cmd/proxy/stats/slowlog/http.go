@@ -0,0 +1,85 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package slowlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HttpHandler serves the entries of the worker.
+func HttpHandler(w http.ResponseWriter, r *http.Request) {
+	ServeEntries(w, r, Entries())
+}
+
+// SortEntries sorts the entries of the workers, the latest first.
+func SortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+}
+
+// Filter keeps the entries of the "op" and the "ns" query parameters, with a
+// response time at least "min", e.g. 100ms, and the first "n" ones. All of
+// them are optional.
+func Filter(entries []Entry, query url.Values) (filtered []Entry, err error) {
+	var minDuration time.Duration
+	if s := query.Get("min"); len(s) != 0 {
+		if minDuration, err = time.ParseDuration(s); err != nil {
+			return
+		}
+	}
+	n := -1
+	if s := query.Get("n"); len(s) != 0 {
+		if n, err = strconv.Atoi(s); err != nil {
+			return
+		}
+	}
+	op, ns := query.Get("op"), query.Get("ns")
+	filtered = []Entry{}
+	for _, e := range entries {
+		if n >= 0 && len(filtered) >= n {
+			break
+		}
+		if (len(op) != 0 && e.Op != op) || (len(ns) != 0 && e.Namespace != ns) ||
+			time.Duration(e.RepliedUs)*time.Microsecond < minDuration {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return
+}
+
+// ServeEntries writes the entries filtered by the query as a JSON array.
+func ServeEntries(w http.ResponseWriter, r *http.Request, entries []Entry) {
+	filtered, err := Filter(entries, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(filtered)
+}
@@ -0,0 +1,224 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package slowlog keeps the requests replied to later than a threshold, with
+// the timeline of their storage server requests. The entries are appended as
+// JSON lines to slowreq.<worker id>.log in the state log directory, rotated
+// once larger than MaxFileSize, and the last ones of the worker are served on
+// /slowlog.
+package slowlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kWriteQueueLen = 1024 // entries are dropped from the file if the writer falls behind
+)
+
+type (
+	// Call is a storage server request of a slow request.
+	Call struct {
+		Op        string
+		SS        string // zone and address of the storage server
+		SentUs    int64  // since the request was received
+		ElapsedUs int64  `json:",omitempty"` // until the response, the IO error or the timeout
+		Status    string // op status of the response, or IOError, SSTimeout, RequestTimeout, Cancelled or Pending
+		Retry     bool   `json:",omitempty"` // sent after a request of the first phase failed
+		Hedged    bool   `json:",omitempty"` // read sent after the hedge delay
+	}
+
+	// Phase sums up the calls of an opcode, e.g. the prepares.
+	Phase struct {
+		Op        string
+		StartUs   int64
+		EndUs     int64 // when the last response, IO error or timeout happened
+		Sent      int
+		Responded int
+	}
+
+	Entry struct {
+		Time        time.Time // when the request was received
+		Worker      int
+		RequestId   string
+		Op          string
+		Namespace   string
+		Key         string
+		AppName     string `json:",omitempty"`
+		Source      string `json:",omitempty"`
+		Replication bool   `json:",omitempty"`
+		ShardId     uint16
+		Status      string
+		RepliedUs   int64 // since the request was received
+		CompletedUs int64 // after the requests sent once replied, e.g. the repairs
+		Retries     int
+		Repairs     int
+		Hedged      bool `json:",omitempty"`
+		Phases      []Phase
+		Calls       []Call
+	}
+
+	ringT struct {
+		mtx     sync.Mutex
+		entries []*Entry
+		next    int
+	}
+)
+
+var (
+	enabled       bool
+	threshold     time.Duration
+	opThresholds  [proto.OpCodeLastProxyOp]time.Duration
+	nsThresholds  map[string]time.Duration
+	samplePercent float64
+	workerId      int
+	ring          ringT
+	chWrite       chan []byte
+)
+
+// Initialize enables the slow log of the worker if configured. It is to be
+// called before any request is processed.
+func Initialize(conf *config.SlowLogConfig, dir string, wid int) (err error) {
+	if !conf.Enabled {
+		return
+	}
+	threshold = conf.Threshold.Duration
+	for op := range opThresholds {
+		opThresholds[op] = threshold
+	}
+	for name, d := range conf.OpThresholds {
+		opThresholds[config.SlowLogOpCode(name)] = d.Duration
+	}
+	nsThresholds = make(map[string]time.Duration, len(conf.NamespaceThresholds))
+	for ns, d := range conf.NamespaceThresholds {
+		nsThresholds[ns] = d.Duration
+	}
+	samplePercent = conf.SamplePercent
+	workerId = wid
+	ring.entries = make([]*Entry, conf.MaxEntries)
+
+	file := util.NewRotatingFile(dir, fmt.Sprintf("slowreq.%d.", wid), conf.MaxFileSize, conf.MaxFiles)
+	if err = file.Open(); err != nil {
+		glog.Errorf("failed to open slow request log: %s", err.Error())
+		return
+	}
+	chWrite = make(chan []byte, kWriteQueueLen)
+	go func() {
+		for b := range chWrite {
+			file.Write(b)
+		}
+	}()
+	enabled = true
+	return
+}
+
+func Enabled() bool {
+	return enabled
+}
+
+// Threshold returns the response time over which a request is slow.
+func Threshold(op proto.OpCode, ns []byte) time.Duration {
+	if d, ok := nsThresholds[string(ns)]; ok {
+		return d
+	}
+	if op < proto.OpCodeLastProxyOp {
+		return opThresholds[op]
+	}
+	return threshold
+}
+
+// Sampled returns whether a slow request is to be logged.
+func Sampled() bool {
+	return samplePercent >= 100 || rand.Float64()*100 < samplePercent
+}
+
+// AddCall appends the call, and counts it in the phase of its opcode.
+func (e *Entry) AddCall(c Call, responded bool) {
+	e.Calls = append(e.Calls, c)
+	if c.Retry {
+		e.Retries++
+	}
+	if c.Hedged {
+		e.Hedged = true
+	}
+	if c.Op == proto.OpCodeRepair.String() {
+		e.Repairs++
+	}
+	var ph *Phase
+	for i := range e.Phases {
+		if e.Phases[i].Op == c.Op {
+			ph = &e.Phases[i]
+			break
+		}
+	}
+	if ph == nil {
+		e.Phases = append(e.Phases, Phase{Op: c.Op, StartUs: c.SentUs})
+		ph = &e.Phases[len(e.Phases)-1]
+	}
+	ph.Sent++
+	if responded {
+		ph.Responded++
+	}
+	if end := c.SentUs + c.ElapsedUs; c.ElapsedUs != 0 && end > ph.EndUs {
+		ph.EndUs = end
+	}
+}
+
+// Add logs the entry to the file, and keeps it for /slowlog.
+func Add(e *Entry) {
+	if !enabled {
+		return
+	}
+	e.Worker = workerId
+	if b, err := json.Marshal(e); err == nil {
+		select {
+		case chWrite <- append(b, '\n'):
+		default:
+		}
+	}
+	if len(ring.entries) != 0 {
+		ring.mtx.Lock()
+		ring.entries[ring.next] = e
+		ring.next = (ring.next + 1) % len(ring.entries)
+		ring.mtx.Unlock()
+	}
+}
+
+// Entries returns the entries kept, the latest first.
+func Entries() (entries []Entry) {
+	ring.mtx.Lock()
+	defer ring.mtx.Unlock()
+	n := len(ring.entries)
+	for i := 1; i <= n; i++ {
+		if e := ring.entries[(ring.next-i+n)%n]; e != nil {
+			entries = append(entries, *e)
+		}
+	}
+	return
+}
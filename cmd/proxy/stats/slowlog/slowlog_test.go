@@ -0,0 +1,99 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package slowlog
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+func TestSlowLog(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.SlowLogConfig{
+		Enabled:             true,
+		Threshold:           util.Duration{Duration: 50 * time.Millisecond},
+		OpThresholds:        map[string]util.Duration{"Get": {Duration: 10 * time.Millisecond}},
+		NamespaceThresholds: map[string]util.Duration{"batch": {Duration: time.Second}},
+		SamplePercent:       100,
+		MaxEntries:          2,
+		MaxFileSize:         1 << 20,
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Initialize(&conf, dir, 3); err != nil {
+		t.Fatal(err)
+	}
+	if d := Threshold(proto.OpCodeGet, []byte("ns")); d != 10*time.Millisecond {
+		t.Errorf("Get threshold %s", d)
+	}
+	if d := Threshold(proto.OpCodeSet, []byte("ns")); d != 50*time.Millisecond {
+		t.Errorf("Set threshold %s", d)
+	}
+	if d := Threshold(proto.OpCodeGet, []byte("batch")); d != time.Second {
+		t.Errorf("namespace threshold %s", d)
+	}
+
+	now := time.Now()
+	for i, op := range []string{"Get", "Create", "Update"} {
+		e := &Entry{Time: now.Add(time.Duration(i) * time.Second), Op: op, Namespace: "ns", RepliedUs: int64(i+1) * 20000}
+		e.AddCall(Call{Op: "PrepareCreate", SentUs: 10, ElapsedUs: 100, Status: "Ok"}, true)
+		e.AddCall(Call{Op: "PrepareCreate", SentUs: 20, ElapsedUs: 300, Status: "SSTimeout"}, false)
+		e.AddCall(Call{Op: "PrepareCreate", SentUs: 400, ElapsedUs: 100, Status: "Ok", Retry: true}, true)
+		e.AddCall(Call{Op: "Repair", SentUs: 600, ElapsedUs: 50, Status: "Ok"}, true)
+		Add(e)
+	}
+	entries := Entries()
+	if len(entries) != 2 || entries[0].Op != "Update" || entries[1].Op != "Create" || entries[0].Worker != 3 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	e := entries[0]
+	if e.Retries != 1 || e.Repairs != 1 || len(e.Phases) != 2 {
+		t.Errorf("unexpected summary %+v", e)
+	}
+	if ph := e.Phases[0]; ph.Sent != 3 || ph.Responded != 2 || ph.StartUs != 10 || ph.EndUs != 500 {
+		t.Errorf("unexpected phase %+v", ph)
+	}
+
+	filtered, err := Filter(entries, url.Values{"min": {"50ms"}})
+	if err != nil || len(filtered) != 1 || filtered[0].Op != "Update" {
+		t.Errorf("unexpected filtered entries %+v %v", filtered, err)
+	}
+	if _, err = Filter(entries, url.Values{"n": {"x"}}); err == nil {
+		t.Error("invalid n accepted")
+	}
+
+	var b []byte
+	for i := 0; i < 100 && strings.Count(string(b), "\n") < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		b, _ = os.ReadFile(filepath.Join(dir, "slowreq.3.log"))
+	}
+	if strings.Count(string(b), "\n") != 3 {
+		t.Errorf("unexpected log file content %s", b)
+	}
+}
@@ -79,6 +79,12 @@ var (
 
 	statsNumReqProcessed uint64
 
+	statsNumGets        uint64
+	statsNumHedgedReads uint64
+	statsNumHedgeWins   uint64
+	statsHedgeRate      float32
+	statsHedgeWinRate   float32
+
 	listeners []io.IListener
 
 	rusage     *syscall.Rusage
@@ -108,6 +114,10 @@ type (
 		curNumErr int32  // snapshot
 		respTime  int32
 
+		curNumGets        uint64 // snapshot
+		curNumHedgedReads uint64 // snapshot
+		curNumHedgeWins   uint64 // snapshot
+
 		// for calculating moving average processing time
 		emaProctime   [proto.OpCodeLastProxyOp]int32
 		emaWindowSize uint32
@@ -206,6 +216,10 @@ func (l *StateLog) ProcessStateChange(stat stats.ProcStat) {
 		if isErrorResponse[stat.ResponseStatus] {
 			l.cnts[NumErrors].Add(1)
 		}
+		if stat.Opcode == proto.OpCodeGet || stat.Opcode == proto.OpCodeUDFGet ||
+			stat.Opcode == proto.OpCodeExists || stat.Opcode == proto.OpCodeGetMeta {
+			atomic.AddUint64(&statsNumGets, 1)
+		}
 		stats.CollectStatsByAppNamespace(&stat)
 	}
 
@@ -241,6 +255,8 @@ func (l *StateLog) ProcessWrite(cnt int) {
 
 	atomic.StoreUint32(&statsEMA, ema)
 
+	statsHedgeRate, statsHedgeWinRate = l.getHedgeRates()
+
 	// we check SS connectivity 30 seconds, but on lead worker only
 	if cnt%30 == 0 {
 		statsNumOkShards, statsNumBadShards, statsNumWarnShards, statsNumAlertShards = cluster.GetShardMgr().GetSSConnectivityStats()
@@ -271,6 +287,36 @@ func (l *StateLog) getThroughputAndErrorRate() (tps uint32, eps uint32) {
 	return
 }
 
+// getHedgeRates returns the percentage of the reads hedged and the percentage
+// of the hedged reads that won since the last call.
+func (l *StateLog) getHedgeRates() (hedgeRate float32, winRate float32) {
+	numGets := atomic.LoadUint64(&statsNumGets)
+	numHedged := atomic.LoadUint64(&statsNumHedgedReads)
+	numWins := atomic.LoadUint64(&statsNumHedgeWins)
+
+	if gets := numGets - l.curNumGets; gets > 0 {
+		hedgeRate = float32(numHedged-l.curNumHedgedReads) * 100 / float32(gets)
+	}
+	if hedged := numHedged - l.curNumHedgedReads; hedged > 0 {
+		winRate = float32(numWins-l.curNumHedgeWins) * 100 / float32(hedged)
+	}
+	l.curNumGets = numGets
+	l.curNumHedgedReads = numHedged
+	l.curNumHedgeWins = numWins
+	return
+}
+
+// OnHedgedRead counts a read sent to one more storage server.
+func OnHedgedRead() {
+	atomic.AddUint64(&statsNumHedgedReads, 1)
+}
+
+// OnHedgeWon counts a hedged read answered while a read sent before it
+// was still pending.
+func OnHedgeWon() {
+	atomic.AddUint64(&statsNumHedgeWins, 1)
+}
+
 func SetListeners(lsnrs []io.IListener) {
 	listeners = lsnrs
 	var tmp []stats.IState
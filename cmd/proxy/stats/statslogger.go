@@ -111,6 +111,8 @@ func (l *statsLoggerT) Init() {
 						stats.NewUint16State(&st.NumWarnShards, "nWShd", "number of shards with bad SS"),
 						stats.NewFloat32State(&st.ProcCpuUsage, "pCPU", "Process CPU usage percentage", 1),
 						stats.NewFloat32State(&st.MachCpuUsage, "mCPU", "Machine CPU usage percentage", 1),
+						stats.NewFloat32State(&st.HedgeRate, "hdg", "Hedged read percentage", 1),
+						stats.NewFloat32State(&st.HedgeWinRate, "hdgw", "Hedged read win percentage", 1),
 					}...)
 			}
 
@@ -128,7 +130,26 @@ func (l *statsLoggerT) Init() {
 							"replication requests drop count", uint16(10)),
 						stats.NewUint64DeltaState(&repStats.NumErrors, reperr,
 							"replication requests error count", uint16(10)),
+						stats.NewUint32State(&repStats.LagMs, fmt.Sprintf("%s_l", tgtName),
+							"end-to-end replication lag in milliseconds"),
+						stats.NewUint32State(&repStats.NumInFlight, fmt.Sprintf("%s_f", tgtName),
+							"number of replication requests in flight"),
+						stats.NewUint32State(&repStats.AckRate, fmt.Sprintf("%s_t", tgtName),
+							"number of replication requests acknowledged per second"),
+						stats.NewUint16State(&repStats.Alarms, fmt.Sprintf("%s_a", tgtName),
+							"replication alarms raised, 1: lag, 2: queue, 4: no success"),
 					}...)
+				if repTargets[t].Spill != 0 {
+					l.workerStats[i] = append(l.workerStats[i],
+						[]stats.IState{
+							stats.NewUint64State(&repStats.SpillBytes, fmt.Sprintf("%s_sb", tgtName),
+								"replication spill backlog in bytes"),
+							stats.NewUint32State(&repStats.SpillOldestAge, fmt.Sprintf("%s_sa", tgtName),
+								"age of the oldest spilled request in seconds"),
+							stats.NewUint32State(&repStats.SpillReplayRate, fmt.Sprintf("%s_sr", tgtName),
+								"number of spilled requests replayed per second"),
+						}...)
+				}
 			}
 		}
 		cfg := &config.Conf
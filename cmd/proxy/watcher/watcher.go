@@ -31,6 +31,7 @@ import (
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
 	"github.com/paypal/junodb/cmd/proxy/proc"
+	"github.com/paypal/junodb/cmd/proxy/replication"
 	"github.com/paypal/junodb/pkg/cluster"
 	"github.com/paypal/junodb/pkg/etcd"
 	"github.com/paypal/junodb/pkg/util"
@@ -115,6 +116,7 @@ func newWatcher(clustername string, cli *etcd.EtcdClient, cfg *etcd.Config) *Wat
 // Watch for
 // -- the shard map change (version)
 // -- ZoneMarkDown
+// -- replication target bootstrap
 /////////////////////////////////////
 
 // At any given time, we can mark down at most one zone
@@ -130,7 +132,7 @@ func (w *Watcher) Watch() (err error) {
 
 		var retryTimer *util.TimerWrapper
 		var chRetry <-chan time.Time = nil
-		var chMarkDown, chLimitsConfigChange clientv3.WatchChan
+		var chMarkDown, chLimitsConfigChange, chBootstrap clientv3.WatchChan
 
 		if w.etcdcli != nil {
 			val, err := w.etcdcli.GetValue(etcd.TagZoneMarkDown)
@@ -147,6 +149,7 @@ func (w *Watcher) Watch() (err error) {
 			if chLimitsConfigChange, err = w.etcdcli.WatchEvt(etcd.TagLimitsConfig, ctx); err != nil {
 				glog.Errorln(err)
 			}
+			chBootstrap = w.watchBootstrap(ctx)
 		} else {
 			// set a timer to reconnect every 3 minutes
 			retryTimer = util.NewTimerWrapper(time.Duration(3) * time.Minute)
@@ -194,11 +197,20 @@ func (w *Watcher) Watch() (err error) {
 					if chLimitsConfigChange, err = w.etcdcli.WatchEvt(etcd.TagLimitsConfig, ctx); err != nil {
 						glog.Errorln(err)
 					}
+					chBootstrap = w.watchBootstrap(ctx)
 					retryTimer.Stop()
 					chRetry = nil
 				} else {
 					retryTimer.Reset(time.Duration(3) * time.Minute)
 				}
+			case b, ok := <-chBootstrap:
+				if ok {
+					for _, ev := range b.Events {
+						if ev.Type == clientv3.EventTypePut {
+							onBootstrapEvent(ev.Kv.Value)
+						}
+					}
+				}
 			case t, ok := <-chLimitsConfigChange:
 				if ok {
 					l := len(t.Events)
@@ -237,6 +249,37 @@ func (w *Watcher) onMarkDownEvent(ev *clientv3.Event) {
 	}
 }
 
+// watchBootstrap applies the current bootstrap state, if any, and watches for
+// its change.
+func (w *Watcher) watchBootstrap(ctx context.Context) (ch clientv3.WatchChan) {
+	if !replication.Enabled() {
+		return nil
+	}
+	if val, err := w.etcdcli.GetValue(etcd.TagRepBootstrap); err == nil {
+		onBootstrapEvent([]byte(val))
+	}
+	var err error
+	if ch, err = w.etcdcli.WatchEvt(etcd.TagRepBootstrap, ctx); err != nil {
+		glog.Errorln(err)
+	}
+	return
+}
+
+func onBootstrapEvent(value []byte) {
+	glog.Infof("bootstrap evt: value=%s", string(value))
+	spec, err := etcd.ParseRepBootstrapSpec(string(value))
+	if err != nil {
+		glog.Errorf("bad bootstrap spec: %s", err.Error())
+		return
+	}
+	if replication.TheReplicator == nil {
+		return
+	}
+	if err = replication.TheReplicator.SetBootstrap(spec.Target, spec.State == etcd.TagRepBootstrapBegin); err != nil {
+		glog.Warningf("bootstrap: %s", err.Error())
+	}
+}
+
 func (w *Watcher) Stop() {
 	glog.Infof("stop watcher")
 	w.cancel()
@@ -0,0 +1,97 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/storageserv/config"
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/initmgr"
+)
+
+// Restore rebuilds the databases of a storage node from a backup set, taken
+// with the /admin/backup monitoring endpoint. The storage server must be
+// stopped.
+type Restore struct {
+	CmdStorageCommon
+	optBackupDir    string
+	optZoneId       uint
+	optMachineIndex uint
+	optForce        bool
+}
+
+func (c *Restore) Init(name string, desc string) {
+	c.CmdStorageCommon.Init(name, desc)
+	c.StringOption(&c.optBackupDir, "dir|backup-dir", "", "specify the directory of the backup set")
+	c.UintOption(&c.optZoneId, "zone-id", 0, "specify zone id")
+	c.UintOption(&c.optMachineIndex, "machine-index", 0, "specify machine index")
+	c.BoolOption(&c.optForce, "force", false, "remove the existing databases of the node")
+	c.AddExample(name+" -c config.toml -dir /backup/20231001 -zone-id 1 -machine-index 2",
+		"restore the databases of node 1-2 from /backup/20231001/1-2")
+}
+
+func (c *Restore) Parse(args []string) (err error) {
+	if err = c.CmdStorageCommon.Parse(args); err != nil {
+		return
+	}
+	if len(c.optBackupDir) == 0 {
+		err = fmt.Errorf("missing backup dir option")
+	}
+	return
+}
+
+func (c *Restore) Exec() {
+	initmgr.Register(config.Initializer, c.optConfigFile)
+	initmgr.Init()
+
+	cfg := config.ServerConfig()
+	initmgr.RegisterWithFuncs(glog.Initialize, glog.Finalize, cfg.LogLevel, "[restore] ")
+	initmgr.Init()
+
+	if isRunning(cfg.PidFileName) {
+		fmt.Fprintf(os.Stderr, "storage server in %s is running, stop it before restore\n", cfg.PidFileName)
+		os.Exit(-1)
+	}
+	m, err := db.RestoreBackup(c.optBackupDir, int(c.optZoneId), int(c.optMachineIndex), c.optForce)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %s\n", err.Error())
+		os.Exit(-1)
+	}
+	fmt.Printf("restored %d dbs of node %d-%d, %d records, backup taken at %s\n",
+		len(m.Dbs), m.ZoneId, m.NodeId, m.NumRecords, m.StartTime.Format("2006-01-02 15:04:05"))
+}
+
+func isRunning(pidFile string) bool {
+	if data, err := os.ReadFile(pidFile); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			if process, err := os.FindProcess(pid); err == nil {
+				return process.Signal(syscall.Signal(0)) == nil
+			}
+		}
+	}
+	return false
+}
@@ -36,13 +36,16 @@ func init() {
 		cmdManager          Manager
 		cmdWorker           Worker
 		cmdMonitoringWorker MonitoringWorker
+		cmdRestore          Restore
 	)
 	cmdManager.Init("manager", "start as storage server manager")
 	cmdWorker.Init("worker", "start as storage worker")
 	cmdMonitoringWorker.Init("monitor", "start as storage monitoring worker")
+	cmdRestore.Init("restore", "restore the databases of a node from a backup set")
 	cmd.Register(&cmdManager)
 	cmd.Register(&cmdWorker)
 	cmd.Register(&cmdMonitoringWorker)
+	cmd.Register(&cmdRestore)
 }
 
 func Main() {
@@ -27,6 +27,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -44,6 +45,7 @@ import (
 	otel "github.com/paypal/junodb/pkg/logging/otel/config"
 	"github.com/paypal/junodb/pkg/service"
 	"github.com/paypal/junodb/pkg/shard"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/util"
 	"github.com/paypal/junodb/pkg/version"
 )
@@ -55,6 +57,7 @@ type Config struct {
 
 	RootDir     string
 	StateLogDir string
+	BackupDir   string // the backups of /admin/backup are taken under it, disabled if not set
 	PidFileName string
 	LogLevel    string
 	ClusterName string
@@ -83,6 +86,25 @@ type Config struct {
 	ShardMapUpdateDelay util.Duration
 	OTEL                otel.Config
 	DbScan              dbscan.DbScan
+	HotKeys             hotkey.Config
+
+	// Per namespace record history, e.g.
+	//   [History.ns1]
+	//   MaxVersions = 10
+	//   MaxAge = "72h"
+	History map[string]HistoryConfig
+}
+
+// HistoryConfig keeps the versions of the records of a namespace replaced in
+// the last MaxAge, up to MaxVersions of them. 0 means no limit, and the
+// history is disabled if both are 0.
+type HistoryConfig struct {
+	MaxVersions int
+	MaxAge      util.Duration
+}
+
+func (c HistoryConfig) Enabled() bool {
+	return c.MaxVersions > 0 || c.MaxAge.Duration > 0
 }
 
 var serverConfig = Config{
@@ -131,6 +153,7 @@ var serverConfig = Config{
 	ShardMapUpdateDelay: util.Duration{30 * time.Second}, // 30 seconds
 	ReqProcCtxPoolSize:  10000,
 	MaxTimeToLive:       3600 * 24 * 3,
+	HotKeys:             hotkey.DefaultConfig,
 	OTEL: otel.Config{
 		Host:        "127.0.0.1",
 		Port:        4318,
@@ -205,6 +228,9 @@ func (c *Config) validatePathAndFileNames() (err error) {
 	}
 	serverConfig.validatePath(&serverConfig.Etcd.CacheDir)
 	serverConfig.validatePath(&serverConfig.StateLogDir)
+	if len(serverConfig.BackupDir) != 0 {
+		serverConfig.validatePath(&serverConfig.BackupDir)
+	}
 	if len(serverConfig.PidFileName) == 0 {
 		serverConfig.PidFileName = "ss.pid"
 	}
@@ -226,6 +252,29 @@ func (c *Config) validatePath(path *string) {
 	}
 }
 
+// BackupPath returns the directory of a backup set, given relative to
+// BackupDir or as an absolute path under it. A path outside BackupDir is
+// rejected.
+func (c *Config) BackupPath(dir string) (string, error) {
+	if len(c.BackupDir) == 0 {
+		return "", errors.New("backup disabled, BackupDir not configured")
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(dir), "/") {
+		if elem == ".." {
+			return "", fmt.Errorf("'..' not allowed in backup dir %s", dir)
+		}
+	}
+	root := filepath.Clean(c.BackupDir)
+	path := filepath.Clean(dir)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("backup dir %s not under %s", dir, root)
+	}
+	return path, nil
+}
+
 func (c *Config) Validate() (err error) {
 	//	if err = c.Config.Validate(); err != nil {
 	//		return
@@ -239,6 +288,9 @@ func (c *Config) Validate() (err error) {
 		err = fmt.Errorf("Rate limit can't be 0: %d", serverConfig.Redist.SnapshotRateLimit)
 		return
 	}
+	if err = c.HotKeys.Validate(); err != nil {
+		return
+	}
 	err = c.DB.Validate()
 
 	return
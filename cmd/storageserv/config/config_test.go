@@ -0,0 +1,55 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package config
+
+import (
+	"testing"
+)
+
+func TestBackupPath(t *testing.T) {
+	if _, err := (&Config{}).BackupPath("set1"); err == nil {
+		t.Error("expect backup to be disabled without BackupDir")
+	}
+	c := &Config{BackupDir: "/backup/"}
+	for _, tc := range []struct {
+		dir  string
+		path string
+	}{
+		{"20231001", "/backup/20231001"},
+		{"daily/20231001", "/backup/daily/20231001"},
+		{"/backup/20231001", "/backup/20231001"},
+		{"/backup", ""},
+		{".", ""},
+		{"../etc", ""},
+		{"20231001/../../etc", ""},
+		{"/backup/../etc", ""},
+		{"/etc", ""},
+		{"/backup2/20231001", ""},
+	} {
+		path, err := c.BackupPath(tc.dir)
+		if len(tc.path) == 0 {
+			if err == nil {
+				t.Errorf("%s: expect an error, got %s", tc.dir, path)
+			}
+		} else if err != nil || path != tc.path {
+			t.Errorf("%s: expect %s, got %s %v", tc.dir, tc.path, path, err)
+		}
+	}
+}
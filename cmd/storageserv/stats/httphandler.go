@@ -20,15 +20,21 @@
 package stats
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
 	"github.com/paypal/junodb/cmd/proxy/stats/qry"
 	"github.com/paypal/junodb/cmd/storageserv/config"
 	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/shard"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/util"
 	"github.com/paypal/junodb/pkg/version"
 )
 
@@ -107,6 +113,110 @@ func httpDebugDbStatsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// adminBackupHandler takes an online backup of the databases of the worker to
+// the directory given by the "dir" query parameter, under the configured
+// BackupDir, and writes the manifest. It requires a POST.
+func adminBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	dir := r.URL.Query().Get("dir")
+	if len(dir) == 0 {
+		http.Error(w, "dir is required", http.StatusBadRequest)
+		return
+	}
+	dir, err := config.ServerConfig().BackupPath(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	m, err := db.GetDB().Backup(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(m)
+}
+
+// recordVersion is a version of a record listed by /admin/history.
+type recordVersion struct {
+	Version          uint32
+	RequestId        string
+	OriginatorId     string
+	ModificationTime time.Time
+	ExpirationTime   time.Time
+	PayloadLength    uint32
+	MarkedDelete     bool `json:",omitempty"`
+	Current          bool `json:",omitempty"`
+}
+
+// adminHistoryHandler lists the versions of the record given by the "ns" and
+// the "key" or "hexkey" query parameters, the oldest first. It returns 404 if
+// the shard of the record is not on the worker.
+func adminHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ns := query.Get("ns")
+	key := []byte(query.Get("key"))
+	if hexKey := query.Get("hexkey"); len(hexKey) != 0 {
+		var err error
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			http.Error(w, "invalid hexkey", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(ns) == 0 || len(key) == 0 {
+		http.Error(w, "ns and key are required", http.StatusBadRequest)
+		return
+	}
+
+	cfg := config.ServerConfig()
+	shardId, microShardId := util.GetShardIds(key, cfg.ClusterInfo.NumShards, cfg.NumMicroShards)
+	if !db.GetDB().ShardSupported(shard.ID(shardId)) {
+		http.Error(w, fmt.Sprintf("shard %d not on the worker", shardId), http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	id := db.NewRecordIDWithBuffer(&buf, shard.ID(shardId), microShardId, []byte(ns), key)
+
+	recs, err := db.GetDB().GetHistory(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cur := &db.Record{}
+	defer cur.ResetRecord()
+	exist, err := db.GetDB().GetRecord(id, cur)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exist && !cur.IsExpired() {
+		recs = append(recs, cur)
+	}
+
+	versions := make([]recordVersion, 0, len(recs))
+	for _, rec := range recs {
+		versions = append(versions, recordVersion{
+			Version:          rec.Version,
+			RequestId:        rec.RequestId.String(),
+			OriginatorId:     rec.OriginatorRequestId.String(),
+			ModificationTime: time.Unix(0, int64(rec.LastModificationTime)),
+			ExpirationTime:   time.Unix(int64(rec.ExpirationTime), 0),
+			PayloadLength:    rec.Payload.GetLength(),
+			MarkedDelete:     rec.IsMarkedDelete(),
+			Current:          rec == cur,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(versions)
+}
+
 func debugMemStatsHandler(w http.ResponseWriter, r *http.Request) {
 	db.WriteSliceTrackerStats(w)
 }
@@ -21,6 +21,7 @@ package stats
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"io"
@@ -34,6 +35,7 @@ import (
 	"github.com/paypal/junodb/cmd/storageserv/config"
 	"github.com/paypal/junodb/cmd/storageserv/stats/shmstats"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/version"
 )
 
@@ -94,6 +96,13 @@ func (h *HttpHandlerForMonitor) Init(isChild bool, addrs []string) {
 	HttpServerMux.HandleFunc("/stats/json", h.httpJsonStatsHandler)
 	HttpServerMux.HandleFunc("/stats/text", h.httpTextStatsHandler)
 	HttpServerMux.HandleFunc("/version", version.HttpHandler)
+	HttpServerMux.HandleFunc("/admin/backup", h.httpBackupHandler)
+	HttpServerMux.HandleFunc("/admin/history", h.httpOwnerWorkerHandler)
+	HttpServerMux.HandleFunc("/admin/locks", h.httpLocksHandler)
+	HttpServerMux.HandleFunc("/admin/locks/abort", h.httpOwnerWorkerHandler)
+	if config.ServerConfig().HotKeys.Enabled {
+		HttpServerMux.HandleFunc("/hotkeys", h.httpHotKeysHandler)
+	}
 }
 
 func (c *HttpHandlerForMonitor) getFromWorkerWithWorkerId(urlPath string, query url.Values, workerId int) (body []byte, err error) {
@@ -155,6 +164,154 @@ func (c *HttpHandlerForMonitor) httpTextStatsHandler(w http.ResponseWriter, r *h
 	shmstats.PrettyPrint(w, workerId)
 }
 
+// httpBackupHandler runs the backup on the worker given by "wid", or on all
+// the workers one after another, and writes their manifests as a JSON array.
+// It requires a POST.
+func (c *HttpHandlerForMonitor) httpBackupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	if len(query.Get("dir")) == 0 {
+		http.Error(w, "dir is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := config.ServerConfig().BackupPath(query.Get("dir")); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	workers := make([]int, 0, c.GetNumWorkers())
+	if wid := query.Get("wid"); wid != "" {
+		id, err := strconv.Atoi(wid)
+		if err != nil || id < 0 || id >= c.GetNumWorkers() {
+			http.Error(w, fmt.Sprintf("invalid wid %s", wid), http.StatusBadRequest)
+			return
+		}
+		query.Del("wid")
+		workers = append(workers, id)
+	} else {
+		for i := 0; i < c.GetNumWorkers(); i++ {
+			workers = append(workers, i)
+		}
+	}
+
+	var buf bytes.Buffer
+	status := http.StatusOK
+	buf.WriteByte('[')
+	for i, id := range workers {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		resp, err := http.Post(c.GetWorkerUrl(id)+r.URL.Path+"?"+query.Encode(), "", nil)
+		var body []byte
+		if err == nil {
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil && resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("%s", bytes.TrimSpace(body))
+			}
+		}
+		if err != nil {
+			glog.Errorf("backup on worker %d failed: %s", id, err.Error())
+			status = http.StatusInternalServerError
+			fmt.Fprintf(&buf, `{"Worker":%d,"Error":%q}`, id, err.Error())
+		} else {
+			buf.Write(bytes.TrimSpace(body))
+		}
+	}
+	buf.WriteString("]\n")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// httpOwnerWorkerHandler forwards a request about a record to the workers in
+// turn, and writes the reply of the one owning its shard, i.e. the first one
+// not replying 404.
+func (c *HttpHandlerForMonitor) httpOwnerWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	var err error
+	status := http.StatusNotFound
+	contentType := ""
+	for id := 0; id < c.GetNumWorkers() && status == http.StatusNotFound; id++ {
+		var req *http.Request
+		var resp *http.Response
+		if req, err = http.NewRequest(r.Method, c.GetWorkerUrl(id)+r.URL.Path+"?"+r.URL.RawQuery, nil); err == nil {
+			resp, err = http.DefaultClient.Do(req)
+		}
+		if err != nil {
+			glog.Errorf("%s on worker %d failed: %s", r.URL.Path, id, err.Error())
+			status = http.StatusInternalServerError
+			body = []byte(err.Error())
+			break
+		}
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		status = resp.StatusCode
+		contentType = resp.Header.Get("Content-Type")
+	}
+	if len(contentType) != 0 {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// httpLocksHandler writes the locked records of all the workers as a JSON
+// array.
+func (c *HttpHandlerForMonitor) httpLocksHandler(w http.ResponseWriter, r *http.Request) {
+	entries := []json.RawMessage{}
+	for id := 0; id < c.GetNumWorkers(); id++ {
+		body, err := c.getFromWorkerWithWorkerId(r.URL.Path, r.URL.Query(), id)
+		var workerEntries []json.RawMessage
+		if err == nil {
+			err = json.Unmarshal(body, &workerEntries)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("worker %d: %s", id, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		entries = append(entries, workerEntries...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(entries)
+}
+
+// httpHotKeysHandler merges the hot keys of all the workers, or of the worker
+// given by "wid".
+func (c *HttpHandlerForMonitor) httpHotKeysHandler(w http.ResponseWriter, r *http.Request) {
+	workers := make([]int, 0, c.GetNumWorkers())
+	if wid := r.URL.Query().Get("wid"); wid != "" {
+		id, err := strconv.Atoi(wid)
+		if err != nil || id < 0 || id >= c.GetNumWorkers() {
+			http.Error(w, fmt.Sprintf("invalid wid %s", wid), http.StatusBadRequest)
+			return
+		}
+		workers = append(workers, id)
+	} else {
+		for i := 0; i < c.GetNumWorkers(); i++ {
+			workers = append(workers, i)
+		}
+	}
+	reports := make([]*hotkey.Report, 0, len(workers))
+	for _, id := range workers {
+		body, err := c.getFromWorkerWithWorkerId(r.URL.Path, url.Values{}, id)
+		rep := &hotkey.Report{}
+		if err == nil {
+			err = json.Unmarshal(body, rep)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("worker %d: %s", id, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, rep)
+	}
+	hotkey.ServeReport(w, r, hotkey.Merge(reports, config.ServerConfig().HotKeys.TopK))
+}
+
 func (c *HttpHandlerForMonitor) httpHandler(w http.ResponseWriter, r *http.Request) {
 	values := r.URL.Query()
 	if values.Get("wid") != "" {
@@ -60,6 +60,10 @@ type (
 		NumMarkDeletes uint64
 		ProcCpuUsage   float32
 		MachCpuUsage   float32
+
+		NumLockConflicts uint64 // requests rejected as the record is locked
+		NumLockTimeouts  uint64 // prepared records not committed or aborted in time
+		AvgLockWaitTime  uint32 // in us, from prepare to commit or abort
 	}
 	StorageStats struct {
 		Free                uint64 // in Megabytes
@@ -70,6 +74,7 @@ type (
 		CompCountByInterval uint32
 		PendingCompKBytes   uint64
 		DelayedWriteRate    uint64
+		NumCorrupted        uint64 // records failing the checksum verification
 	}
 	WorkerStats struct {
 		Pid              uint32
@@ -159,8 +164,12 @@ func (s *workerStatsManagerT) PrettyPrint(w io.Writer) {
 		fmt.Fprintf(w, "\tNumAborts\t: %d\n", st.NumAborts)
 		fmt.Fprintf(w, "\tNumRepairs\t: %d\n", st.NumRepairs)
 		fmt.Fprintf(w, "\tNumMarkDeletes\t: %d\n", st.NumMarkDeletes)
+		fmt.Fprintf(w, "\tNumLockConflicts\t: %d\n", st.NumLockConflicts)
+		fmt.Fprintf(w, "\tNumLockTimeouts\t: %d\n", st.NumLockTimeouts)
+		fmt.Fprintf(w, "\tAvgLockWaitTime\t: %d\n", st.AvgLockWaitTime)
 		fmt.Fprintf(w, "\tStorageFree\t: %d\n", st.Free)
 		fmt.Fprintf(w, "\tStorageUsed\t: %d\n", st.Used)
+		fmt.Fprintf(w, "\tNumCorrupted\t: %d\n", st.NumCorrupted)
 		fmt.Fprintf(w, "\tNumConnections\t: %d\n", st.NumConnections)
 		fmt.Fprintf(w, "\tMaxNumConnections\t: %d\n", st.MaxNumConntions)
 	}
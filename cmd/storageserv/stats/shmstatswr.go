@@ -26,6 +26,7 @@ import (
 	"unsafe"
 
 	"github.com/paypal/junodb/cmd/storageserv/stats/shmstats"
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
 	"github.com/paypal/junodb/pkg/stats"
 )
 
@@ -50,6 +51,9 @@ func (w *stateLogShmWriter) Write(now time.Time) error {
 			NumAborts:         atomic.LoadUint64(&statsNumRequestsByType[kAbort]),
 			NumRepairs:        atomic.LoadUint64(&statsNumRequestsByType[kRepair]),
 			NumMarkDeletes:    atomic.LoadUint64(&statsNumRequestsByType[kMarkDelete]),
+			NumLockConflicts:  atomic.LoadUint64(&statsNumLockConflicts),
+			NumLockTimeouts:   atomic.LoadUint64(&statsNumLockTimeouts),
+			AvgLockWaitTime:   atomic.LoadUint32(&statsLockWaitEMA),
 			ProcCpuUsage:      math.Float32frombits(atomic.LoadUint32(((*uint32)(unsafe.Pointer(&statsProcCpuUsage))))),
 			MachCpuUsage:      math.Float32frombits(atomic.LoadUint32(((*uint32)(unsafe.Pointer(&statsMachCpuUsage))))),
 		})
@@ -62,6 +66,7 @@ func (w *stateLogShmWriter) Write(now time.Time) error {
 			CompCountByInterval: atomic.LoadUint32(&statsCompCountByInterval),
 			PendingCompKBytes:   atomic.LoadUint64(&statsPendingCompKBytes),
 			DelayedWriteRate:    atomic.LoadUint64(&statsDelayedWriteRate),
+			NumCorrupted:        db.NumCorruptedRecords(),
 		})
 	}
 
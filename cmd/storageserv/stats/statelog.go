@@ -67,6 +67,10 @@ var (
 	statsPendingCompKBytes   uint64
 	statsDelayedWriteRate    uint64
 
+	statsNumLockConflicts uint64
+	statsNumLockTimeouts  uint64
+	statsLockWaitEMA      uint32 // in us
+
 	theDbPaths  []string
 	rusage      *syscall.Rusage
 	rusageTime  time.Time
@@ -204,6 +208,9 @@ var ( //Variables only being set in initialization
 	emaWindowSize           uint32                   = 39 // multipler = 2/(39+1) => 0.2
 	statsMapNumRequestTypes map[proto.OpCode]*uint64 = map[proto.OpCode]*uint64{
 		proto.OpCodeRead:       &statsNumRequestsByType[kRead],
+		proto.OpCodeReadExists: &statsNumRequestsByType[kRead],
+		proto.OpCodeReadMeta:   &statsNumRequestsByType[kRead],
+		proto.OpCodeSetTTL:     &statsNumRequestsByType[kRead],
 		proto.OpCodeDelete:     &statsNumRequestsByType[kDelete],
 		proto.OpCodeCommit:     &statsNumRequestsByType[kCommit],
 		proto.OpCodeAbort:      &statsNumRequestsByType[kAbort],
@@ -262,6 +269,22 @@ func (l *StateLog) ProcessStateChange(stat stats.ProcStat) {
 	atomic.StoreUint32(&statsReqProcEMA, uint32(curEMA))
 }
 
+// OnLockConflict counts a request rejected as the record is locked.
+func OnLockConflict() {
+	atomic.AddUint64(&statsNumLockConflicts, 1)
+}
+
+// OnPreparedLockReleased records how long a prepared record stayed locked
+// waiting for the commit or the abort, and counts the ones timed out.
+func OnPreparedLockReleased(wait time.Duration, timedOut bool) {
+	if timedOut {
+		atomic.AddUint64(&statsNumLockTimeouts, 1)
+	}
+	prevEMA := int32(atomic.LoadUint32(&statsLockWaitEMA))
+	curEMA := (int32(wait/time.Microsecond)-prevEMA)*2.0/(int32(emaWindowSize)+1) + prevEMA
+	atomic.StoreUint32(&statsLockWaitEMA, uint32(curEMA))
+}
+
 func getCompSecCount() (uint32, uint64, uint64) {
 
 	w := new(bytes.Buffer)
@@ -27,6 +27,7 @@ import (
 	"github.com/paypal/junodb/cmd/storageserv/config"
 	"github.com/paypal/junodb/cmd/storageserv/stats/shmstats"
 	"github.com/paypal/junodb/pkg/debug"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 )
 
 func InitForManager(numChildren int) (err error) {
@@ -57,10 +58,17 @@ func initForWorker(isChild bool, workerId int, zoneId uint32, machineIndex uint3
 
 	addPage("/debug/dbstats/", httpDebugDbStatsHandler)
 	addPage("/debug/config", debugConfigHandler)
+	HttpServerMux.HandleFunc("/admin/backup", adminBackupHandler)
+	HttpServerMux.HandleFunc("/admin/history", adminHistoryHandler)
 
 	if debug.DEBUG {
 		addPage("/debug/memstats", debugMemStatsHandler)
 	}
+
+	hotkey.Initialize(&cfg.HotKeys)
+	if hotkey.Enabled() {
+		addPage("/hotkeys", hotkey.HttpHandler)
+	}
 	return
 }
 
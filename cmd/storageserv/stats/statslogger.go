@@ -108,12 +108,16 @@ func (l *statsLoggerT) Init() {
 						stats.NewUint64State(&st.NumCommits, "C", "Number of Commit"),
 						stats.NewUint64State(&st.NumAborts, "A", "Number of Abort"),
 						stats.NewUint64State(&st.NumRepairs, "RR", "Number of Repair"),
+						stats.NewUint64State(&st.NumLockConflicts, "lkc", "Number of Lock Conflicts"),
+						stats.NewUint64State(&st.NumLockTimeouts, "lkto", "Number of Prepared Lock Timeouts"),
+						stats.NewUint32State(&st.AvgLockWaitTime, "alw", "Average Prepared Lock Wait time(us)"),
 						stats.NewUint64State(&st.StorageStats.NumKeys, "keys", "Number of Keys"),
 						stats.NewUint32State(&st.StorageStats.MaxDBLevel, "LN", "Max LN Level in Rocksdb"),
 						stats.NewUint32State(&st.StorageStats.CompSecByInterval, "compSec", "Compaction Sec"),
 						stats.NewUint32State(&st.StorageStats.CompCountByInterval, "compCount", "Compaction Count"),
 						stats.NewUint64State(&st.StorageStats.PendingCompKBytes, "pCompKB", "Pending Compaction KBytes"),
 						stats.NewUint64State(&st.StorageStats.DelayedWriteRate, "stall", "Actural Delayed Write Rate"),
+						stats.NewUint64State(&st.StorageStats.NumCorrupted, "crpt", "Number of Corrupted Records"),
 						stats.NewFloat32State(&st.ProcCpuUsage, "pCPU", "Process CPU usage percentage", 1),
 						stats.NewFloat32State(&st.MachCpuUsage, "mCPU", "Machine CPU usage percentage", 1),
 					}...)
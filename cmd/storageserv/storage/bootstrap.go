@@ -0,0 +1,244 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/storageserv/redist"
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/etcd"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/shard"
+	redistst "github.com/paypal/junodb/pkg/stats/redist"
+)
+
+var (
+	theBootstrap  *repBootstrap
+	bootstrapLock sync.Mutex
+
+	errBootstrapStopped = errors.New("bootstrap stopped")
+)
+
+// repBootstrap streams a consistent snapshot of the shards on this node to a
+// new replication target, as replicated set requests sent to its proxy. It
+// reuses the redist replicator for flow control and the snapshot stats.
+type repBootstrap struct {
+	spec      etcd.RepBootstrapSpec
+	zoneid    uint16
+	nodeid    uint16
+	etcdcli   *etcd.EtcdClient
+	processor *io.OutboundProcessor
+	rb        *redist.Replicator
+	nsMap     map[string]bool
+	stop      int32
+	wg        sync.WaitGroup
+}
+
+func (r *SSRedistWatchHandler) RepBootstrapStart(spec *etcd.RepBootstrapSpec) {
+	bootstrapLock.Lock()
+	defer bootstrapLock.Unlock()
+
+	if theBootstrap != nil {
+		glog.Warningf("bootstrap of target %s is in progress, ignore new request", theBootstrap.spec.Target)
+		return
+	}
+	key := etcd.KeyRepBootstrapState(int(r.zoneid), int(r.nodeid))
+	if r.etcdcli != nil {
+		if value, err := r.etcdcli.GetValue(key); err == nil {
+			st := redistst.NewKVPairs(value)
+			if st.GetValue(etcd.TagRepBootstrapTarget, "") == spec.Target &&
+				st.GetValue(string(redistst.StatsTagStatus), "") == redistst.StatsFinish {
+				glog.Infof("snapshot already sent to bootstrap target %s", spec.Target)
+				return
+			}
+		}
+	}
+
+	b := &repBootstrap{
+		spec:    *spec,
+		zoneid:  r.zoneid,
+		nodeid:  r.nodeid,
+		etcdcli: r.etcdcli,
+	}
+	if len(spec.Namespaces) != 0 {
+		b.nsMap = make(map[string]bool, len(spec.Namespaces))
+		for _, ns := range spec.Namespaces {
+			b.nsMap[ns] = true
+		}
+	}
+	b.processor = io.NewOutbProcessor(io.ServiceEndpoint{Addr: spec.Addr, SSLEnabled: spec.SSLEnabled},
+		&redist.RedistConfig.Outbound, false)
+	b.rb = redist.NewBalancer(0, b.processor, nil, key, spec.RateLimit, r.etcdcli)
+
+	glog.Infof("start bootstrapping target %s (%s), namespaces: %v", spec.Target, spec.Addr, spec.Namespaces)
+	theBootstrap = b
+	b.wg.Add(1)
+	go b.run()
+}
+
+func (r *SSRedistWatchHandler) RepBootstrapStop(target string) {
+	bootstrapLock.Lock()
+	b := theBootstrap
+	theBootstrap = nil
+	bootstrapLock.Unlock()
+
+	if b == nil {
+		return
+	}
+	if b.spec.Target != target {
+		glog.Warningf("bootstrap target %s is not in progress", target)
+	}
+	atomic.StoreInt32(&b.stop, 1)
+	b.wg.Wait()
+	b.processor.Shutdown()
+}
+
+func (b *repBootstrap) isStopped() bool {
+	return atomic.LoadInt32(&b.stop) != 0
+}
+
+func (b *repBootstrap) run() {
+	defer b.wg.Done()
+
+	start := time.Now()
+	stats := b.rb.GetSnapshotStats()
+	stats.SetStatus(redistst.StatsInProgress)
+
+	shards := db.GetDB().GetShards().Keys()
+	rlconfig := redist.RedistConfig.SnapshotRateLimit
+	if b.spec.RateLimit > 0 {
+		rlconfig = int64(b.spec.RateLimit)
+	}
+	ratelimit := redist.NewRateLimiter(rlconfig*1000, 200)
+
+	var err error
+	for i, shardId := range shards {
+		if err = b.sendShard(shard.ID(shardId), ratelimit); err == nil {
+			err = b.waitForAcks()
+		}
+		if err == nil && stats.ShouldAbort(redist.RedistConfig.DropThreshold, redist.RedistConfig.ErrThreshold) {
+			err = errors.New("too many errors")
+		}
+		if err != nil {
+			break
+		}
+		b.logStats(start, i+1, len(shards))
+	}
+
+	if err != nil {
+		glog.Errorf("abort bootstrapping target %s: %s", b.spec.Target, err.Error())
+		stats.SetStatus(redistst.StatsAbort)
+	} else {
+		stats.SetStatus(redistst.StatsFinish)
+	}
+	b.logStats(start, len(shards), len(shards))
+}
+
+func (b *repBootstrap) sendShard(shardId shard.ID, ratelimit *redist.RateLimiter) (err error) {
+	var raw proto.RawMessage
+
+	db.GetDB().ForEachInSnapshot(shardId, func(ns []byte, key []byte, rec *db.Record) bool {
+		if b.isStopped() {
+			err = errBootstrapStopped
+			return false
+		}
+		if rec.IsMarkedDelete() {
+			return true
+		}
+		if b.nsMap != nil && !b.nsMap[string(ns)] {
+			return true
+		}
+		if err = encodeBootstrapMsg(ns, key, rec, &raw); err != nil {
+			return false
+		}
+		ratelimit.GetToken(int64(len(key)+len(ns)) + int64(rec.Payload.GetLength()))
+		err = b.send(&raw)
+		raw.ReleaseBuffer()
+		return err == nil
+	})
+	return
+}
+
+// the same retry as the one for redistribution
+func (b *repBootstrap) send(msg *proto.RawMessage) error {
+	maxtry := redist.RedistConfig.MaxWaitTime * 1000 / 20
+
+	for i := 0; i < maxtry; i++ {
+		if b.rb.SendRequest(msg, false, false) == nil {
+			return nil
+		}
+		if b.isStopped() {
+			return errBootstrapStopped
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return b.rb.SendRequest(msg, false, true)
+}
+
+func (b *repBootstrap) waitForAcks() error {
+	maxwait := time.Now().Add(time.Duration(redist.RedistConfig.MaxWaitTime) * time.Second)
+	for !b.rb.IsSnapShotDone() {
+		if b.isStopped() {
+			return errBootstrapStopped
+		}
+		if time.Now().After(maxwait) {
+			return errors.New("timed out waiting for the responses")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+func (b *repBootstrap) logStats(start time.Time, done int, total int) {
+	value := fmt.Sprintf("%s=%s&shards=%d/%d&%s", etcd.TagRepBootstrapTarget, b.spec.Target,
+		done, total, b.rb.GetSnapshotStats().GetStatsStr(start))
+	glog.Infof("bootstrap: %s", value)
+	if b.etcdcli != nil && !b.isStopped() {
+		b.etcdcli.PutValue(etcd.KeyRepBootstrapState(int(b.zoneid), int(b.nodeid)), value, 5, 5)
+	}
+}
+
+func encodeBootstrapMsg(ns []byte, key []byte, rec *db.Record, raw *proto.RawMessage) error {
+	msg := &proto.OperationalMessage{}
+	var ttl uint32
+	if now := uint32(time.Now().Unix()); rec.ExpirationTime > now {
+		ttl = rec.ExpirationTime - now
+	}
+	msg.SetRequest(proto.OpCodeSet, key, ns, &rec.Payload, ttl)
+	msg.SetAsReplication()
+	msg.SetRequestID(rec.RequestId)
+	msg.SetCreationTime(rec.CreationTime)
+	msg.SetLastModificationTime(rec.LastModificationTime)
+	msg.SetVersion(rec.Version)
+	msg.SetExpirationTime(rec.ExpirationTime)
+	msg.SetOriginatorRequestID(rec.OriginatorRequestId)
+	if rec.OriginClusterId != 0 {
+		msg.SetOriginClusterId(uint32(rec.OriginClusterId))
+	}
+	return msg.Encode(raw)
+}
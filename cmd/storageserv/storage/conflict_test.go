@@ -0,0 +1,163 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// newPeerRequest returns a replicated request from the cluster originId in
+// active-active mode, or from an active-passive source if originId is 0.
+func newPeerRequest(op proto.OpCode, lmt uint64, version uint32, originId uint32) *proto.OperationalMessage {
+	req := &proto.OperationalMessage{}
+	payload := &proto.Payload{}
+	payload.SetWithClearValue(testValue)
+	req.SetRequest(op, testKey, testNamespace, payload, testDefaultTTL)
+	req.SetAsReplication()
+	req.SetLastModificationTime(lmt)
+	req.SetVersion(version)
+	if originId != 0 {
+		req.SetOriginClusterId(originId)
+	}
+	return req
+}
+
+func TestIsConflictLastWriterWins(t *testing.T) {
+	rec := &db.Record{RecordHeader: db.RecordHeader{Version: 3, LastModificationTime: 1000, OriginClusterId: 2}}
+	for _, tc := range []struct {
+		name     string
+		lmt      uint64
+		version  uint32
+		originId uint32
+		conflict bool
+	}{
+		{"older", 999, 5, 3, true},
+		{"newer", 1001, 1, 1, false},
+		{"same time, not active-active", 1000, 1, 0, false},
+		{"same time, lower version", 1000, 2, 3, true},
+		{"same time, higher version", 1000, 4, 1, false},
+		{"same time and version, lower cluster id", 1000, 3, 1, true},
+		{"same time and version, higher cluster id", 1000, 3, 3, false},
+	} {
+		req := newPeerRequest(proto.OpCodeSet, tc.lmt, tc.version, tc.originId)
+		if c := isConflict(req, rec); c != tc.conflict {
+			t.Errorf("%s: expect conflict %t, got %t", tc.name, tc.conflict, c)
+		}
+	}
+}
+
+// Two concurrent writes in two clusters, each replicated to the other, must
+// leave the same winner on both sides.
+func TestIsConflictSameWinner(t *testing.T) {
+	type write struct {
+		lmt      uint64
+		version  uint32
+		originId uint32
+	}
+	for _, tc := range []struct {
+		a, b write
+	}{
+		{write{1000, 3, 1}, write{1001, 3, 2}},
+		{write{1000, 3, 1}, write{1000, 4, 2}},
+		{write{1000, 3, 1}, write{1000, 3, 2}},
+		{write{1000, 3, 2}, write{1000, 3, 1}},
+	} {
+		recA := &db.Record{RecordHeader: db.RecordHeader{Version: tc.a.version, LastModificationTime: tc.a.lmt, OriginClusterId: uint16(tc.a.originId)}}
+		recB := &db.Record{RecordHeader: db.RecordHeader{Version: tc.b.version, LastModificationTime: tc.b.lmt, OriginClusterId: uint16(tc.b.originId)}}
+		// a applied where b is stored, and b applied where a is stored
+		aRejected := isConflict(newPeerRequest(proto.OpCodeSet, tc.a.lmt, tc.a.version, tc.a.originId), recB)
+		bRejected := isConflict(newPeerRequest(proto.OpCodeSet, tc.b.lmt, tc.b.version, tc.b.originId), recA)
+		if aRejected == bRejected {
+			t.Errorf("%+v vs %+v: a rejected %t, b rejected %t", tc.a, tc.b, aRejected, bRejected)
+		}
+	}
+}
+
+func TestIsFromActivePeer(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		replication bool
+		originId    uint32
+		expected    bool
+	}{
+		{"client", false, 0, false},
+		{"active-passive replication", true, 0, false},
+		{"active-active replication", true, 2, true},
+		{"cluster id without replication", false, 2, false},
+	} {
+		req := &proto.OperationalMessage{}
+		req.SetRequest(proto.OpCodeSet, testKey, testNamespace, &proto.Payload{}, testDefaultTTL)
+		if tc.replication {
+			req.SetAsReplication()
+		}
+		if tc.originId != 0 {
+			req.SetOriginClusterId(tc.originId)
+		}
+		if v := isFromActivePeer(req); v != tc.expected {
+			t.Errorf("%s: expect %t, got %t", tc.name, tc.expected, v)
+		}
+	}
+}
+
+func TestTombstoneOrdering(t *testing.T) {
+	const deleteTime = uint64(2000)
+	for _, tc := range []struct {
+		name      string
+		updateLmt uint64
+		originId  uint32
+		conflict  bool
+	}{
+		{"update before the delete", deleteTime - 1, 1, true},
+		{"update after the delete", deleteTime + 1, 1, false},
+		{"update at the same time from a lower cluster id", deleteTime, 1, true},
+		{"update at the same time from a higher cluster id", deleteTime, 3, false},
+	} {
+		// the tombstone left by a delete from cluster 2
+		del := newPeerRequest(proto.OpCodeDestroy, deleteTime, 3, 2)
+		lmt := tombstoneModificationTime(del)
+		if lmt != deleteTime {
+			t.Fatalf("expect the tombstone at the time of the delete %d, got %d", deleteTime, lmt)
+		}
+		tombstone := &db.Record{RecordHeader: db.RecordHeader{Version: 3, LastModificationTime: lmt, OriginClusterId: 2}}
+		tombstone.MarkDelete()
+
+		update := newPeerRequest(proto.OpCodeUpdate, tc.updateLmt, 3, tc.originId)
+		if c := isConflict(update, tombstone); c != tc.conflict {
+			t.Errorf("%s: expect conflict %t, got %t", tc.name, tc.conflict, c)
+		}
+	}
+
+	// without the modification time of active-active, the tombstone is at the
+	// time the delete is applied
+	for _, del := range []*proto.OperationalMessage{
+		newPeerRequest(proto.OpCodeDestroy, deleteTime, 3, 0),
+		newPeerRequest(proto.OpCodeDestroy, 0, 3, 2),
+	} {
+		before := uint64(time.Now().UnixNano())
+		lmt := tombstoneModificationTime(del)
+		if lmt < before || lmt > uint64(time.Now().UnixNano()) {
+			t.Errorf("expect the current time, got %d", lmt)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+	"github.com/paypal/junodb/third_party/forked/tecbot/gorocksdb"
+
+	"github.com/paypal/junodb/pkg/shard"
+)
+
+const (
+	kBackupManifestFile = "manifest.json"
+
+	BackupShardingByPrefix   = "prefix"
+	BackupShardingByInstance = "instance"
+)
+
+var (
+	backupInProgress int32
+)
+
+type (
+	// BackupManifest describes the backup of the databases of a storage node.
+	// A backup set has one directory per node, named <zone>-<node>, holding a
+	// RocksDB checkpoint per database and the manifest.
+	BackupManifest struct {
+		ZoneId     int
+		NodeId     int
+		Sharding   string // "prefix" or "instance"
+		NumShards  int
+		Shards     []shard.ID // the shards owned by the node at the time of the backup
+		NumRecords uint64
+		StartTime  time.Time
+		EndTime    time.Time
+		Dbs        []BackupDbInfo
+	}
+
+	// BackupDbInfo describes the checkpoint of a database instance.
+	BackupDbInfo struct {
+		Name       string // the directory name of the database, e.g. 0-1-3.db
+		Index      int    // the index of the database, or the shard id if sharded by instance
+		NumRecords uint64
+		Shards     []BackupShardInfo
+	}
+
+	BackupShardInfo struct {
+		Id         shard.ID
+		NumRecords uint64
+	}
+)
+
+// Backup takes a RocksDB checkpoint of each database while serving traffic,
+// counts the records per shard in the checkpoints, and writes the manifest.
+// The backup of the node is written to dir/<zone>-<node>, which must not exist.
+func (r *RocksDB) Backup(dir string) (m *BackupManifest, err error) {
+	if !atomic.CompareAndSwapInt32(&backupInProgress, 0, 1) {
+		return nil, errors.New("a backup is in progress")
+	}
+	defer atomic.StoreInt32(&backupInProgress, 0)
+
+	nodeDir := filepath.Join(dir, fmt.Sprintf("%d-%d", r.zoneId, r.nodeId))
+	if _, err = os.Stat(nodeDir); err == nil {
+		return nil, fmt.Errorf("%s already exists", nodeDir)
+	}
+	if err = os.MkdirAll(nodeDir, 0777); err != nil {
+		return nil, err
+	}
+
+	m = &BackupManifest{
+		ZoneId:    r.zoneId,
+		NodeId:    r.nodeId,
+		NumShards: r.numShards,
+		Shards:    r.shards.Keys(),
+		StartTime: time.Now(),
+	}
+	sort.Slice(m.Shards, func(i, j int) bool { return m.Shards[i] < m.Shards[j] })
+	if _, ok := r.sharding.(*ShardingByPrefix); ok {
+		m.Sharding = BackupShardingByPrefix
+	} else {
+		m.Sharding = BackupShardingByInstance
+	}
+	glog.Infof("backup to %s started", nodeDir)
+
+	if m.Dbs, err = r.sharding.checkpoint(nodeDir); err != nil {
+		glog.Errorf("backup to %s failed: %s", nodeDir, err.Error())
+		os.RemoveAll(nodeDir)
+		return nil, err
+	}
+	for _, d := range m.Dbs {
+		m.NumRecords += d.NumRecords
+	}
+	m.EndTime = time.Now()
+	if err = writeBackupManifest(nodeDir, m); err != nil {
+		os.RemoveAll(nodeDir)
+		return nil, err
+	}
+	glog.Infof("backup to %s done: %d dbs, %d records, took %s", nodeDir, len(m.Dbs), m.NumRecords, m.EndTime.Sub(m.StartTime))
+	return m, nil
+}
+
+// checkpointDb creates a checkpoint of db in dir, and returns the number of
+// records per shard in it. shardOf returns the shard of a storage key, and
+// nsOffset is the offset of the namespace length in the storage keys. The
+// history entries, with a namespace length of 0, are not counted.
+func checkpointDb(db *gorocksdb.DB, name string, index int, dir string, nsOffset int,
+	shardOf func(key []byte) shard.ID) (info BackupDbInfo, err error) {
+	info = BackupDbInfo{Name: name, Index: index}
+	path := filepath.Join(dir, name)
+
+	var cp *gorocksdb.Checkpoint
+	if cp, err = db.NewCheckpoint(); err != nil {
+		return
+	}
+	// flush the memtables, so the checkpoint does not depend on the WAL
+	err = cp.CreateCheckpoint(path, 0)
+	cp.Destroy()
+	if err != nil {
+		err = fmt.Errorf("checkpoint %s: %s", name, err.Error())
+		return
+	}
+
+	// count the records in the checkpoint, so the counts match the backup
+	opts := NewRocksDBptions()
+	defer opts.Destroy()
+	var cpDb *gorocksdb.DB
+	if cpDb, err = gorocksdb.OpenDbForReadOnly(opts, path, false); err != nil {
+		err = fmt.Errorf("open checkpoint %s: %s", name, err.Error())
+		return
+	}
+	defer cpDb.Close()
+
+	ropts := gorocksdb.NewDefaultReadOptions()
+	defer ropts.Destroy()
+	ropts.SetFillCache(false)
+	iter := cpDb.NewIterator(ropts)
+	defer iter.Close()
+
+	counts := make(map[shard.ID]uint64)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		k := iter.Key()
+		if data := k.Data(); len(data) > nsOffset && data[nsOffset] != 0 {
+			counts[shardOf(data)]++
+		}
+		k.Free()
+	}
+	if err = iter.Err(); err != nil {
+		return
+	}
+	for id, n := range counts {
+		info.Shards = append(info.Shards, BackupShardInfo{Id: id, NumRecords: n})
+		info.NumRecords += n
+	}
+	sort.Slice(info.Shards, func(i, j int) bool { return info.Shards[i].Id < info.Shards[j].Id })
+	return
+}
+
+func writeBackupManifest(dir string, m *BackupManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, kBackupManifestFile+".tmp")
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, kBackupManifestFile))
+}
+
+// ReadBackupManifest reads the manifest of the node backup in dir.
+func ReadBackupManifest(dir string) (m *BackupManifest, err error) {
+	var data []byte
+	if data, err = os.ReadFile(filepath.Join(dir, kBackupManifestFile)); err != nil {
+		return
+	}
+	m = &BackupManifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		m = nil
+	}
+	return
+}
+
+// RestoreBackup rebuilds the databases of the node zoneId-nodeId from the
+// backup set in dir. It must be run with the storage server stopped. The
+// databases are restored to the first of the DbPaths only, as the table files
+// of a checkpoint are all in its directory, and RocksDB moves them to the
+// other paths by their target size as it compacts. The existing databases of
+// the node are removed from all the paths if force is true.
+func RestoreBackup(dir string, zoneId int, nodeId int, force bool) (m *BackupManifest, err error) {
+	nodeDir := filepath.Join(dir, fmt.Sprintf("%d-%d", zoneId, nodeId))
+	if m, err = ReadBackupManifest(nodeDir); err != nil {
+		return nil, fmt.Errorf("no backup of node %d-%d in %s: %s", zoneId, nodeId, dir, err.Error())
+	}
+	if m.ZoneId != zoneId || m.NodeId != nodeId {
+		return nil, fmt.Errorf("backup in %s is of node %d-%d", nodeDir, m.ZoneId, m.NodeId)
+	}
+	if len(DBConfig.DbPaths) == 0 {
+		return nil, errors.New("DbPaths is not set in config")
+	}
+
+	// check all before changing anything
+	for _, d := range m.Dbs {
+		if _, err = os.Stat(filepath.Join(nodeDir, d.Name)); err != nil {
+			return nil, fmt.Errorf("backup of %s: %s", d.Name, err.Error())
+		}
+		for _, p := range DBConfig.DbPaths {
+			target := filepath.Join(p.Path, d.Name)
+			if _, e := os.Stat(target); e == nil && !force {
+				return nil, fmt.Errorf("%s exists", target)
+			}
+		}
+	}
+
+	for _, d := range m.Dbs {
+		for _, p := range DBConfig.DbPaths {
+			if err = os.RemoveAll(filepath.Join(p.Path, d.Name)); err != nil {
+				return
+			}
+		}
+		walDir := backupWalDir(m, d)
+		if len(walDir) != 0 {
+			if err = os.RemoveAll(walDir); err != nil {
+				return
+			}
+		}
+		target := filepath.Join(DBConfig.DbPaths[0].Path, d.Name)
+		if err = restoreDbFiles(filepath.Join(nodeDir, d.Name), target, walDir); err != nil {
+			return nil, fmt.Errorf("restore %s: %s", d.Name, err.Error())
+		}
+		glog.Infof("%s restored, %d records", target, d.NumRecords)
+	}
+	return
+}
+
+// backupWalDir returns the WAL directory the database is opened with, or ""
+// if the WAL is kept in the database directory.
+func backupWalDir(m *BackupManifest, d BackupDbInfo) string {
+	if DBConfig.WriteDisableWAL || len(DBConfig.WalDir) == 0 {
+		return ""
+	}
+	prefix := fmt.Sprintf("%d-%d", m.ZoneId, m.NodeId)
+	if m.Sharding == BackupShardingByPrefix {
+		return fmt.Sprintf("%s/wal%s-%d", DBConfig.WalDir, prefix, d.Index)
+	}
+	return fmt.Sprintf("%s/wal-%s-%d", DBConfig.WalDir, prefix, d.Index)
+}
+
+// restoreDbFiles copies a checkpoint to target. The immutable table files are
+// hard linked when possible, and the log files go to walDir if set.
+func restoreDbFiles(src string, target string, walDir string) error {
+	if err := os.MkdirAll(target, 0777); err != nil {
+		return err
+	}
+	if len(walDir) != 0 {
+		if err := os.MkdirAll(walDir, 0777); err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		from := filepath.Join(src, name)
+		to := filepath.Join(target, name)
+		if len(walDir) != 0 && strings.HasSuffix(name, ".log") {
+			to = filepath.Join(walDir, name)
+		}
+		if strings.HasSuffix(name, ".sst") {
+			if err = os.Link(from, to); err == nil {
+				continue
+			}
+		}
+		if err = copyFile(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(from string, to string) (err error) {
+	var in, out *os.File
+	if in, err = os.Open(from); err != nil {
+		return
+	}
+	defer in.Close()
+	if out, err = os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fs.FileMode(0644)); err != nil {
+		return
+	}
+	if _, err = io.Copy(out, in); err == nil {
+		err = out.Sync()
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	return
+}
@@ -0,0 +1,85 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestoreBackup(t *testing.T) {
+	saved := DBConfig
+	defer func() { DBConfig = saved }()
+
+	backupDir := t.TempDir()
+	dataDir := t.TempDir()
+	walDir := t.TempDir()
+	DBConfig.DbPaths = []DbPath{{Path: dataDir}}
+	DBConfig.WalDir = walDir
+	DBConfig.WriteDisableWAL = false
+
+	nodeDir := filepath.Join(backupDir, "1-2")
+	m := &BackupManifest{
+		ZoneId:    1,
+		NodeId:    2,
+		Sharding:  BackupShardingByPrefix,
+		StartTime: time.Now(),
+		Dbs:       []BackupDbInfo{{Name: "1-2-0.db", Index: 0, NumRecords: 3}},
+	}
+	cpDir := filepath.Join(nodeDir, "1-2-0.db")
+	if err := os.MkdirAll(cpDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"000010.sst", "CURRENT", "MANIFEST-000005", "000011.log"} {
+		os.WriteFile(filepath.Join(cpDir, name), []byte(name), 0644)
+	}
+	if err := writeBackupManifest(nodeDir, m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RestoreBackup(backupDir, 1, 3, false); err == nil {
+		t.Error("expect error for a node not in the backup set")
+	}
+	os.MkdirAll(filepath.Join(dataDir, "1-2-0.db"), 0777)
+	if _, err := RestoreBackup(backupDir, 1, 2, false); err == nil {
+		t.Error("expect error if the db exists")
+	}
+
+	restored, err := RestoreBackup(backupDir, 1, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Dbs) != 1 || restored.Dbs[0].NumRecords != 3 {
+		t.Errorf("unexpected manifest %+v", restored)
+	}
+	for _, name := range []string{"000010.sst", "CURRENT", "MANIFEST-000005"} {
+		if data, err := os.ReadFile(filepath.Join(dataDir, "1-2-0.db", name)); err != nil || string(data) != name {
+			t.Errorf("%s not restored: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(walDir, "wal1-2-0", "000011.log")); err != nil {
+		t.Errorf("expect the log in the wal dir: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(cpDir, "000010.sst")); err != nil {
+		t.Errorf("expect the backup kept: %s", err)
+	}
+}
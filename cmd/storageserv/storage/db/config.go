@@ -184,6 +184,11 @@ type Config struct {
 	// and the write may got lost after a crash.
 	WriteDisableWAL bool
 
+	// If true, records are written without checksum, in the encoding read
+	// by the versions before the checksum was added. Set it during an upgrade
+	// that may be rolled back. The checksum is verified on read either way.
+	RecordChecksumDisabled bool
+
 	RandomizeWriteBuffer bool
 
 	RateBytesPerSec int64
@@ -21,6 +21,7 @@ package db
 
 import (
 	"io"
+	"time"
 
 	"github.com/paypal/junodb/cmd/storageserv/redist"
 	"github.com/paypal/junodb/pkg/shard"
@@ -35,11 +36,16 @@ type IDatabase interface {
 	Get(id RecordID, fetchExpired bool) (*Record, error)
 	GetRecord(id RecordID, rec *Record) (recExists bool, err error)
 	Delete(id RecordID) error
+	SaveHistory(id RecordID, tombstone *RecordHeader, maxVersions int, maxAge time.Duration) error
+	GetHistory(id RecordID) ([]*Record, error)
 
 	IsPresent(id RecordID) (bool, error, *Record)
 	IsRecordPresent(id RecordID, rec *Record) (bool, error)
 
 	ReplicateSnapshot(shardId shard.ID, r *redist.Replicator, mshardid int32) bool
+	ForEachInSnapshot(shardId shard.ID, fn func(ns []byte, key []byte, rec *Record) bool) bool
+	GetShards() shard.Map
+	Backup(dir string) (*BackupManifest, error)
 	ShardSupported(shardId shard.ID) bool
 	UpdateRedistShards(shards shard.Map)
 	UpdateShards(shards shard.Map)
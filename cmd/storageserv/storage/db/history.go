@@ -0,0 +1,231 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+	"github.com/paypal/junodb/third_party/forked/tecbot/gorocksdb"
+)
+
+// Record history
+//
+// For the namespaces with history enabled, the version of a record replaced
+// or deleted by a write is kept as a history entry in the same shard, with
+// the key
+//
+//	shard id (2 bytes) | [micro shard id (1 byte)] | 0 | namespace length | namespace |
+//	key length (2 bytes) | key | last modification time of the version (8 bytes)
+//
+// and the encoded record as the value. As no record has an empty namespace,
+// a history entry is never taken as a record. The entries of a record sort by
+// their last modification time, and expire with the version they keep. They
+// are not copied to the new owner of the shard by redistribution.
+
+const kSzHistoryLmt = 8
+
+// ErrHistoryKey is returned when decoding the key of a history entry as a
+// record key.
+var ErrHistoryKey = errors.New("history key")
+
+func recordIdPrefixLen() int {
+	if enableMircoShardId {
+		return 3
+	}
+	return 2
+}
+
+// historyPrefix returns the prefix of the keys of the history entries of a
+// record.
+func historyPrefix(id RecordID) RecordID {
+	off := recordIdPrefixLen()
+	szNamespace := int(id[off])
+	key := id[off+1+szNamespace:]
+
+	prefix := make([]byte, len(id)+3, len(id)+3+kSzHistoryLmt)
+	copy(prefix, id[:off])
+	prefix[off] = 0
+	copy(prefix[off+1:], id[off:off+1+szNamespace])
+	off += 2 + szNamespace
+	binary.BigEndian.PutUint16(prefix[off:], uint16(len(key)))
+	copy(prefix[off+2:], key)
+	return RecordID(prefix)
+}
+
+func historyKey(prefix RecordID, lmt uint64) RecordID {
+	var b [kSzHistoryLmt]byte
+	binary.BigEndian.PutUint64(b[:], lmt)
+	return append(prefix[:len(prefix):len(prefix)], b[:]...)
+}
+
+type historyEntry struct {
+	key   []byte // db key
+	lmt   uint64
+	value []byte // nil if already in the db
+}
+
+// SaveHistory keeps the current version of the record as a history entry,
+// before it is replaced or deleted. If tombstone is not nil, the record is
+// being deleted, and a marked delete entry with its header is kept for the
+// deletion. Then it
+// removes the entries beyond the last maxVersions ones, and the ones replaced
+// longer than maxAge ago. 0 means no limit.
+func (r *RocksDB) SaveHistory(id RecordID, tombstone *RecordHeader, maxVersions int, maxAge time.Duration) error {
+	db, key := r.sharding.getDbInstanceAndKey(id)
+	if db == nil {
+		glog.Errorf("no db for shard %d", id.GetShardID())
+		return errors.New(fmt.Sprintf("no db for shard %d", id.GetShardID()))
+	}
+	value, err := db.Get(readOptions, key)
+	if err != nil {
+		glog.Errorf("%s, Key: %s", err, id)
+		return NewDBError(err)
+	}
+	defer value.Free()
+	if value.Data() == nil {
+		return nil
+	}
+	var rec Record
+	if err = rec.Decode(value.Data()); err != nil || rec.IsExpired() {
+		return nil
+	}
+
+	prefix := historyPrefix(id)
+	_, newKey := r.sharding.getDbInstanceAndKey(historyKey(prefix, rec.LastModificationTime))
+	entries := []historyEntry{{key: newKey, lmt: rec.LastModificationTime, value: value.Data()}}
+	var deleteTime uint64
+	if tombstone != nil && tombstone.LastModificationTime > rec.LastModificationTime {
+		deleteTime = tombstone.LastModificationTime
+		delRec := Record{RecordHeader: *tombstone}
+		delRec.MarkDelete()
+		var buf bytes.Buffer
+		if err = delRec.EncodeToBuffer(&buf); err != nil {
+			return err
+		}
+		_, delKey := r.sharding.getDbInstanceAndKey(historyKey(prefix, deleteTime))
+		entries = append(entries, historyEntry{key: delKey, lmt: deleteTime, value: buf.Bytes()})
+	}
+
+	_, dbPrefix := r.sharding.getDbInstanceAndKey(prefix)
+	iter := db.NewIterator(readOptions)
+	for iter.Seek(dbPrefix); iter.ValidForPrefix(dbPrefix); iter.Next() {
+		k := iter.Key().Data()
+		if len(k) != len(dbPrefix)+kSzHistoryLmt {
+			continue
+		}
+		lmt := binary.BigEndian.Uint64(k[len(dbPrefix):])
+		if lmt != rec.LastModificationTime && (deleteTime == 0 || lmt != deleteTime) {
+			entries = append(entries, historyEntry{key: append([]byte(nil), k...), lmt: lmt})
+		}
+	}
+	iter.Close()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].lmt < entries[j].lmt })
+
+	numRemoved := numHistoryRemoved(entries, maxVersions, maxAge, time.Now())
+
+	batch := gorocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for i, e := range entries {
+		if i < numRemoved {
+			if e.value == nil {
+				batch.Delete(e.key)
+			}
+		} else if e.value != nil {
+			batch.Put(e.key, e.value)
+		}
+	}
+	if err = db.Write(writeOptions, batch); err != nil {
+		glog.Errorf("RocksDB error while saving history: %s", err.Error())
+		return NewDBError(err)
+	}
+	return nil
+}
+
+// numHistoryRemoved returns the number of the entries, sorted the oldest
+// first, to be removed to keep the last maxVersions ones, and the ones
+// replaced at most maxAge before now. An entry is replaced when the next one
+// is written, so the last one is always kept.
+func numHistoryRemoved(entries []historyEntry, maxVersions int, maxAge time.Duration, now time.Time) (numRemoved int) {
+	if maxVersions > 0 && len(entries) > maxVersions {
+		numRemoved = len(entries) - maxVersions
+	}
+	if maxAge > 0 {
+		oldest := uint64(now.Add(-maxAge).UnixNano())
+		for numRemoved < len(entries)-1 && entries[numRemoved+1].lmt < oldest {
+			numRemoved++
+		}
+	}
+	return
+}
+
+// SelectHistory returns the latest of the versions, sorted the oldest first,
+// with the given version and last modified at or before asOf. 0 matches any.
+// A marked delete entry copies the version of the record deleted, so it is
+// only selected by time, for the version to be read back after a delete.
+func SelectHistory(recs []*Record, version uint32, asOf uint64) (rec *Record) {
+	for _, r := range recs {
+		if version != 0 && (r.Version != version || r.IsMarkedDelete()) {
+			continue
+		}
+		if asOf == 0 || r.LastModificationTime <= asOf {
+			rec = r
+		}
+	}
+	return
+}
+
+// GetHistory returns the unexpired history entries of a record, the oldest
+// first. The current version of the record is not included.
+func (r *RocksDB) GetHistory(id RecordID) (recs []*Record, err error) {
+	prefix := historyPrefix(id)
+	db, dbPrefix := r.sharding.getDbInstanceAndKey(prefix)
+	if db == nil {
+		glog.Errorf("no db for shard %d", id.GetShardID())
+		return nil, errors.New(fmt.Sprintf("no db for shard %d", id.GetShardID()))
+	}
+	iter := db.NewIterator(readOptions)
+	defer iter.Close()
+	for iter.Seek(dbPrefix); iter.ValidForPrefix(dbPrefix); iter.Next() {
+		if len(iter.Key().Data()) != len(dbPrefix)+kSzHistoryLmt {
+			continue
+		}
+		rec := new(Record)
+		if err = rec.Decode(append([]byte(nil), iter.Value().Data()...)); err != nil {
+			if err == ErrRecordCorrupted {
+				logCorruptedRecord(id)
+			}
+			err = nil
+			continue
+		}
+		if !rec.IsExpired() {
+			recs = append(recs, rec)
+		}
+	}
+	if err = iter.Err(); err != nil {
+		err = NewDBError(err)
+	}
+	return
+}
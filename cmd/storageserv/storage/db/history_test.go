@@ -0,0 +1,139 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package db
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/shard"
+)
+
+func TestHistoryKey(t *testing.T) {
+	for _, microShard := range []bool{false, true} {
+		SetEnableMircoShardId(microShard)
+
+		var buf bytes.Buffer
+		id := NewRecordIDWithBuffer(&buf, shard.ID(129), 6, []byte("ns"), []byte("ab"))
+		prefix := historyPrefix(id)
+		hkey := historyKey(prefix, 0x0102030405060708)
+
+		off := recordIdPrefixLen()
+		if !bytes.Equal(hkey[:off], id[:off]) {
+			t.Errorf("history key not in the shard of the record: %X", hkey)
+		}
+		if _, _, err := DecodeRecordKey(hkey); err != ErrHistoryKey {
+			t.Errorf("expect ErrHistoryKey, got %v", err)
+		}
+		if _, _, err := DecodeRecordKeyNoShardID(hkey.GetKeyWithoutShardID()); err != ErrHistoryKey {
+			t.Errorf("expect ErrHistoryKey, got %v", err)
+		}
+		if !bytes.Equal(prefix, hkey[:len(prefix)]) || len(hkey) != len(prefix)+kSzHistoryLmt {
+			t.Errorf("unexpected history key %X of prefix %X", hkey, prefix)
+		}
+
+		// the history of "ab" does not include the one of "abc"
+		var otherBuf bytes.Buffer
+		other := NewRecordIDWithBuffer(&otherBuf, shard.ID(129), 6, []byte("ns"), []byte("abc"))
+		if bytes.HasPrefix(historyKey(historyPrefix(other), 1), prefix) {
+			t.Errorf("history of %s matches the prefix of %s", other, id)
+		}
+	}
+	SetEnableMircoShardId(false)
+}
+
+func TestHistoryRetention(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) uint64 { return uint64(now.Add(-d).UnixNano()) }
+	entries := []historyEntry{
+		{lmt: ago(5 * time.Hour)},
+		{lmt: ago(4 * time.Hour)},
+		{lmt: ago(3 * time.Hour)},
+		{lmt: ago(2 * time.Hour)},
+		{lmt: ago(time.Hour)},
+	}
+	tests := []struct {
+		name        string
+		maxVersions int
+		maxAge      time.Duration
+		expected    int
+	}{
+		{"no limit", 0, 0, 0},
+		{"max versions", 3, 0, 2},
+		{"max versions above count", 10, 0, 0},
+		// the entry at -4h was replaced at -3h, within 3.5h
+		{"max age", 0, 3*time.Hour + 30*time.Minute, 1},
+		{"max age keeps the last one", 0, time.Minute, 4},
+		{"both, max versions first", 2, 3*time.Hour + 30*time.Minute, 3},
+		{"both, max age first", 4, 2*time.Hour + 30*time.Minute, 2},
+	}
+	for _, tc := range tests {
+		if n := numHistoryRemoved(entries, tc.maxVersions, tc.maxAge, now); n != tc.expected {
+			t.Errorf("%s: %d removed, expected %d", tc.name, n, tc.expected)
+		}
+	}
+}
+
+func TestSelectHistory(t *testing.T) {
+	newRec := func(version uint32, lmt uint64, deleted bool) *Record {
+		rec := &Record{RecordHeader: RecordHeader{Version: version, LastModificationTime: lmt}}
+		if deleted {
+			rec.MarkDelete()
+		}
+		return rec
+	}
+	// v1 at 10, v2 at 20, deleted at 30 with the header of v2, v1 created again at 40
+	recs := []*Record{
+		newRec(1, 10, false),
+		newRec(2, 20, false),
+		newRec(2, 30, true),
+		newRec(1, 40, false),
+	}
+	tests := []struct {
+		name    string
+		version uint32
+		asOf    uint64
+		lmt     uint64 // of the record expected, 0 for none
+	}{
+		{"latest", 0, 0, 40},
+		{"version", 2, 0, 20},
+		{"version after delete", 2, 35, 20},
+		{"version not found", 3, 0, 0},
+		{"version recreated", 1, 0, 40},
+		{"version as of", 1, 25, 10},
+		{"as of before first", 0, 5, 0},
+		{"as of", 0, 25, 20},
+		{"as of deleted", 0, 35, 30},
+	}
+	for _, tc := range tests {
+		rec := SelectHistory(recs, tc.version, tc.asOf)
+		var lmt uint64
+		if rec != nil {
+			lmt = rec.LastModificationTime
+		}
+		if lmt != tc.lmt {
+			t.Errorf("%s: selected the record of lmt %d, expected %d", tc.name, lmt, tc.lmt)
+		}
+	}
+	if rec := SelectHistory(recs, 0, 35); rec == nil || !rec.IsMarkedDelete() {
+		t.Error("marked delete entry not selected as of the delete")
+	}
+}
@@ -0,0 +1,214 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+Binary dump format
+
+	header: "JUNODUMP" | format version (1 byte)
+	record: length of the rest of the record (4 bytes) |
+	        namespace length (1 byte) | namespace | key length (2 bytes) | key |
+	        payload type (1 byte) | version (4 bytes) | creation time (4 bytes) |
+	        expiration time (4 bytes) | last modification time (8 bytes) | value
+
+All integers are big endian. The JSON lines format has one DumpRecord per
+line, with the key and the value base64 encoded.
+*/
+
+const (
+	kFormatJson = "json"
+	kFormatBin  = "bin"
+
+	kBinMagic         = "JUNODUMP"
+	kBinFormatVersion = byte(1)
+	kSzBinFixed       = 1 + 2 + 1 + 4 + 4 + 4 + 8
+	kMaxBinRecordSize = 64 * 1024 * 1024
+)
+
+// DumpRecord is a record of a namespace in a dump.
+type DumpRecord struct {
+	Namespace            string `json:"namespace"`
+	Key                  []byte `json:"key"`
+	Value                []byte `json:"value"`
+	PayloadType          uint8  `json:"payloadType"`
+	Version              uint32 `json:"version"`
+	CreationTime         uint32 `json:"creationTime"`
+	ExpirationTime       uint32 `json:"expirationTime"`
+	LastModificationTime uint64 `json:"lastModificationTime"`
+}
+
+type dumpWriter interface {
+	Write(rec *DumpRecord) error
+	Flush() error
+}
+
+type dumpReader interface {
+	// Read returns io.EOF at the end of the dump.
+	Read() (*DumpRecord, error)
+}
+
+type jsonDumpWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+type binDumpWriter struct {
+	w   *bufio.Writer
+	buf bytes.Buffer
+}
+
+type jsonDumpReader struct {
+	dec *json.Decoder
+}
+
+type binDumpReader struct {
+	r *bufio.Reader
+}
+
+func newDumpWriter(w io.Writer, format string) (dumpWriter, error) {
+	bw := bufio.NewWriterSize(w, 1024*1024)
+	switch format {
+	case kFormatJson:
+		return &jsonDumpWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case kFormatBin:
+		bw.WriteString(kBinMagic)
+		bw.WriteByte(kBinFormatVersion)
+		return &binDumpWriter{w: bw}, nil
+	}
+	return nil, fmt.Errorf("unsupported dump format %s", format)
+}
+
+// newDumpReader detects the format of the dump from its header.
+func newDumpReader(r io.Reader) (dumpReader, error) {
+	br := bufio.NewReaderSize(r, 1024*1024)
+	header, err := br.Peek(len(kBinMagic) + 1)
+	if err == nil && string(header[:len(kBinMagic)]) == kBinMagic {
+		if header[len(kBinMagic)] != kBinFormatVersion {
+			return nil, fmt.Errorf("unsupported dump format version %d", header[len(kBinMagic)])
+		}
+		br.Discard(len(header))
+		return &binDumpReader{r: br}, nil
+	}
+	return &jsonDumpReader{dec: json.NewDecoder(br)}, nil
+}
+
+func (d *jsonDumpWriter) Write(rec *DumpRecord) error {
+	return d.enc.Encode(rec)
+}
+
+func (d *jsonDumpWriter) Flush() error {
+	return d.w.Flush()
+}
+
+func (d *binDumpWriter) Write(rec *DumpRecord) error {
+	if len(rec.Namespace) > 255 || len(rec.Key) > 65535 {
+		return errors.New("namespace or key too long")
+	}
+	d.buf.Reset()
+	var b [8]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(kSzBinFixed+len(rec.Namespace)+len(rec.Key)+len(rec.Value)))
+	d.buf.Write(b[:4])
+	d.buf.WriteByte(uint8(len(rec.Namespace)))
+	d.buf.WriteString(rec.Namespace)
+	binary.BigEndian.PutUint16(b[:2], uint16(len(rec.Key)))
+	d.buf.Write(b[:2])
+	d.buf.Write(rec.Key)
+	d.buf.WriteByte(rec.PayloadType)
+	binary.BigEndian.PutUint32(b[:4], rec.Version)
+	d.buf.Write(b[:4])
+	binary.BigEndian.PutUint32(b[:4], rec.CreationTime)
+	d.buf.Write(b[:4])
+	binary.BigEndian.PutUint32(b[:4], rec.ExpirationTime)
+	d.buf.Write(b[:4])
+	binary.BigEndian.PutUint64(b[:], rec.LastModificationTime)
+	d.buf.Write(b[:])
+	d.buf.Write(rec.Value)
+	_, err := d.w.Write(d.buf.Bytes())
+	return err
+}
+
+func (d *binDumpWriter) Flush() error {
+	return d.w.Flush()
+}
+
+func (d *jsonDumpReader) Read() (*DumpRecord, error) {
+	rec := &DumpRecord{}
+	if err := d.dec.Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (d *binDumpReader) Read() (*DumpRecord, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(d.r, b[:]); err != nil {
+		return nil, err
+	}
+	sz := binary.BigEndian.Uint32(b[:])
+	if sz < kSzBinFixed || sz > kMaxBinRecordSize {
+		return nil, fmt.Errorf("bad record length %d", sz)
+	}
+	data := make([]byte, sz)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	rec := &DumpRecord{}
+	off := 0
+	szNs := int(data[off])
+	off++
+	if off+szNs+2 > len(data) {
+		return nil, errors.New("bad namespace length")
+	}
+	rec.Namespace = string(data[off : off+szNs])
+	off += szNs
+	szKey := int(binary.BigEndian.Uint16(data[off:]))
+	off += 2
+	if off+szKey+kSzBinFixed-3 > len(data) {
+		return nil, errors.New("bad key length")
+	}
+	rec.Key = data[off : off+szKey]
+	off += szKey
+	rec.PayloadType = data[off]
+	off++
+	rec.Version = binary.BigEndian.Uint32(data[off:])
+	off += 4
+	rec.CreationTime = binary.BigEndian.Uint32(data[off:])
+	off += 4
+	rec.ExpirationTime = binary.BigEndian.Uint32(data[off:])
+	off += 4
+	rec.LastModificationTime = binary.BigEndian.Uint64(data[off:])
+	off += 8
+	rec.Value = data[off:]
+	return rec, nil
+}
@@ -0,0 +1,77 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestDumpFormats(t *testing.T) {
+	recs := []*DumpRecord{
+		{Namespace: "ns1", Key: []byte("key1"), Value: []byte("value1"), Version: 3,
+			CreationTime: 1700000000, ExpirationTime: 1700003600, LastModificationTime: 1700000100000000000},
+		{Namespace: "ns1", Key: []byte{0, 1, 0xff}, Value: []byte{}, PayloadType: 2, Version: 1},
+	}
+
+	for _, format := range []string{kFormatJson, kFormatBin} {
+		var buf bytes.Buffer
+		w, err := newDumpWriter(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range recs {
+			if err = w.Write(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w.Flush()
+
+		r, err := newDumpReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, rec := range recs {
+			got, err := r.Read()
+			if err != nil {
+				t.Fatalf("%s: record %d: %s", format, i, err)
+			}
+			if !reflect.DeepEqual(got, rec) {
+				t.Errorf("%s: record %d: got %+v, expect %+v", format, i, got, rec)
+			}
+		}
+		if _, err = r.Read(); err != io.EOF {
+			t.Errorf("%s: expect EOF, got %v", format, err)
+		}
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	sskey := []byte{0, 5, 7, 3, 'n', 's', '1', 'k', 'e', 'y'}
+	ns, key, ok := decodeKey(sskey, true, true)
+	if !ok || string(ns) != "ns1" || string(key) != "key" {
+		t.Errorf("unexpected %s %s %v", ns, key, ok)
+	}
+	if _, _, ok = decodeKey(sskey[:4], true, true); ok {
+		t.Error("expect the truncated key to be rejected")
+	}
+}
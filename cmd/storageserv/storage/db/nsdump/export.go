@@ -0,0 +1,151 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+	"github.com/paypal/junodb/third_party/forked/tecbot/gorocksdb"
+
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+type exportStats struct {
+	numRecords int
+	numSkipped int // expired or deleted
+	numErrors  int
+}
+
+// Return the db directories of the node, or of all the nodes if node < 0.
+func getDbSet(dbPath string, zone int, node int) (dbset []string) {
+	pattern := filepath.Join(dbPath, "*.db")
+	if zone >= 0 {
+		if node >= 0 {
+			pattern = filepath.Join(dbPath, fmt.Sprintf("%d-%d-*.db", zone, node))
+		} else {
+			pattern = filepath.Join(dbPath, fmt.Sprintf("%d-*.db", zone))
+		}
+	}
+	pathList, _ := filepath.Glob(pattern)
+	for _, path := range pathList {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dbset = append(dbset, path)
+		}
+	}
+	return
+}
+
+// decodeKey returns the namespace and the key of a storage key. With prefix
+// dbs, the storage key starts with the shard id, and the micro shard id if
+// enabled.
+func decodeKey(sskey []byte, prefixDbs bool, microShards bool) (ns []byte, key []byte, ok bool) {
+	off := 0
+	if prefixDbs {
+		off = 2
+		if microShards {
+			off++
+		}
+	}
+	if len(sskey) <= off || len(sskey) < off+1+int(sskey[off]) {
+		return
+	}
+	// history entries are skipped
+	var err error
+	if ns, key, err = db.DecodeRecordKeyNoShardID(sskey[off:]); err != nil {
+		return nil, nil, false
+	}
+	return ns, key, true
+}
+
+// exportDb writes the live records of the namespace in a db to the dump. The
+// db is opened read only, so it can be exported while the storage server is
+// running, or from a backup.
+func exportDb(path string, ns []byte, w dumpWriter, prefixDbs bool, microShards bool,
+	decrypt bool, st *exportStats) error {
+	opts := db.NewRocksDBptions()
+	defer opts.Destroy()
+	opts.SetCreateIfMissing(false)
+
+	rdb, err := gorocksdb.OpenDbForReadOnly(opts, path, false)
+	if err != nil {
+		return fmt.Errorf("open %s: %s", path, err.Error())
+	}
+	defer rdb.Close()
+
+	ro := gorocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetFillCache(false)
+	iter := rdb.NewIterator(ro)
+	defer iter.Close()
+
+	glog.Infof("Export db: %s", path)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		k := iter.Key()
+		recNs, key, ok := decodeKey(k.Data(), prefixDbs, microShards)
+		k.Free()
+		if !ok || !bytes.Equal(recNs, ns) {
+			continue
+		}
+
+		v := iter.Value()
+		var rec db.Record
+		if err = rec.Decode(v.Data()); err != nil {
+			v.Free()
+			st.numErrors++
+			continue
+		}
+		if rec.IsExpired() || rec.IsMarkedDelete() {
+			v.Free()
+			st.numSkipped++
+			continue
+		}
+		if decrypt && rec.Payload.GetPayloadType() == proto.PayloadTypeEncryptedByProxy {
+			if err = rec.Payload.Decrypt(); err != nil {
+				v.Free()
+				if st.numErrors < 10 {
+					glog.Errorf("[ERROR] Decrypt failed: %s", err)
+				}
+				st.numErrors++
+				continue
+			}
+		}
+		err = w.Write(&DumpRecord{
+			Namespace:            string(recNs),
+			Key:                  key,
+			Value:                rec.Payload.GetData(),
+			PayloadType:          uint8(rec.Payload.GetPayloadType()),
+			Version:              rec.Version,
+			CreationTime:         rec.CreationTime,
+			ExpirationTime:       rec.ExpirationTime,
+			LastModificationTime: rec.LastModificationTime,
+		})
+		v.Free()
+		if err != nil {
+			return err
+		}
+		st.numRecords++
+	}
+	return iter.Err()
+}
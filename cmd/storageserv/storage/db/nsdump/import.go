@@ -0,0 +1,209 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/storageserv/config"
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/internal/cli"
+	junoio "github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/shard"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+type importStats struct {
+	numRecords  int64
+	numExpired  int64
+	numKept     int64 // a newer record exists
+	numNotOwned int64 // the shard is not on the node
+	numErrors   int64
+}
+
+func (s *importStats) log() {
+	glog.Infof("imported=%d expired=%d kept=%d not_owned=%d errors=%d",
+		s.numRecords, s.numExpired, s.numKept, s.numNotOwned, s.numErrors)
+}
+
+// importToProxy sends the records as replicated set requests, so the
+// expiration time, version and creation time of the records are kept. A
+// record updated on the target since is not overwritten.
+func importToProxy(r dumpReader, ns string, proxyAddr string, numConns int, st *importStats) error {
+	if numConns <= 0 {
+		numConns = 1
+	}
+	ch := make(chan *proto.OperationalMessage, 1000)
+	wg := new(sync.WaitGroup)
+	wg.Add(numConns)
+	for i := 0; i < numConns; i++ {
+		processor := cli.NewProcessor(
+			junoio.ServiceEndpoint{Addr: proxyAddr},
+			"nsdump",
+			time.Duration(500*time.Millisecond),  // ConnectTimeout
+			time.Duration(1000*time.Millisecond), // RequestTimeout
+			time.Duration(60*time.Second))        // ConnectRecycleTimeout
+		processor.Start()
+
+		go func() {
+			defer wg.Done()
+			defer processor.Close()
+			for req := range ch {
+				if err := sendToProxy(processor, req); err != nil {
+					if atomic.AddInt64(&st.numErrors, 1) <= 10 {
+						glog.Errorf("[ERROR] Import key=%s failed: %s", util.ToPrintableAndHexString(req.GetKey()), err)
+					}
+					continue
+				}
+				atomic.AddInt64(&st.numRecords, 1)
+			}
+		}()
+	}
+
+	var err error
+	var rec *DumpRecord
+	for {
+		if rec, err = r.Read(); err != nil {
+			break
+		}
+		if len(ns) != 0 {
+			rec.Namespace = ns
+		}
+		now := uint32(time.Now().Unix())
+		if rec.ExpirationTime <= now {
+			st.numExpired++
+			continue
+		}
+		var payload proto.Payload
+		payload.SetPayload(proto.PayloadType(rec.PayloadType), rec.Value)
+
+		req := &proto.OperationalMessage{}
+		req.SetRequest(proto.OpCodeSet, rec.Key, []byte(rec.Namespace), &payload, rec.ExpirationTime-now)
+		req.SetAsReplication()
+		req.SetNewRequestID()
+		req.SetCreationTime(rec.CreationTime)
+		req.SetLastModificationTime(rec.LastModificationTime)
+		req.SetVersion(rec.Version)
+		req.SetExpirationTime(rec.ExpirationTime)
+		ch <- req
+	}
+	close(ch)
+	wg.Wait()
+
+	if err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+func sendToProxy(processor *cli.Processor, req *proto.OperationalMessage) (err error) {
+	var resp *proto.OperationalMessage
+	for i := 0; i < 3; i++ {
+		if resp, err = processor.ProcessRequest(req); err != nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if status := resp.GetOpStatus(); status != proto.OpStatusNoError &&
+			status != proto.OpStatusVersionConflict {
+			err = &importError{status}
+		}
+		return
+	}
+	return
+}
+
+type importError struct {
+	status proto.OpStatus
+}
+
+func (e *importError) Error() string {
+	return e.status.String()
+}
+
+// importToStorage writes the records to the databases of a node, with the
+// shard ids of the cluster. The storage server must be stopped. The records
+// of the shards not on the node are skipped, so the same dump is imported to
+// each node of the cluster.
+func importToStorage(r dumpReader, ns string, zone int, node int, st *importStats) (err error) {
+	cfg := config.ServerConfig()
+	numShards := cfg.ClusterInfo.NumShards
+	db.Initialize(int(numShards), int(cfg.NumMicroShards), int(cfg.NumMicroShardGroups),
+		int(cfg.NumPrefixDbs), zone, node, cfg.NewShardMap(zone, node), 0)
+	defer db.Finalize()
+
+	var idBuf, valueBuf bytes.Buffer
+	var rec *DumpRecord
+	for {
+		if rec, err = r.Read(); err != nil {
+			break
+		}
+		if len(ns) != 0 {
+			rec.Namespace = ns
+		}
+		if int64(rec.ExpirationTime) <= time.Now().Unix() {
+			st.numExpired++
+			continue
+		}
+		shardId, microShardId := util.GetShardIds(rec.Key, numShards, cfg.NumMicroShards)
+		if !db.GetDB().ShardSupported(shard.ID(shardId)) {
+			st.numNotOwned++
+			continue
+		}
+		id := db.NewRecordIDWithBuffer(&idBuf, shard.ID(shardId), microShardId, []byte(rec.Namespace), rec.Key)
+
+		var cur db.Record
+		exist, _ := db.GetDB().GetRecord(id, &cur)
+		newer := exist && !cur.IsExpired() && cur.LastModificationTime > rec.LastModificationTime
+		cur.ResetRecord()
+		if newer {
+			st.numKept++
+			continue
+		}
+
+		dbRec := db.Record{
+			RecordHeader: db.RecordHeader{
+				Version:              rec.Version,
+				CreationTime:         rec.CreationTime,
+				LastModificationTime: rec.LastModificationTime,
+				ExpirationTime:       rec.ExpirationTime,
+			},
+		}
+		dbRec.RequestId.SetNewRequestId()
+		dbRec.OriginatorRequestId = dbRec.RequestId
+		dbRec.Payload.SetPayload(proto.PayloadType(rec.PayloadType), rec.Value)
+		valueBuf.Reset()
+		dbRec.EncodeToBuffer(&valueBuf)
+		if err = db.GetDB().Put(id, valueBuf.Bytes()); err != nil {
+			return
+		}
+		st.numRecords++
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,159 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// nsdump exports the live records of a namespace to a dump file, and imports
+// a dump through a proxy or into the databases of a storage node.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/storageserv/config"
+	"github.com/paypal/junodb/pkg/sec"
+)
+
+func main() {
+	defer glog.Finalize()
+
+	var (
+		cmdString string
+		cfgFile   string
+		ns        string
+		zone      int
+		node      int
+		dbPath    string
+		format    string
+		file      string
+		proxyAddr string
+		numConns  int
+		keyStore  string
+	)
+
+	flag.StringVar(&cmdString, "cmd", "none", "export|import")
+	flag.StringVar(&cfgFile, "c", "config.toml", "storage server config file")
+	flag.StringVar(&ns, "ns", "", "namespace to export, or to import to if different from the dump")
+	flag.IntVar(&zone, "zone", -1, "zone id")
+	flag.IntVar(&node, "node", -1, "node id")
+	flag.StringVar(&dbPath, "dbpath", "", "db directory to export from, the first DbPaths if not set")
+	flag.StringVar(&format, "format", kFormatJson, "dump format: json|bin")
+	flag.StringVar(&file, "file", "", "dump file")
+	flag.StringVar(&proxyAddr, "proxy", "", "proxy address to import through: host:port")
+	flag.IntVar(&numConns, "conns", 4, "number of connections to the proxy")
+	flag.StringVar(&keyStore, "keystore", "", "key store file to decrypt the values encrypted by the proxy")
+	flag.Parse()
+
+	if len(file) == 0 {
+		printUsage()
+		return
+	}
+
+	// not needed to import through a proxy
+	cfg := config.ServerConfig()
+	if cmdString == "export" || len(proxyAddr) == 0 {
+		if err := config.LoadConfig(cfgFile); err != nil {
+			glog.Exitf("[ERROR] Failed to load config file %s. %s", cfgFile, err)
+		}
+	}
+
+	switch cmdString {
+	case "export":
+		if len(ns) == 0 {
+			glog.Exitf("[ERROR] -ns is missing.")
+		}
+		if len(keyStore) != 0 {
+			if err := sec.Initialize(&sec.Config{AppName: "nsdump", KeyStoreFilePath: keyStore},
+				sec.KFlagEncryptionEnabled); err != nil {
+				glog.Exitf("[ERROR] Failed to load key store. %s", err)
+			}
+		}
+		if len(dbPath) == 0 {
+			if len(cfg.DB.DbPaths) == 0 {
+				glog.Exitf("[ERROR] DbPaths.Path entry is missing in config.")
+			}
+			dbPath = cfg.DB.DbPaths[0].Path
+		}
+
+		f, err := os.Create(file)
+		if err != nil {
+			glog.Exitf("[ERROR] %s", err)
+		}
+		defer f.Close()
+		w, err := newDumpWriter(f, format)
+		if err != nil {
+			glog.Exitf("[ERROR] %s", err)
+		}
+
+		var st exportStats
+		for _, path := range getDbSet(dbPath, zone, node) {
+			if err = exportDb(path, []byte(ns), w, cfg.NumPrefixDbs > 0, cfg.NumMicroShards > 0,
+				len(keyStore) != 0, &st); err != nil {
+				glog.Exitf("[ERROR] %s", err)
+			}
+		}
+		if err = w.Flush(); err != nil {
+			glog.Exitf("[ERROR] %s", err)
+		}
+		glog.Infof("ns=%s exported=%d skipped=%d errors=%d", ns, st.numRecords, st.numSkipped, st.numErrors)
+
+	case "import":
+		f, err := os.Open(file)
+		if err != nil {
+			glog.Exitf("[ERROR] %s", err)
+		}
+		defer f.Close()
+		r, err := newDumpReader(f)
+		if err != nil {
+			glog.Exitf("[ERROR] %s", err)
+		}
+
+		var st importStats
+		if len(proxyAddr) != 0 {
+			err = importToProxy(r, ns, proxyAddr, numConns, &st)
+		} else {
+			if zone < 0 || node < 0 {
+				glog.Exitf("[ERROR] -zone and -node are required to import into storage.")
+			}
+			err = importToStorage(r, ns, zone, node, &st)
+		}
+		st.log()
+		if err != nil {
+			glog.Exitf("[ERROR] Import failed: %s", err)
+		}
+
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	progName := filepath.Base(os.Args[0])
+	fmt.Printf("\nUsage:\n")
+	fmt.Printf("Export ns:             ./%s -cmd export -ns [ns] -file [file] -format json|bin\n", progName)
+	fmt.Printf("Export ns from node:   ./%s -cmd export -ns [ns] -file [file] -zone [n] -node [n]\n", progName)
+	fmt.Printf("Export ns from backup: ./%s -cmd export -ns [ns] -file [file] -dbpath [backup dir]/[zone]-[node]\n\n", progName)
+
+	fmt.Printf("Import via proxy:      ./%s -cmd import -file [file] -proxy [host:port]\n", progName)
+	fmt.Printf("Import into storage:   ./%s -cmd import -file [file] -zone [n] -node [n]\n", progName)
+	fmt.Printf("Import to another ns:  ./%s -cmd import -file [file] -proxy [host:port] -ns [ns]\n\n", progName)
+}
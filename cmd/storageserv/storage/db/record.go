@@ -28,7 +28,7 @@ Record Encoding Format
 	--------+---------------------------------+---------------
 	      1 | flag                            | 1 byte
 	--------+---------------------------------+---------------
-	      2 | reserved                        | 2 bytes
+	      2 | origin cluster id               | 2 bytes
 	--------+---------------------------------+---------------
 	      4 | expiration time                 | 4 bytes
 	--------+---------------------------------+---------------
@@ -42,7 +42,13 @@ Record Encoding Format
 	--------+---------------------------------+---------------
 	     40 | request Id of the originator    | 16 bytes
 	--------+---------------------------------+---------------
-	     56 | encapsulating payload           | ...
+	     56 | checksum                        | 4 bytes
+	--------+---------------------------------+---------------
+	     60 | encapsulating payload           | ...
+
+	The checksum is the CRC32C of the header without the checksum and of the
+	payload. Records of encoding version 1 have no checksum, and the payload
+	starts at offset 56.
 
 	Record Flag
 	  bit |           0|           1|           2|           3|           4|           5|           6|           7
@@ -62,7 +68,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"sync/atomic"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -74,28 +82,41 @@ import (
 )
 
 const (
-	kEncVersion byte = 0x01
+	kEncVersion           byte = 0x02
+	kEncVersionNoChecksum byte = 0x01
 
 	kSzEncVersion            = 1
 	kSzFlag                  = 1
-	kSzReserved              = 2
+	kSzOriginClusterId       = 2
 	kSzExpirationTime        = 4
 	kSzVersion               = 4
 	kSzCreationTime          = 4
 	kSzLastModificationTime  = 8
 	kSzLastModifierRequestId = 16
 	kSzOriginatorRequestId   = 16
-	kSzHeader                = 56
+	kSzChecksum              = 4
+	kSzHeaderNoChecksum      = 56
+	kSzHeader                = kSzHeaderNoChecksum + kSzChecksum
 
 	kOffEncodingVersion       = 0
 	kOffFlag                  = kOffEncodingVersion + kSzEncVersion
-	kOffReserved              = kOffFlag + kSzFlag
-	kOffExpirationTime        = kOffReserved + kSzReserved
+	kOffOriginClusterId       = kOffFlag + kSzFlag
+	kOffExpirationTime        = kOffOriginClusterId + kSzOriginClusterId
 	kOffVersion               = kOffExpirationTime + kSzExpirationTime
 	kOffCreationTime          = kOffVersion + kSzVersion
 	kOffLastModificationTime  = kOffCreationTime + kSzCreationTime
 	kOffLastModifierRequestId = kOffLastModificationTime + kSzLastModificationTime
 	kOffOriginatorRequestId   = kOffLastModifierRequestId + kSzLastModifierRequestId
+	kOffChecksum              = kOffOriginatorRequestId + kSzOriginatorRequestId
+)
+
+var (
+	// ErrRecordCorrupted is returned when decoding a record failing the
+	// checksum verification.
+	ErrRecordCorrupted = errors.New("record corrupted")
+
+	crc32cTable         = crc32.MakeTable(crc32.Castagnoli)
+	numCorruptedRecords uint64
 )
 
 const (
@@ -122,6 +143,7 @@ type (
 
 		OriginatorRequestId proto.RequestId
 		RequestId           proto.RequestId
+		OriginClusterId     uint16 // 0 if the record was not written in active-active mode
 		flag                recordFlagT
 	}
 	Record struct {
@@ -193,15 +215,22 @@ func (b *Record) ResetRecord() { //changed from Reset() to ResetRecord() to make
 }
 
 func (rec *Record) EncodingSize() int {
+	if DBConfig.RecordChecksumDisabled {
+		return kSzHeaderNoChecksum + int(rec.Payload.GetLength())
+	}
 	return kSzHeader + int(rec.Payload.GetLength())
 }
 
 func (rec *Record) EncodeToBuffer(buffer *bytes.Buffer) error {
 	var buf [kSzHeader]byte
+	szHeader := kSzHeader
 	buf[0] = kEncVersion
+	if DBConfig.RecordChecksumDisabled {
+		szHeader = kSzHeaderNoChecksum
+		buf[0] = kEncVersionNoChecksum
+	}
 	buf[1] = byte(rec.flag)
-	buf[2] = 0
-	buf[3] = 0
+	binary.BigEndian.PutUint16(buf[kOffOriginClusterId:kOffOriginClusterId+kSzOriginClusterId], rec.OriginClusterId)
 
 	//	if !rec.OriginatorRequestId.IsSet() {
 	//		panic("")
@@ -226,25 +255,58 @@ func (rec *Record) EncodeToBuffer(buffer *bytes.Buffer) error {
 		rec.RequestId.Bytes())
 	copy(buf[kOffOriginatorRequestId:kOffOriginatorRequestId+kSzOriginatorRequestId],
 		rec.OriginatorRequestId.Bytes())
-	buffer.Write(buf[:])
+	start := buffer.Len()
+	buffer.Write(buf[:szHeader])
 
 	rec.Payload.EncodeToBuffer(buffer)
 
+	if szHeader == kSzHeader {
+		data := buffer.Bytes()[start:]
+		binary.BigEndian.PutUint32(data[kOffChecksum:kOffChecksum+kSzChecksum], checksum(data))
+	}
 	return nil
 }
 
+func checksum(data []byte) uint32 {
+	c := crc32.Update(0, crc32cTable, data[:kOffChecksum])
+	return crc32.Update(c, crc32cTable, data[kSzHeader:])
+}
+
+// checkEncoding verifies the encoding version and the checksum of an encoded
+// record, and returns the size of its header.
+func checkEncoding(data []byte) (szHeader int, err error) {
+	if data == nil || len(data) < kSzHeaderNoChecksum {
+		return 0, errors.New("Decoding error: empty")
+	}
+	switch data[kOffEncodingVersion] {
+	case kEncVersionNoChecksum:
+		return kSzHeaderNoChecksum, nil
+	case kEncVersion:
+		if len(data) >= kSzHeader &&
+			checksum(data) == binary.BigEndian.Uint32(data[kOffChecksum:kOffChecksum+kSzChecksum]) {
+			return kSzHeader, nil
+		}
+	}
+	// an unknown encoding version is taken as corrupted too
+	atomic.AddUint64(&numCorruptedRecords, 1)
+	return 0, ErrRecordCorrupted
+}
+
+// NumCorruptedRecords returns the number of records found corrupted since
+// the start.
+func NumCorruptedRecords() uint64 {
+	return atomic.LoadUint64(&numCorruptedRecords)
+}
+
 // /TODO validation. the slices
 func (rec *Record) Decode(data []byte) error {
-	if data == nil || len(data) < kSzHeader {
-		return errors.New("Decoding error: empty")
+	szHeader, err := checkEncoding(data)
+	if err != nil {
+		return err
 	}
-	// TODO add more validation here !
 	szData := len(data)
-	encodingVersion := data[kOffEncodingVersion]
-	if encodingVersion != kEncVersion {
-		return fmt.Errorf("unsupported encoding version %d", encodingVersion)
-	}
 	rec.flag = recordFlagT(data[kOffFlag])
+	rec.OriginClusterId = binary.BigEndian.Uint16(data[kOffOriginClusterId : kOffOriginClusterId+kSzOriginClusterId])
 	rec.ExpirationTime = binary.BigEndian.Uint32(
 		data[kOffExpirationTime : kOffExpirationTime+kSzExpirationTime])
 	rec.Version = binary.BigEndian.Uint32(
@@ -255,7 +317,7 @@ func (rec *Record) Decode(data []byte) error {
 		data[kOffLastModificationTime : kOffLastModificationTime+kSzLastModificationTime])
 	rec.RequestId.SetFromBytes(data[kOffLastModifierRequestId : kOffLastModifierRequestId+kSzLastModifierRequestId])
 	rec.OriginatorRequestId.SetFromBytes(data[kOffOriginatorRequestId : kOffOriginatorRequestId+kSzOriginatorRequestId])
-	rec.Payload.Decode(data[kSzHeader:szData], false)
+	rec.Payload.Decode(data[szHeader:szData], false)
 	if glog.LOG_VERBOSE {
 		b := logging.NewKVBufferForLog()
 		b.AddRequestID(rec.RequestId).AddVersion(rec.Version).AddExpirationTime(rec.ExpirationTime).
@@ -273,17 +335,13 @@ func (rec *Record) DecodeFrom(holder valueHolderI) error {
 	rec.holder = holder
 	onAllocValue(holder)
 
-	if data == nil || len(data) < kSzHeader {
-		return errors.New("Decoding error: empty")
+	szHeader, err := checkEncoding(data)
+	if err != nil {
+		return err
 	}
-
-	// TODO add more validation here !
 	szData := len(data)
-	encodingVersion := data[kOffEncodingVersion]
-	if encodingVersion != kEncVersion {
-		return fmt.Errorf("unsupported encoding version %d", encodingVersion)
-	}
 	rec.flag = recordFlagT(data[kOffFlag])
+	rec.OriginClusterId = binary.BigEndian.Uint16(data[kOffOriginClusterId : kOffOriginClusterId+kSzOriginClusterId])
 	rec.ExpirationTime = binary.BigEndian.Uint32(
 		data[kOffExpirationTime : kOffExpirationTime+kSzExpirationTime])
 	rec.Version = binary.BigEndian.Uint32(
@@ -294,7 +352,7 @@ func (rec *Record) DecodeFrom(holder valueHolderI) error {
 		data[kOffLastModificationTime : kOffLastModificationTime+kSzLastModificationTime])
 	rec.RequestId.SetFromBytes(data[kOffLastModifierRequestId : kOffLastModifierRequestId+kSzLastModifierRequestId])
 	rec.OriginatorRequestId.SetFromBytes(data[kOffOriginatorRequestId : kOffOriginatorRequestId+kSzOriginatorRequestId])
-	rec.Payload.Decode(data[kSzHeader:szData], false)
+	rec.Payload.Decode(data[szHeader:szData], false)
 	if glog.LOG_VERBOSE {
 		b := logging.NewKVBufferForLog()
 		b.AddRequestID(rec.RequestId).AddVersion(rec.Version).AddExpirationTime(rec.ExpirationTime).
@@ -323,6 +381,7 @@ func (rec *Record) EncodeRedistMsg(shardId shard.ID, ns []byte, key []byte, row
 	msg.SetVersion(rec.Version)
 	msg.SetExpirationTime(rec.ExpirationTime)
 	msg.SetOriginatorRequestID(rec.OriginatorRequestId)
+	msg.SetOriginClusterId(uint32(rec.OriginClusterId))
 	return msg.Encode(row)
 }
 
@@ -349,6 +408,9 @@ func (rec *Record) PrettyPrint(w io.Writer) {
 	fmt.Fprintf(w, "Expiration Time       : %d\n", rec.ExpirationTime)
 	fmt.Fprintf(w, "Originator Request Id : %s\n", rec.OriginatorRequestId.String())
 	fmt.Fprintf(w, "Request Id            : %s\n", rec.RequestId.String())
+	if rec.OriginClusterId != 0 {
+		fmt.Fprintf(w, "Origin Cluster Id     : %d\n", rec.OriginClusterId)
+	}
 
 	rec.Payload.PrettyPrint(w)
 }
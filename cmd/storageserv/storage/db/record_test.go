@@ -0,0 +1,101 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package db
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRecordOriginClusterId(t *testing.T) {
+	rec := Record{
+		RecordHeader: RecordHeader{
+			Version:              3,
+			CreationTime:         uint32(time.Now().Unix()),
+			ExpirationTime:       uint32(time.Now().Unix()) + 100,
+			LastModificationTime: uint64(time.Now().UnixNano()),
+			OriginClusterId:      513,
+		},
+	}
+	rec.MarkDelete()
+	var buf bytes.Buffer
+	if err := rec.EncodeToBuffer(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Record
+	if err := decoded.Decode(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.OriginClusterId != 513 || !decoded.IsMarkedDelete() || decoded.Version != 3 ||
+		decoded.LastModificationTime != rec.LastModificationTime {
+		t.Errorf("unexpected record %v, origin cluster id %d", &decoded, decoded.OriginClusterId)
+	}
+}
+
+func TestRecordChecksum(t *testing.T) {
+	rec := Record{
+		RecordHeader: RecordHeader{
+			Version:              1,
+			CreationTime:         uint32(time.Now().Unix()),
+			ExpirationTime:       uint32(time.Now().Unix()) + 100,
+			LastModificationTime: uint64(time.Now().UnixNano()),
+		},
+	}
+	rec.Payload.SetWithClearValue([]byte("value"))
+	var buf bytes.Buffer
+	buf.WriteString("prefix")
+	rec.EncodeToBuffer(&buf)
+	data := buf.Bytes()[len("prefix"):]
+	if len(data) != rec.EncodingSize() || data[kOffEncodingVersion] != kEncVersion {
+		t.Fatalf("unexpected encoding, size %d version %d", len(data), data[kOffEncodingVersion])
+	}
+
+	var decoded Record
+	if err := decoded.Decode(data); err != nil || string(decoded.Payload.GetData()) != "value" {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	num := NumCorruptedRecords()
+	for _, off := range []int{kOffVersion, kOffChecksum, len(data) - 1} {
+		corrupted := append([]byte{}, data...)
+		corrupted[off] ^= 0x10
+		if err := decoded.Decode(corrupted); err != ErrRecordCorrupted {
+			t.Errorf("offset %d: expect corrupted record, got %v", off, err)
+		}
+	}
+	if NumCorruptedRecords() != num+3 {
+		t.Errorf("expect 3 more corrupted records, got %d", NumCorruptedRecords()-num)
+	}
+
+	// records written without checksum are still read
+	DBConfig.RecordChecksumDisabled = true
+	defer func() { DBConfig.RecordChecksumDisabled = false }()
+	buf.Reset()
+	rec.EncodeToBuffer(&buf)
+	if buf.Len() != kSzHeaderNoChecksum+int(rec.Payload.GetLength()) || buf.Bytes()[0] != kEncVersionNoChecksum {
+		t.Fatalf("unexpected encoding without checksum, size %d", buf.Len())
+	}
+	if err := decoded.Decode(buf.Bytes()); err != nil || string(decoded.Payload.GetData()) != "value" ||
+		decoded.Version != 1 {
+		t.Errorf("decode without checksum failed: %v", err)
+	}
+}
@@ -85,6 +85,9 @@ func DecodeRecordKey(sskey []byte) ([]byte, []byte, error) {
 
 func DecodeRecordKeyNoShardID(storageKey []byte) ([]byte, []byte, error) {
 	szStorageKey := len(storageKey)
+	if szStorageKey != 0 && storageKey[0] == 0 {
+		return nil, nil, ErrHistoryKey
+	}
 	var szNamespace uint8 = storageKey[0]
 	namespace := make([]byte, szNamespace)
 	copy(namespace, storageKey[1:1+szNamespace])
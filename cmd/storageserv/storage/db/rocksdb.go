@@ -211,9 +211,12 @@ func (r *RocksDB) GetRecord(recId RecordID, rec *Record) (exist bool, err error)
 		exist = value.Data() != nil
 		if exist {
 			gerr = rec.DecodeFrom(value)
-			if gerr != nil {
+			if gerr == ErrRecordCorrupted {
+				logCorruptedRecord(recId)
+				exist = false
+			} else if gerr != nil {
 				glog.Error(gerr)
-				err = NewDBError(err)
+				err = NewDBError(gerr)
 			}
 		}
 	} else {
@@ -225,6 +228,15 @@ func (r *RocksDB) GetRecord(recId RecordID, rec *Record) (exist bool, err error)
 	return
 }
 
+// A corrupted record is taken as missing, so that it is repaired from the
+// other zones on read.
+func logCorruptedRecord(recId RecordID) {
+	glog.Errorf("corrupted record, Key: %s", recId)
+	if cal.IsEnabled() {
+		cal.Event(logging.CalMsgTypeDb, "CorruptedRecord", cal.StatusWarning, []byte("key="+recId.String()))
+	}
+}
+
 func (r *RocksDB) LogCalTransaction(startT time.Time, name string, err error) {
 	rht := time.Since(startT)
 	if cal.IsEnabled() {
@@ -271,6 +283,10 @@ func (r *RocksDB) Get(recId RecordID, fetchExpired bool) (*Record, error) {
 
 	rec := new(Record)
 	err = rec.Decode(value.Data())
+	if err == ErrRecordCorrupted {
+		logCorruptedRecord(recId)
+		return nil, nil
+	}
 	if err != nil {
 		return rec, NewDBError(err)
 	}
@@ -477,6 +493,15 @@ func (r *RocksDB) ReplicateSnapshot(shardId shard.ID, rb *redist.Replicator, msh
 	return r.sharding.replicateSnapshot(shardId, rb, mshardid)
 }
 
+// Iterate through a snapshot of the shard. Stop when fn returns false.
+func (r *RocksDB) ForEachInSnapshot(shardId shard.ID, fn func(ns []byte, key []byte, rec *Record) bool) bool {
+	return r.sharding.forEachInSnapshot(shardId, fn)
+}
+
+func (r *RocksDB) GetShards() shard.Map {
+	return r.shards
+}
+
 func sendRedistRep(shardId shard.ID, ns []byte, key []byte, rec *Record, rb *redist.Replicator) (err error) {
 
 	var rowMsg proto.RawMessage
@@ -44,6 +44,8 @@ type IDBSharding interface {
 	duplicate() IDBSharding
 
 	replicateSnapshot(shardId shard.ID, rb *redist.Replicator, mshardid int32) bool
+	forEachInSnapshot(shardId shard.ID, fn func(ns []byte, key []byte, rec *Record) bool) bool
+	checkpoint(dir string) ([]BackupDbInfo, error)
 }
 
 type ShardingBase struct {
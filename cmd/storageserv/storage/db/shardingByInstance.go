@@ -279,6 +279,54 @@ LOOP:
 	return s.waitForFinish(rb)
 }
 
+func (s *ShardingByInstance) forEachInSnapshot(shardId shard.ID, fn func(ns []byte, key []byte, rec *Record) bool) bool {
+	dbInst := s.dbs[shardId]
+	if dbInst == nil {
+		glog.Errorf("no db for shard %d", shardId)
+		return false
+	}
+
+	opts := gorocksdb.NewDefaultReadOptions()
+	snapshot := dbInst.NewSnapshot()
+	defer dbInst.ReleaseSnapshot(snapshot)
+
+	opts.SetSnapshot(snapshot)
+	iter := dbInst.NewIterator(opts)
+	defer iter.Close()
+
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		ns, key, err := s.decodeStorageKey(iter.Key().Data())
+		if err != nil {
+			continue
+		}
+		rec := new(Record)
+		if err = rec.Decode(iter.Value().Data()); err != nil || rec.IsExpired() {
+			continue
+		}
+		if !fn(ns, key, rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ShardingByInstance) checkpoint(dir string) (dbs []BackupDbInfo, err error) {
+	for i, dbInst := range s.dbs {
+		if dbInst == nil {
+			continue
+		}
+		shardId := shard.ID(i)
+		var info BackupDbInfo
+		info, err = checkpointDb(dbInst, fmt.Sprintf("%s-%d.db", s.dbnamePrefix, i), i, dir, 0,
+			func(key []byte) shard.ID { return shardId })
+		if err != nil {
+			return
+		}
+		dbs = append(dbs, info)
+	}
+	return
+}
+
 func (s *ShardingByInstance) decodeStorageKey(sskey []byte) ([]byte, []byte, error) {
 	return DecodeRecordKeyNoShardID(sskey)
 }
@@ -370,7 +370,9 @@ LOOP:
 		}
 
 		ns, key, err := s.decodeStorageKey(iter.Key().Data())
-		if err != nil {
+		if err == ErrHistoryKey {
+			continue LOOP
+		} else if err != nil {
 			msgroup.cnt_err++
 			continue LOOP
 		}
@@ -426,6 +428,41 @@ LOOP:
 	return true
 }
 
+// forEachInSnapshot calls fn for each unexpired record of the shard in a
+// consistent snapshot, until fn returns false.
+func (s *ShardingByPrefix) forEachInSnapshot(shardId shard.ID, fn func(ns []byte, key []byte, rec *Record) bool) bool {
+	numDbs := len(s.dbs)
+	dbInst := s.dbs[int(shardId)%numDbs]
+	if dbInst == nil {
+		glog.Errorf("no db for shard %d", shardId)
+		return false
+	}
+
+	opts := gorocksdb.NewDefaultReadOptions()
+	snapshot := dbInst.NewSnapshot()
+	defer dbInst.ReleaseSnapshot(snapshot)
+
+	opts.SetSnapshot(snapshot)
+	iter := dbInst.NewIterator(opts)
+	defer iter.Close()
+
+	prefix := s.getPrefixKey(shardId)
+	for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+		ns, key, err := s.decodeStorageKey(iter.Key().Data())
+		if err != nil {
+			continue
+		}
+		rec := new(Record)
+		if err = rec.Decode(iter.Value().Data()); err != nil || rec.IsExpired() {
+			continue
+		}
+		if !fn(ns, key, rec) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *ShardingByPrefix) duplicate() IDBSharding {
 	dup := &ShardingByPrefix{}
 	dup.dbnamePrefix = s.dbnamePrefix
@@ -522,6 +559,22 @@ func (s *ShardingByPrefix) CompactRangeByShard(shardId shard.ID) error {
 	return err
 }
 
+func (s *ShardingByPrefix) checkpoint(dir string) (dbs []BackupDbInfo, err error) {
+	for i, dbInst := range s.dbs {
+		if dbInst == nil {
+			continue
+		}
+		var info BackupDbInfo
+		info, err = checkpointDb(dbInst, fmt.Sprintf("%s-%d.db", s.dbnamePrefix, i), i, dir, recordIdPrefixLen(),
+			func(key []byte) shard.ID { id := RecordID(key); return id.GetShardID() })
+		if err != nil {
+			return
+		}
+		dbs = append(dbs, info)
+	}
+	return
+}
+
 func (s *ShardingByPrefix) decodeStorageKey(sskey []byte) ([]byte, []byte, error) {
 	return DecodeRecordKey(sskey)
 }
@@ -21,6 +21,7 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
 
@@ -35,6 +36,20 @@ var (
 	prepareMap []*sync.Map // sharded map
 )
 
+type (
+	// lockInfoT describes the request holding the lock of a record. It is not
+	// changed once set, so that it can be read by the admin requests while
+	// the record is locked.
+	lockInfoT struct {
+		recordId   db.RecordID
+		opCode     proto.OpCode
+		requestId  proto.RequestId
+		originator proto.RequestId
+		appName    string
+		lockTime   time.Time
+	}
+)
+
 func InitializeCMap(numShards int) {
 	prepareMap = make([]*sync.Map, numShards)
 	for i := 0; i < numShards; i++ {
@@ -46,6 +61,14 @@ func acquireLock(p *reqProcCtxT) (owner *reqProcCtxT, success bool) {
 	req := &p.request
 
 	shardId := p.recordId.GetShardID()
+	p.lockInfo.Store(&lockInfoT{
+		recordId:   p.recordId,
+		opCode:     req.GetOpCode(),
+		requestId:  req.GetRequestID(),
+		originator: req.GetOriginatorRequestID(),
+		appName:    string(req.GetAppName()),
+		lockTime:   time.Now(),
+	})
 	recInMap, loaded := prepareMap[shardId].LoadOrStore(string(p.recordId), p)
 	owner = recInMap.(*reqProcCtxT)
 
@@ -114,3 +137,8 @@ func getFromPrepareMap(shardId shard.ID, recId db.RecordID, reqId proto.RequestI
 	}
 	return
 }
+
+func (p *reqProcCtxT) getLockInfo() *lockInfoT {
+	info, _ := p.lockInfo.Load().(*lockInfoT)
+	return info
+}
@@ -0,0 +1,201 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/cmd/storageserv/config"
+	ssstats "github.com/paypal/junodb/cmd/storageserv/stats"
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/logging"
+	"github.com/paypal/junodb/pkg/logging/cal"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/shard"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+type (
+	// lockEntry is a locked record listed by /admin/locks.
+	lockEntry struct {
+		ShardId    shard.ID
+		Namespace  string
+		Key        string
+		HexKey     string
+		OpCode     string
+		Prepared   bool // waiting for the commit or the abort
+		RequestId  string
+		Originator string `json:",omitempty"`
+		AppName    string `json:",omitempty"`
+		LockTime   time.Time
+		Age        string
+	}
+)
+
+func registerAdminHandlers() {
+	ssstats.HttpServerMux.HandleFunc("/admin/locks", httpLocksHandler)
+	ssstats.HttpServerMux.HandleFunc("/admin/locks/abort", httpForceAbortHandler)
+}
+
+func isPrepare(op proto.OpCode) bool {
+	switch op {
+	case proto.OpCodePrepareCreate, proto.OpCodePrepareUpdate, proto.OpCodePrepareSet, proto.OpCodePrepareDelete:
+		return true
+	}
+	return false
+}
+
+// listLocks returns the records locked for longer than minAge, in the
+// namespace if not empty.
+func listLocks(ns string, minAge time.Duration) (entries []lockEntry) {
+	now := time.Now()
+	for shardId, m := range prepareMap {
+		m.Range(func(k, v interface{}) bool {
+			info := v.(*reqProcCtxT).getLockInfo()
+			if info == nil || now.Sub(info.lockTime) < minAge {
+				return true
+			}
+			recNs, key, err := db.DecodeRecordKey(info.recordId)
+			if err != nil || (len(ns) != 0 && string(recNs) != ns) {
+				return true
+			}
+			e := lockEntry{
+				ShardId:   shard.ID(shardId),
+				Namespace: string(recNs),
+				Key:       util.ToPrintableAndHexString(key),
+				HexKey:    hex.EncodeToString(key),
+				OpCode:    info.opCode.String(),
+				Prepared:  isPrepare(info.opCode),
+				RequestId: info.requestId.String(),
+				AppName:   info.appName,
+				LockTime:  info.lockTime,
+				Age:       now.Sub(info.lockTime).String(),
+			}
+			if info.originator.IsSet() {
+				e.Originator = info.originator.String()
+			}
+			entries = append(entries, e)
+			return true
+		})
+	}
+	return
+}
+
+// forceAbort aborts the prepared request holding the lock of the record, as
+// if it had timed out. Nothing is aborted if the request id does not match,
+// so that a later request locking the record is not affected.
+func forceAbort(recordId db.RecordID, rid proto.RequestId) error {
+	v, ok := prepareMap[recordId.GetShardID()].Load(string(recordId))
+	if !ok {
+		return errors.New("record not locked")
+	}
+	owner := v.(*reqProcCtxT)
+	info := owner.getLockInfo()
+	if info == nil || !info.requestId.Equal(rid) {
+		return errors.New("record locked by another request")
+	}
+	if !isPrepare(info.opCode) {
+		return fmt.Errorf("%s is not a prepared request", info.opCode)
+	}
+	if owner.chReq == nil || owner.timer.IsStopped() {
+		return errors.New("not waiting for commit or abort")
+	}
+	select {
+	case owner.chReq <- nil:
+	default:
+		return errors.New("commit or abort in progress")
+	}
+	glog.Warningf("force abort. recId=%s,rid=%s,age=%s", recordId, rid, time.Since(info.lockTime))
+	if cal.IsEnabled() {
+		b := logging.NewKVBuffer()
+		b.AddRequestID(rid).Add([]byte("recId"), recordId.String())
+		cal.Event(kCalMsgTypeReqProc, "force_abort", cal.StatusWarning, b.Bytes())
+	}
+	return nil
+}
+
+// httpLocksHandler lists the locked records, with the "ns" and the "minage"
+// (e.g. 1s) query parameters as optional filters.
+func httpLocksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var minAge time.Duration
+	if s := query.Get("minage"); len(s) != 0 {
+		var err error
+		if minAge, err = time.ParseDuration(s); err != nil {
+			http.Error(w, "invalid minage", http.StatusBadRequest)
+			return
+		}
+	}
+	entries := listLocks(query.Get("ns"), minAge)
+	if entries == nil {
+		entries = []lockEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(entries)
+}
+
+// httpForceAbortHandler aborts the prepared request given by the "rid" query
+// parameter, locking the record of the "ns" and the "key" or "hexkey" query
+// parameters. It returns 404 if the shard of the record is not on the worker.
+func httpForceAbortHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	ns := query.Get("ns")
+	key := []byte(query.Get("key"))
+	if hexKey := query.Get("hexkey"); len(hexKey) != 0 {
+		var err error
+		if key, err = hex.DecodeString(hexKey); err != nil {
+			http.Error(w, "invalid hexkey", http.StatusBadRequest)
+			return
+		}
+	}
+	var rid proto.RequestId
+	if len(ns) == 0 || len(key) == 0 || rid.SetFromString(query.Get("rid")) != nil {
+		http.Error(w, "ns, key and rid are required", http.StatusBadRequest)
+		return
+	}
+
+	cfg := config.ServerConfig()
+	shardId, microShardId := util.GetShardIds(key, cfg.ClusterInfo.NumShards, cfg.NumMicroShards)
+	if !db.GetDB().ShardSupported(shard.ID(shardId)) {
+		http.Error(w, fmt.Sprintf("shard %d not on the worker", shardId), http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	recordId := db.NewRecordIDWithBuffer(&buf, shard.ID(shardId), microShardId, []byte(ns), key)
+	if err := forceAbort(recordId, rid); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	fmt.Fprintf(w, "aborted %s\n", rid)
+}
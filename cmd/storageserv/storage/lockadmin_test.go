@@ -0,0 +1,169 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/cmd/storageserv/storage/db"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/shard"
+)
+
+func newTestPrepareRequest(op proto.OpCode, ns []byte, key []byte) *proto.OperationalMessage {
+	req := &proto.OperationalMessage{}
+	payload := &proto.Payload{}
+	payload.SetWithClearValue(testValue)
+	req.SetRequest(op, key, ns, payload, testDefaultTTL)
+	req.SetNewRequestID()
+	req.SetCreationTime(uint32(time.Now().Unix()))
+	return req
+}
+
+func newTestRecordID(ns []byte, key []byte) db.RecordID {
+	var buf bytes.Buffer
+	return db.NewRecordIDWithBuffer(&buf, shard.ID(0), 0, ns, key)
+}
+
+// lockRecord locks the record for the request, locked since lockTime, without
+// waiting for the phase II request as processTwoPC does.
+func lockRecord(t *testing.T, req *proto.OperationalMessage, lockTime time.Time) *reqProcCtxT {
+	t.Helper()
+	p := &reqProcCtxT{}
+	p.init()
+	p.request = *req
+	p.recordId = newTestRecordID(req.GetNamespace(), req.GetKey())
+	if _, ok := acquireLock(p); !ok {
+		t.Fatalf("cannot lock %s", p.recordId)
+	}
+	info := *p.getLockInfo()
+	info.lockTime = lockTime
+	p.lockInfo.Store(&info)
+	return p
+}
+
+func isLocked(recId db.RecordID) bool {
+	_, ok := prepareMap[recId.GetShardID()].Load(string(recId))
+	return ok
+}
+
+func TestForceAbort(t *testing.T) {
+	key := []byte("forceabort")
+	recId := newTestRecordID(testNamespace, key)
+	req := newTestPrepareRequest(proto.OpCodePrepareCreate, testNamespace, key)
+	resp, _ := processRequest(req)
+	expectStatus(t, resp, proto.OpStatusNoError)
+	if !isLocked(recId) {
+		t.Fatal("expect the record locked by the prepare")
+	}
+
+	var other proto.RequestId
+	other.SetNewRequestId()
+	if err := forceAbort(recId, other); err == nil || !strings.Contains(err.Error(), "another request") {
+		t.Errorf("expect a request id mismatch, got %v", err)
+	}
+	if !isLocked(recId) {
+		t.Fatal("expect the record still locked")
+	}
+
+	// delivered to processTwoPC waiting for the phase II request
+	if err := forceAbort(recId, req.GetRequestID()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && isLocked(recId); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if isLocked(recId) {
+		t.Fatal("expect the lock released")
+	}
+	if err := forceAbort(recId, req.GetRequestID()); err == nil || !strings.Contains(err.Error(), "not locked") {
+		t.Errorf("expect the record not locked, got %v", err)
+	}
+}
+
+func TestForceAbortNotPrepared(t *testing.T) {
+	for _, tc := range []struct {
+		op  proto.OpCode
+		err string
+	}{
+		{proto.OpCodeCreate, "not a prepared request"},
+		{proto.OpCodePrepareUpdate, "not waiting"}, // not in processTwoPC
+	} {
+		req := newTestPrepareRequest(tc.op, testNamespace, []byte("notprepared"))
+		p := lockRecord(t, req, time.Now())
+		err := forceAbort(p.recordId, req.GetRequestID())
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expect %q, got %v", tc.op, tc.err, err)
+		}
+		if !isLocked(p.recordId) {
+			t.Errorf("%s: expect the record still locked", tc.op)
+		}
+		releaseLock(p)
+	}
+}
+
+func TestListLocks(t *testing.T) {
+	now := time.Now()
+	old := lockRecord(t, newTestPrepareRequest(proto.OpCodePrepareSet, []byte("lockns1"), []byte("k1")), now.Add(-10*time.Second))
+	recent := lockRecord(t, newTestPrepareRequest(proto.OpCodePrepareDelete, []byte("lockns2"), []byte("k2")), now)
+	defer releaseLock(old)
+	defer releaseLock(recent)
+
+	for _, tc := range []struct {
+		ns     string
+		minAge time.Duration
+		keys   string
+	}{
+		{"", 0, "k1,k2"},
+		{"lockns1", 0, "k1"},
+		{"lockns2", 0, "k2"},
+		{"lockns3", 0, ""},
+		{"", 5 * time.Second, "k1"},
+		{"lockns2", 5 * time.Second, ""},
+	} {
+		var keys []string
+		for _, e := range listLocks(tc.ns, tc.minAge) {
+			// skip the locks of the other tests
+			if strings.HasPrefix(e.Namespace, "lockns") {
+				key, _ := hex.DecodeString(e.HexKey)
+				keys = append(keys, string(key))
+			}
+		}
+		sort.Strings(keys)
+		if strings.Join(keys, ",") != tc.keys {
+			t.Errorf("ns=%q minage=%s: expect %s, got %v", tc.ns, tc.minAge, tc.keys, keys)
+		}
+	}
+
+	entries := listLocks("lockns1", 0)
+	if len(entries) != 1 {
+		t.Fatalf("expect 1 lock, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.OpCode != proto.OpCodePrepareSet.String() || !e.Prepared ||
+		e.RequestId != old.request.GetRequestID().String() || !e.LockTime.Equal(now.Add(-10*time.Second)) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	goio "io"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -39,6 +40,7 @@ import (
 	"github.com/paypal/junodb/pkg/proto"
 	"github.com/paypal/junodb/pkg/shard"
 	"github.com/paypal/junodb/pkg/stats"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
 	"github.com/paypal/junodb/pkg/util"
 )
 
@@ -66,6 +68,7 @@ type (
 		timer      *util.TimerWrapper
 		chReq      chan *reqProcCtxT
 		encodeBuf  bytes.Buffer
+		lockInfo   atomic.Value // *lockInfoT
 	}
 	ReqProcCtxPool util.ChanPool
 )
@@ -122,6 +125,11 @@ func (p *reqProcCtxT) OnComplete() {
 	}
 
 	otel.RecordOperation(opcode.String(), p.response.GetOpStatus(), int64(rhtus))
+	hotkey.Record(p.request.GetNamespace(), p.request.GetKey(), opcode,
+		int(p.request.GetPayloadValueLength()+p.response.GetPayloadValueLength()))
+	if p.response.GetOpStatus() == proto.OpStatusRecordLocked {
+		ssstats.OnLockConflict()
+	}
 
 	if p.cacheable {
 		if p.prepareCtx != nil {
@@ -372,7 +380,7 @@ func (p *reqProcCtxT) initResponse(opStatus proto.OpStatus, version uint32, expT
 			resp.SetTimeToLive(existingRemainingTTL)
 		}
 
-	case proto.OpCodeRead:
+	case proto.OpCodeRead, proto.OpCodeReadExists, proto.OpCodeReadMeta, proto.OpCodeSetTTL:
 		resp.SetTimeToLive(existingRemainingTTL)
 	}
 
@@ -38,7 +38,7 @@ func testSetup() {
 
 	config.ServerConfig().DB.DbPaths = []db.DbPath{
 		db.DbPath{"./test.db", 0}}
-	db.Initialize(1, 1, 0, 0, 0, 0, shardMap)
+	db.Initialize(1, 1, 0, 0, 0, 0, shardMap, 0)
 	InitializeCMap(1)
 	//	Setup()
 }
@@ -32,6 +32,7 @@ import (
 	"github.com/paypal/junodb/cmd/dbscanserv/patch"
 	"github.com/paypal/junodb/cmd/storageserv/config"
 	"github.com/paypal/junodb/cmd/storageserv/redist"
+	ssstats "github.com/paypal/junodb/cmd/storageserv/stats"
 	"github.com/paypal/junodb/cmd/storageserv/storage/db"
 	"github.com/paypal/junodb/cmd/storageserv/watcher"
 	"github.com/paypal/junodb/pkg/cluster"
@@ -92,6 +93,7 @@ func initialize(zoneId int, machineId int, lruCacheSizeInMB int) {
 		db.Initialize(int(cfg.ClusterInfo.NumShards), int(cfg.NumMicroShards),
 			int(cfg.NumMicroShardGroups), int(cfg.NumPrefixDbs),
 			zoneId, machineId, shardMap, lruCacheSizeInMB)
+		registerAdminHandlers()
 
 		glog.Infof("storage engine initialized")
 	})
@@ -146,6 +148,14 @@ func validate(r *proto.OperationalMessage) bool {
 		}
 		return false
 	}
+
+	if opcode == proto.OpCodeSetTTL && r.GetExpirationTime() == 0 {
+		glog.Error("Bad Param: 0 TTL")
+		if cal.IsEnabled() {
+			cal.Event(kCalMsgTypeReqProc, "BadParam_0TTL_for_setttl", cal.StatusSuccess, nil)
+		}
+		return false
+	}
 	///TODO to enable later
 	//	if r.IsForReplication() {
 	//		if len(r.GetOriginatorRequestID()) == 0 {
@@ -214,6 +224,10 @@ func process(p *reqProcCtxT) {
 		forwardAbort(p)
 	case proto.OpCodeRead:
 		read(p)
+	case proto.OpCodeReadExists, proto.OpCodeReadMeta:
+		readMeta(p)
+	case proto.OpCodeSetTTL:
+		setTTL(p)
 	case proto.OpCodeDelete:
 		processDelete(p)
 	case proto.OpCodeRepair:
@@ -247,6 +261,11 @@ func read(p *reqProcCtxT) {
 		return
 	}
 
+	if p.request.IsHistoryRead() {
+		readHistory(p, rec, exist)
+		return
+	}
+
 	// NoKey
 	if !exist || rec.IsExpired() {
 		p.replyWithErrorOpStatus(proto.OpStatusNoKey)
@@ -284,6 +303,108 @@ func read(p *reqProcCtxT) {
 	return
 }
 
+// readHistory replies with the latest version of the record matching the
+// version and the as of time of the request, out of the record and its
+// history. The TTL is not extended.
+func readHistory(p *reqProcCtxT, cur *db.Record, exist bool) {
+	recs, err := db.GetDB().GetHistory(p.recordId)
+	if err != nil {
+		p.replyWithErrorOpStatus(proto.OpStatusSSError)
+		return
+	}
+	if exist && !cur.IsExpired() {
+		recs = append(recs, cur)
+	}
+
+	rec := db.SelectHistory(recs, p.request.GetReadVersion(), p.request.GetAsOfTime())
+	if rec == nil {
+		p.replyWithErrorOpStatus(proto.OpStatusNoKey)
+		return
+	}
+
+	status := proto.OpStatusNoError
+	if rec.IsMarkedDelete() {
+		status = proto.OpStatusKeyMarkedDelete
+	}
+	p.initResponse(status, rec.Version, rec.ExpirationTime, rec.CreationTime)
+	p.response.SetPayload(&rec.Payload)
+	p.response.SetOriginatorRequestID(rec.OriginatorRequestId)
+	p.response.SetLastModificationTime(rec.LastModificationTime)
+	p.reply()
+}
+
+// readMeta replies with the meta of the record without the payload. The
+// value size is included for ReadMeta. The TTL is not extended.
+func readMeta(p *reqProcCtxT) {
+	rec := &db.Record{}
+	defer rec.ResetRecord()
+
+	exist, err := db.GetDB().GetRecord(p.recordId, rec)
+	if err != nil {
+		p.replyWithErrorOpStatus(proto.OpStatusSSError)
+		return
+	}
+	if !exist || rec.IsExpired() {
+		p.replyWithErrorOpStatus(proto.OpStatusNoKey)
+		return
+	}
+
+	status := proto.OpStatusNoError
+	if rec.IsMarkedDelete() {
+		status = proto.OpStatusKeyMarkedDelete
+	}
+	p.initResponse(status, rec.Version, rec.ExpirationTime, rec.CreationTime)
+	p.response.SetOriginatorRequestID(rec.OriginatorRequestId)
+	p.response.SetLastModificationTime(rec.LastModificationTime)
+	if p.request.GetOpCode() == proto.OpCodeReadMeta {
+		p.response.SetValueSize(rec.Payload.GetValueLength())
+	}
+	p.reply()
+}
+
+// SetTTL: one phase operation
+// Replaces the expiration time of the record. The version and the last
+// modification time are kept.
+func setTTL(p *reqProcCtxT) {
+	pdata, ok := acquireLock(p)
+	if !ok || pdata != p {
+		p.replyWithErrorOpStatus(proto.OpStatusRecordLocked)
+		return
+	}
+
+	rec := &db.Record{}
+	defer rec.ResetRecord()
+
+	exist, err := db.GetDB().GetRecord(p.recordId, rec)
+	if err != nil {
+		releaseLock(pdata)
+		p.replyWithErrorOpStatus(proto.OpStatusSSError)
+		return
+	}
+	if !exist || rec.IsExpired() {
+		releaseLock(pdata)
+		p.replyWithErrorOpStatus(proto.OpStatusNoKey)
+		return
+	}
+
+	status := proto.OpStatusNoError
+	if rec.IsMarkedDelete() {
+		status = proto.OpStatusKeyMarkedDelete
+	} else {
+		rec.ExpirationTime = p.request.GetExpirationTime()
+		if err := dbPutWrapper(p, rec); err != nil {
+			releaseLock(pdata)
+			p.replyWithErrorOpStatus(proto.OpStatusSSError)
+			return
+		}
+	}
+	releaseLock(pdata)
+	p.initResponse(status, rec.Version, rec.ExpirationTime, rec.CreationTime)
+	p.response.SetOriginatorRequestID(rec.OriginatorRequestId)
+	p.response.SetLastModificationTime(rec.LastModificationTime)
+	p.reply()
+}
+
 // Repair: one phase operation
 func repair(p *reqProcCtxT) {
 	request := &p.request
@@ -304,6 +425,7 @@ func repair(p *reqProcCtxT) {
 			CreationTime:         request.GetCreationTime(),
 			OriginatorRequestId:  request.GetOriginatorRequestID(),
 			LastModificationTime: request.GetLastModificationTime(),
+			OriginClusterId:      uint16(request.GetOriginClusterId()),
 		},
 		Payload: *request.GetPayload(),
 	}
@@ -348,6 +470,7 @@ func clone(p *reqProcCtxT) {
 			CreationTime:         request.GetCreationTime(),
 			LastModificationTime: request.GetLastModificationTime(),
 			OriginatorRequestId:  request.GetOriginatorRequestID(),
+			OriginClusterId:      uint16(request.GetOriginClusterId()),
 		},
 		Payload: *request.GetPayload(),
 	}
@@ -523,6 +646,7 @@ func processTwoPC(p *reqProcCtxT) {
 	}
 	// wait for phase II request
 	var p2reqctx *reqProcCtxT
+	timedOut := false
 	select {
 	case <-pdata.timer.GetTimeoutCh():
 		if glog.LOG_VERBOSE {
@@ -532,6 +656,7 @@ func processTwoPC(p *reqProcCtxT) {
 		case p2reqctx, ok = <-pdata.chReq:
 		default:
 			// no msg
+			timedOut = true
 		}
 
 	case p2reqctx, ok = <-pdata.chReq:
@@ -540,6 +665,9 @@ func processTwoPC(p *reqProcCtxT) {
 		}
 		pdata.timer.Stop()
 	}
+	if info := pdata.getLockInfo(); info != nil {
+		ssstats.OnPreparedLockReleased(time.Since(info.lockTime), timedOut)
+	}
 
 	if !ok || p2reqctx == nil {
 		// timed out or channel closed
@@ -638,6 +766,12 @@ func prepareSet(p *reqProcCtxT) (st proto.OpStatus) {
 			p.reply()
 			return
 		}
+		if isFromActivePeer(&p.request) && isConflict(&p.request, rec) {
+			st = proto.OpStatusVersionConflict
+			p.initResponse(st, rec.Version, rec.ExpirationTime, rec.CreationTime)
+			p.reply()
+			return
+		}
 		if rec.IsMarkedDelete() {
 			versionToReturn = rec.Version
 			st = proto.OpStatusInserting
@@ -712,6 +846,13 @@ func prepareCUpdate(p *reqProcCtxT) (st proto.OpStatus) {
 	if p.request.IsForReplication() {
 		if p.dbRecExist {
 			if rec.IsMarkedDelete() {
+				if isFromActivePeer(&p.request) && isConflict(&p.request, rec) {
+					// the delete happened later
+					st = proto.OpStatusVersionConflict
+					p.initResponse(st, rec.Version, rec.ExpirationTime, rec.CreationTime)
+					p.reply()
+					return
+				}
 				p.dbRecExist = false
 				p.dbRec.ResetRecord()
 				st = proto.OpStatusInserting
@@ -921,7 +1062,8 @@ func markDelete(p *reqProcCtxT) {
 			return
 		} else {
 			rec.MarkDelete()
-			rec.LastModificationTime = uint64(time.Now().UnixNano())
+			rec.LastModificationTime = tombstoneModificationTime(req)
+			rec.OriginClusterId = uint16(req.GetOriginClusterId())
 			if req.GetVersion() != 0 {
 				rec.Version = req.GetVersion()
 			} else {
@@ -955,7 +1097,8 @@ func markDelete(p *reqProcCtxT) {
 		}
 		rec.RequestId = req.GetRequestID()
 		rec.ExpirationTime = util.GetExpirationTime(req.GetTimeToLive())
-		rec.LastModificationTime = uint64(time.Now().UnixNano())
+		rec.LastModificationTime = tombstoneModificationTime(req)
+		rec.OriginClusterId = uint16(req.GetOriginClusterId())
 		rec.MarkDelete()
 		if err = dbPutWrapper(p, rec); err != nil {
 			releaseLock(pdata)
@@ -994,12 +1137,14 @@ func doMarkDeleteAndReleaseLock(p *reqProcCtxT, prepare *reqProcCtxT) {
 				ExpirationTime:       req.GetExpirationTime(),
 				OriginatorRequestId:  req.GetOriginatorRequestID(),
 				RequestId:            req.GetRequestID(),
+				OriginClusterId:      uint16(req.GetOriginClusterId()),
 			},
 		}
 	} else {
 		rec.RequestId = req.GetRequestID()
 		rec.Version++
-		rec.LastModificationTime = uint64(time.Now().UnixNano())
+		rec.LastModificationTime = tombstoneModificationTime(req)
+		rec.OriginClusterId = uint16(req.GetOriginClusterId())
 		rec.Payload.Clear()
 	}
 
@@ -1058,6 +1203,7 @@ func doCommitAndReleaselock(p *reqProcCtxT, prepare *reqProcCtxT) {
 	rec.CreationTime = req.GetCreationTime()
 	rec.Version = req.GetVersion()
 	rec.LastModificationTime = req.GetLastModificationTime()
+	rec.OriginClusterId = uint16(req.GetOriginClusterId())
 	rec.Payload.Set(prepare.request.GetPayload())
 	//rec.ExpirationTime = d.request.GetExpirationTime()
 	//rec.RequestId = d.request.GetRequestID()
@@ -1129,7 +1275,15 @@ func TruncateExpired() {
 func isConflict(request *proto.OperationalMessage, rec *db.Record) (conflict bool) {
 	lmt := request.GetLastModificationTime()
 	if lmt != 0 {
-		return lmt < rec.LastModificationTime
+		if lmt != rec.LastModificationTime || !request.IsOriginClusterIdSet() {
+			return lmt < rec.LastModificationTime
+		}
+		// Active-active: break the tie the same way on both sides, by version
+		// and then by origin cluster id.
+		if ver := request.GetVersion(); ver != rec.Version {
+			return ver < rec.Version
+		}
+		return uint16(request.GetOriginClusterId()) < rec.OriginClusterId
 	}
 	// for conflict checking, last modification time should be sufficient.
 	// the following code is for handling mayfly request, which does not have modification time
@@ -1165,6 +1319,22 @@ func isConflict(request *proto.OperationalMessage, rec *db.Record) (conflict boo
 	return false
 }
 
+// isFromActivePeer returns true for the replication requests from a peer
+// cluster in active-active mode, which are resolved with last-writer-wins.
+func isFromActivePeer(request *proto.OperationalMessage) bool {
+	return request.IsForReplication() && request.IsOriginClusterIdSet()
+}
+
+// tombstoneModificationTime keeps the modification time stamped by the proxy
+// in active-active mode, so that a tombstone is ordered against concurrent
+// updates by when the delete happened rather than when it was applied.
+func tombstoneModificationTime(request *proto.OperationalMessage) uint64 {
+	if request.IsOriginClusterIdSet() && request.GetLastModificationTime() != 0 {
+		return request.GetLastModificationTime()
+	}
+	return uint64(time.Now().UnixNano())
+}
+
 func forwardRequest(p2 *reqProcCtxT, prepare *reqProcCtxT) error {
 	if prepare.chReq == nil {
 		return errors.New("missing twopc info")
@@ -1200,6 +1370,10 @@ func dbPutWrapper(p *reqProcCtxT, rec *db.Record) (err error) {
 		return
 	}
 
+	// changing the TTL keeps the version
+	if op := p.request.GetOpCode(); op != proto.OpCodeRead && op != proto.OpCodeSetTTL {
+		saveHistory(p.request.GetNamespace(), p.recordId, nil)
+	}
 	//util.HexDump(p.encodeBuf.Bytes())
 	err = db.GetDB().Put(p.recordId, p.encodeBuf.Bytes())
 	//	pool.Put(buf)
@@ -1226,6 +1400,12 @@ func dbPutWrapper(p *reqProcCtxT, rec *db.Record) (err error) {
 func dbDeleteRecord(request *proto.OperationalMessage, shardId shard.ID,
 	recordId db.RecordID, rec *db.Record) (err error) {
 
+	if rec != nil {
+		tombstone := rec.RecordHeader
+		tombstone.LastModificationTime = tombstoneModificationTime(request)
+		tombstone.RequestId = request.GetRequestID()
+		saveHistory(request.GetNamespace(), recordId, &tombstone)
+	}
 	err = db.GetDB().Delete(recordId)
 
 	if err != nil || redist.IsEnabled() == false || rec == nil {
@@ -1255,6 +1435,19 @@ func dbDeleteRecord(request *proto.OperationalMessage, shardId shard.ID,
 	return
 }
 
+// saveHistory keeps the current version of the record before it is replaced
+// or deleted, if the history is enabled for the namespace. A failure does not
+// fail the write.
+func saveHistory(namespace []byte, recordId db.RecordID, tombstone *db.RecordHeader) {
+	cfg, ok := config.ServerConfig().History[string(namespace)]
+	if !ok || !cfg.Enabled() {
+		return
+	}
+	if err := db.GetDB().SaveHistory(recordId, tombstone, cfg.MaxVersions, cfg.MaxAge.Duration); err != nil {
+		glog.Warningf("failed to save history. Key: %s, %s", recordId, err)
+	}
+}
+
 func ReplicateSnapshot(shardId shard.ID, rb *redist.Replicator, mshardid int32) bool {
 	return db.GetDB().ReplicateSnapshot(shardId, rb, mshardid)
 }
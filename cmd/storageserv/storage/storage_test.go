@@ -129,6 +129,9 @@ func (r *testInboundReqCtxT) Cleanup()
 func (r *testInboundReqCtxT) GetReceiveTime() time.Time                                { return time.Now() }
 func (r *testInboundReqCtxT) SetTimeout(parent context.Context, timeout time.Duration) {}
 func (r *testInboundReqCtxT) Deadline() (deadline time.Time)                           { return time.Time{} }
+func (r *testInboundReqCtxT) ResetDeadline()                                           {}
+func (r *testInboundReqCtxT) SetQueTimeout(t time.Duration)                            {}
+func (r *testInboundReqCtxT) GetQueTimeout() time.Duration                             { return 0 }
 
 func dbStoreValidate(req *proto.OperationalMessage) bool {
 	//validate() modifies the expiration time now, so make a copy here
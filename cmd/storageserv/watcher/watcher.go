@@ -45,6 +45,9 @@ import (
 //    - for target, the additional shards for redisbution will be read and updated
 //    - for source, it will start to transfer the snapshots corresponding to the shards to the new target
 //    -             and forward real time requests too
+//
+// 3) replication target bootstrap: rep_bootstrap
+//    the storage nodes in the source zone stream their snapshot to the target
 
 type IWatchEvtHandler interface {
 	UpdateShards(shards shard.Map) bool
@@ -54,6 +57,9 @@ type IWatchEvtHandler interface {
 	RedistResume(ratelimit int)
 	RedistStop()
 	RedistIsInProgress() bool
+
+	RepBootstrapStart(spec *etcd.RepBootstrapSpec)
+	RepBootstrapStop(target string)
 }
 
 type Watcher struct {
@@ -146,10 +152,20 @@ func (w *Watcher) Restart() {
 	w.processRedistByState(state, true)
 }
 
+// called during SS start up to resume an unfinished bootstrap.
+func (w *Watcher) restartRepBootstrap() {
+	value, err := w.etcdcli.GetValue(etcd.TagRepBootstrap)
+	if err != nil {
+		return
+	}
+	w.processRepBootstrap([]byte(value))
+}
+
 // run in a seperate go routine
 func (w *Watcher) Watch() (cancel context.CancelFunc, err error) {
 	// if needed
 	w.Restart()
+	w.restartRepBootstrap()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	chShardMap, err := w.etcdcli.WatchEvt(etcd.TagVersion, ctx)
@@ -160,6 +176,10 @@ func (w *Watcher) Watch() (cancel context.CancelFunc, err error) {
 	if err != nil {
 		return
 	}
+	chBootstrap, err := w.etcdcli.WatchEvt(etcd.TagRepBootstrap, ctx)
+	if err != nil {
+		return
+	}
 
 	glog.Infof("start %s go routine", w.name)
 	for {
@@ -170,6 +190,13 @@ func (w *Watcher) Watch() (cancel context.CancelFunc, err error) {
 					w.onRedistEvent(ev)
 				}
 			}
+		case b := <-chBootstrap:
+			for _, ev := range b.Events {
+				if ev.Type != clientv3.EventTypeDelete {
+					glog.Infof("%s bootstrap evt: %s", w.name, string(ev.Kv.Value))
+					w.processRepBootstrap(ev.Kv.Value)
+				}
+			}
 		case m := <-chShardMap:
 			for _, ev := range m.Events {
 				if ev.Type != clientv3.EventTypeDelete {
@@ -252,6 +279,24 @@ func (w *Watcher) processRedistByState(state []byte, restart bool) {
 	}
 }
 
+func (w *Watcher) processRepBootstrap(value []byte) {
+	spec, err := etcd.ParseRepBootstrapSpec(string(value))
+	if err != nil {
+		glog.Errorf("%s bad bootstrap spec: %s", w.name, err.Error())
+		return
+	}
+	if w.hdr == nil {
+		return
+	}
+	if spec.State == etcd.TagRepBootstrapBegin {
+		if spec.Zone == int(w.zoneid) {
+			w.hdr.RepBootstrapStart(spec)
+		}
+	} else {
+		w.hdr.RepBootstrapStop(spec.Target)
+	}
+}
+
 func (w *Watcher) onShardMapEvent(e *clientv3.Event) {
 	if ver, err := strconv.Atoi(string(e.Kv.Value)); err != nil {
 		glog.Errorf("fail to convert event value to int. %s", err.Error())
@@ -110,6 +110,16 @@ type (
 	cmdDestroyT struct {
 		clientCommandT
 	}
+	cmdExistsT struct {
+		clientCommandT
+	}
+	cmdGetMetaT struct {
+		clientCommandT
+	}
+	cmdTouchT struct {
+		clientCommandT
+		ttl uint
+	}
 	cmdPopulateT struct {
 		cmd.Command
 		client.Config
@@ -329,6 +339,52 @@ func (c *cmdDestroyT) Exec() {
 	}
 }
 
+func (c *cmdExistsT) Exec() {
+	c.Validate()
+
+	if cli, err := client.New(c.Config); err == nil {
+		exists, err := cli.Exists(c.key)
+		if c.isOk(err) {
+			fmt.Printf("Exists: %t\n", exists)
+		}
+	} else {
+		fmt.Println(err)
+	}
+}
+
+func (c *cmdGetMetaT) Exec() {
+	c.Validate()
+
+	if cli, err := client.New(c.Config); err == nil {
+		meta, err := cli.GetMeta(c.key)
+		if c.isOk(err) {
+			meta.PrettyPrint(os.Stdout)
+			fmt.Printf("ExpirationTime: %d\nLastModificationTime: %d\nValueSize: %d\n",
+				meta.GetExpirationTime(), meta.GetLastModificationTime(), meta.GetValueSize())
+		}
+	} else {
+		fmt.Println(err)
+	}
+}
+
+func (c *cmdTouchT) Init(name string, desc string) {
+	c.clientCommandT.Init(name, desc)
+	c.UintOption(&c.ttl, "ttl", kDefaultTimeToLive, "specify TTL in second")
+}
+
+func (c *cmdTouchT) Exec() {
+	c.Validate()
+
+	if cli, err := client.New(c.Config); err == nil {
+		rec, err := cli.Touch(c.key, uint32(c.ttl))
+		if c.isOk(err) {
+			rec.PrettyPrint(os.Stdout)
+		}
+	} else {
+		fmt.Println(err)
+	}
+}
+
 func (c *cmdUDFGetT) Exec() {
 	c.Validate()
 
@@ -463,6 +519,15 @@ func init() {
 	destroy := &cmdDestroyT{}
 	destroy.Init("destroy", "destroy a record")
 
+	exists := &cmdExistsT{}
+	exists.Init("exists", "check if a record exists")
+
+	getMeta := &cmdGetMetaT{}
+	getMeta.Init("getmeta", "get the metadata of a record without its value")
+
+	touch := &cmdTouchT{}
+	touch.Init("touch", "replace the TTL of a record")
+
 	udfget := &cmdUDFGetT{}
 	udfget.Init("udfget", "udf get")
 
@@ -472,7 +537,7 @@ func init() {
 	populate := &cmdPopulateT{}
 	populate.Init("populate", "populate a set of records with set commands")
 
-	cmd.RegisterNewGroup("proxy commands", create, get, update, set, destroy, udfget, udfset, populate)
+	cmd.RegisterNewGroup("proxy commands", create, get, update, set, destroy, exists, getMeta, touch, udfget, udfset, populate)
 
 	pCreate := &cmdPrepareCreateT{}
 	pCreate.Init("pcreate", "PrepareCreate to storage server")
@@ -507,5 +572,11 @@ func init() {
 	populatess := &cmdPopulateSST{}
 	populatess.Init("populatess", "populate storage with a set of repair commands")
 
-	cmd.RegisterNewGroup("storage commands", pCreate, read, pUpdate, pSet, del, twoPhaseDel, commit, abort, repair, markDelete, populatess)
+	locks := &cmdLocksT{}
+	locks.Init("locks", "list the locked records of storage server")
+
+	forceAbort := &cmdForceAbortT{}
+	forceAbort.Init("fabort", "force abort the prepare holding the lock of a record")
+
+	cmd.RegisterNewGroup("storage commands", pCreate, read, pUpdate, pSet, del, twoPhaseDel, commit, abort, repair, markDelete, populatess, locks, forceAbort)
 }
@@ -0,0 +1,170 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paypal/junodb/pkg/cmd"
+)
+
+type (
+	// lockEntryT mirrors the entries returned by the storage server /admin/locks.
+	lockEntryT struct {
+		ShardId    uint16
+		Namespace  string
+		Key        string
+		HexKey     string
+		OpCode     string
+		Prepared   bool
+		RequestId  string
+		Originator string
+		AppName    string
+		LockTime   time.Time
+		Age        string
+	}
+
+	cmdLocksT struct {
+		cmd.Command
+		monAddr   string
+		namespace string
+		minAge    string
+	}
+
+	cmdForceAbortT struct {
+		cmd.Command
+		monAddr   string
+		namespace string
+		keyType   uint
+		key       []byte
+		rid       string
+	}
+)
+
+const kAdminHttpTimeout = 5 * time.Second
+
+func (c *cmdLocksT) Init(name string, desc string) {
+	c.Command.Init(name, desc)
+	c.StringOption(&c.monAddr, "s|server", "127.0.0.1:8089", "specify storage server http monitoring address")
+	c.StringOption(&c.namespace, "ns|namespace", "", "only list the records of the namespace")
+	c.StringOption(&c.minAge, "a|min-age", "", "only list the locks held at least this long, e.g. 10s")
+	c.AddExample(name+" -s 127.0.0.1:8089 -a 5s", "list the records locked for 5 seconds or longer")
+}
+
+func (c *cmdLocksT) Exec() {
+	query := url.Values{}
+	if len(c.namespace) != 0 {
+		query.Set("ns", c.namespace)
+	}
+	if len(c.minAge) != 0 {
+		query.Set("minage", c.minAge)
+	}
+	body, err := adminHttpRequest(http.MethodGet, c.monAddr, "/admin/locks", query)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	var entries []lockEntryT
+	if err = json.Unmarshal(body, &entries); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "shard\tnamespace\tkey\top\tprepared\trid\toriginator\tapp\tage")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\n", e.ShardId, e.Namespace, e.Key,
+			e.OpCode, e.Prepared, e.RequestId, e.Originator, e.AppName, e.Age)
+	}
+	w.Flush()
+	fmt.Printf("  * %d locked record(s)\n", len(entries))
+}
+
+func (c *cmdForceAbortT) Init(name string, desc string) {
+	c.Command.Init(name, desc)
+	c.StringOption(&c.monAddr, "s|server", "127.0.0.1:8089", "specify storage server http monitoring address")
+	c.StringOption(&c.namespace, "ns|namespace", "namespace", "specify namespace")
+	c.UintOption(&c.keyType, "kt|key-type", 0, "specify the type of the key. \n   \t0 - string key\n   \t1 - hex key")
+	c.StringOption(&c.rid, "rid|request-id", "", "specify request id of the prepare holding the lock")
+	c.SetSynopsis("[option] <key>")
+	c.AddExample(name+" -ns ns -rid <rid> key", "abort the prepare holding the lock of the record")
+}
+
+func (c *cmdForceAbortT) Parse(args []string) (err error) {
+	if err = c.Command.Parse(args); err != nil {
+		return
+	}
+	if c.NArg() < 1 {
+		err = fmt.Errorf("missing key")
+		return
+	}
+	switch c.keyType {
+	case 0:
+		c.key = []byte(c.Arg(0))
+	case 1:
+		c.key, err = hex.DecodeString(c.Arg(0))
+	default:
+		err = fmt.Errorf("invalid key type %d", c.keyType)
+	}
+	if err == nil && len(c.rid) == 0 {
+		err = fmt.Errorf("missing request id")
+	}
+	return
+}
+
+func (c *cmdForceAbortT) Exec() {
+	query := url.Values{}
+	query.Set("ns", c.namespace)
+	query.Set("hexkey", hex.EncodeToString(c.key))
+	query.Set("rid", c.rid)
+	if body, err := adminHttpRequest(http.MethodPost, c.monAddr, "/admin/locks/abort", query); err == nil {
+		fmt.Print(string(body))
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func adminHttpRequest(method string, addr string, path string, query url.Values) (body []byte, err error) {
+	u := url.URL{Scheme: "http", Host: addr, Path: path, RawQuery: query.Encode()}
+	var req *http.Request
+	if req, err = http.NewRequest(method, u.String(), nil); err != nil {
+		return
+	}
+	client := &http.Client{Timeout: kAdminHttpTimeout}
+	var resp *http.Response
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	return
+}
@@ -0,0 +1,78 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package insp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paypal/junodb/cmd/proxy/audit"
+	"github.com/paypal/junodb/pkg/cmd"
+)
+
+type cmdAuditVerifyT struct {
+	cmd.Command
+	files []string
+}
+
+func (c *cmdAuditVerifyT) Init(name string, desc string) {
+	c.Command.Init(name, desc)
+	c.SetSynopsis("<audit log file> ...\n  the rotated files of a worker, the oldest first, and then audit.<worker id>.log")
+}
+
+func (c *cmdAuditVerifyT) Parse(args []string) (err error) {
+	if err = c.FlagSet.Parse(args); err != nil {
+		return
+	}
+	if c.NArg() < 1 {
+		err = fmt.Errorf("missing audit log file")
+		return
+	}
+	c.files = c.Args()
+	return
+}
+
+func (c *cmdAuditVerifyT) Exec() {
+	c.Validate()
+
+	var chain audit.Chain
+	for _, name := range c.files {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		n, err := audit.Verify(f, &chain)
+		f.Close()
+		if err != nil {
+			fmt.Printf("%s: FAILED after %d records: %s\n", name, n, err.Error())
+			return
+		}
+		fmt.Printf("%s: %d records OK\n", name, n)
+	}
+	fmt.Printf("last seq %d, hash %s\n", chain.Seq, chain.Hash)
+}
+
+func init() {
+	c := &cmdAuditVerifyT{}
+	c.Init("auditverify", "verify the hash chain of proxy audit logs")
+
+	cmd.Register(c)
+}
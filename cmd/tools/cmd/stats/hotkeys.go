@@ -0,0 +1,106 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/paypal/junodb/pkg/cmd"
+	"github.com/paypal/junodb/pkg/stats/hotkey"
+)
+
+var _ cmd.ICommand = (*CmdHotKeys)(nil)
+
+// CmdHotKeys prints the hot keys served on /hotkeys by the monitor of a proxy
+// or a storage server.
+type CmdHotKeys struct {
+	cmd.Command
+	optMonAddr   string
+	optNamespace string
+	optNumKeys   int
+	optWorkerId  string
+	optJson      bool
+}
+
+func (c *CmdHotKeys) Init(name string, desc string) {
+	c.Command.Init(name, desc)
+	c.StringOption(&c.optMonAddr, "s|server", "127.0.0.1:8088", "specify proxy or storageserv http monitoring address")
+	c.StringOption(&c.optNamespace, "ns|namespace", "", "only list the keys of the namespace")
+	c.IntOption(&c.optNumKeys, "n|num-keys", 0, "max number of keys listed per metric. all the tracked keys if 0")
+	c.StringOption(&c.optWorkerId, "w|worker-id", "", "specify worker id. merge the keys of all workers, if not specified")
+	c.BoolOption(&c.optJson, "j|json", false, "print in JSON")
+	c.AddExample(name+" -s 127.0.0.1:8089 -n 10", "list the 10 hottest keys of the storageserv by reads, writes and bytes")
+}
+
+func (c *CmdHotKeys) Parse(args []string) (err error) {
+	if err = c.Option.Parse(args); err != nil {
+		return
+	}
+	if c.optNumKeys < 0 {
+		err = fmt.Errorf("invalid number of keys %d", c.optNumKeys)
+	}
+	return
+}
+
+func (c *CmdHotKeys) Exec() {
+	query := url.Values{}
+	if len(c.optNamespace) != 0 {
+		query.Set("ns", c.optNamespace)
+	}
+	if c.optNumKeys != 0 {
+		query.Set("n", strconv.Itoa(c.optNumKeys))
+	}
+	if len(c.optWorkerId) != 0 {
+		query.Set("wid", c.optWorkerId)
+	}
+	u := url.URL{Scheme: "http", Host: c.optMonAddr, Path: "/hotkeys", RawQuery: query.Encode()}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if c.optJson {
+		os.Stdout.Write(body)
+		return
+	}
+	var report hotkey.Report
+	if err = json.Unmarshal(body, &report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	report.WriteText(os.Stdout)
+}
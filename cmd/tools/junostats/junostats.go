@@ -45,4 +45,7 @@ func init() {
 	sstats := &stats.CmdStorageStats{}
 	sstats.Init("storage", "get storageserv statistics")
 	cmd.Register(sstats)
+	hotkeys := &stats.CmdHotKeys{}
+	hotkeys.Init("hotkeys", "get the hot keys of proxy or storageserv")
+	cmd.Register(hotkeys)
 }
@@ -36,6 +36,7 @@ juno_executables="\
         github.com/paypal/junodb/test/drv/junoload \
         github.com/paypal/junodb/test/drv/bulkload \
         github.com/paypal/junodb/cmd/storageserv/storage/db/dbcopy \
+        github.com/paypal/junodb/cmd/storageserv/storage/db/nsdump \
         "
 
 export PATH=/usr/local/go/bin:$PATH
@@ -26,10 +26,13 @@ import (
 )
 
 type RecordInfo struct {
-	version      uint32
-	creationTime uint32
-	timeToLive   uint32
-	originatorId proto.RequestId
+	version              uint32
+	creationTime         uint32
+	timeToLive           uint32
+	expirationTime       uint32
+	lastModificationTime uint64
+	valueSize            uint32
+	originatorId         proto.RequestId
 }
 
 func (r *RecordInfo) GetVersion() uint32 {
@@ -44,6 +47,22 @@ func (r *RecordInfo) GetTimeToLive() uint32 {
 	return r.timeToLive
 }
 
+func (r *RecordInfo) GetExpirationTime() uint32 {
+	return r.expirationTime
+}
+
+func (r *RecordInfo) GetLastModificationTime() uint64 {
+	return r.lastModificationTime
+}
+
+func (r *RecordInfo) GetValueSize() uint32 {
+	return r.valueSize
+}
+
+func (r *RecordInfo) SetTimeToLive(ttl uint32) {
+	r.timeToLive = ttl
+}
+
 func (r *RecordInfo) SetRequestWithUpdateCond(request *proto.OperationalMessage) {
 	if r.creationTime != 0 {
 		request.SetCreationTime(r.creationTime)
@@ -64,6 +83,9 @@ func (r *RecordInfo) SetFromOpMsg(m *proto.OperationalMessage) {
 	r.version = m.GetVersion()
 	r.creationTime = m.GetCreationTime()
 	r.timeToLive = m.GetTimeToLive()
+	r.expirationTime = m.GetExpirationTime()
+	r.lastModificationTime = m.GetLastModificationTime()
+	r.valueSize = m.GetValueSize()
 	if m.IsOriginatorSet() {
 		r.originatorId = m.GetOriginatorRequestID()
 	} else {
@@ -71,6 +93,10 @@ func (r *RecordInfo) SetFromOpMsg(m *proto.OperationalMessage) {
 	}
 }
 
+func (r *RecordInfo) IsOriginatorSet() bool {
+	return r.originatorId.IsSet()
+}
+
 func (r *RecordInfo) IsSameOriginator(ctx *RecordInfo) bool {
 	if ctx != nil {
 		return r.originatorId.Equal(ctx.originatorId)
@@ -0,0 +1,484 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+	"sync"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+const (
+	kDefaultChunkSize    = 128 * 1024
+	kDefaultMaxValueSize = 8 * 1024 * 1024
+	kChunkConcurrency    = 8
+	kChunkTTLGrace       = 60 // chunks outlive the manifest by this many seconds
+
+	kManifestHeaderSize = 8 + 16 + 4 + 4 + 4 + sha256.Size
+	// kMinManifestStoredSize is the magic, the write id and the sha256 of a
+	// manifest, which do not compress.
+	kMinManifestStoredSize = 8 + 16 + sha256.Size
+	// kMaxManifestOverhead bounds the compression or encryption overhead on
+	// the stored size of a manifest.
+	kMaxManifestOverhead = 256
+)
+
+var (
+	manifestMagic = []byte{0xff, 'J', 'C', 'H', 'U', 'N', 'K', 0x01}
+	chunkCrcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// ChunkingConfig configures the optional storage of large values as a manifest
+// record plus chunk records.
+type ChunkingConfig struct {
+	Enabled      bool     // Enabled turns chunking on.
+	ChunkSize    int      // ChunkSize is the largest value stored as is, and the size of the chunks. It must fit the MaxPayloadLength of the proxy.
+	MaxValueSize int      // MaxValueSize bounds the size of a chunked value.
+	Namespaces   []string // Namespaces chunking is enabled for. Empty means all.
+}
+
+// isEnabledFor returns true if values of the namespace are to be chunked.
+func (c *ChunkingConfig) isEnabledFor(namespace string) bool {
+	if !c.Enabled {
+		return false
+	}
+	if len(c.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range c.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkManifest is the value of the record of a chunked value. The chunks are
+// stored under keys derived from the record key and the write id, so that the
+// chunks of a new value never overwrite the ones of the current value.
+//
+//	magic(8) | write id(16) | value size(4) | chunk size(4) | #chunks(4) | sha256(32) | crc32c(4) of each chunk
+type chunkManifest struct {
+	writeId   proto.RequestId
+	size      uint32
+	chunkSize uint32
+	sum       [sha256.Size]byte
+	crcs      []uint32
+}
+
+func newChunkManifest(value []byte, chunkSize int) *chunkManifest {
+	m := &chunkManifest{
+		size:      uint32(len(value)),
+		chunkSize: uint32(chunkSize),
+		sum:       sha256.Sum256(value),
+	}
+	m.writeId.SetNewRequestId()
+	m.crcs = make([]uint32, (len(value)+chunkSize-1)/chunkSize)
+	for i := range m.crcs {
+		m.crcs[i] = crc32.Checksum(m.chunk(value, i), chunkCrcTable)
+	}
+	return m
+}
+
+func (m *chunkManifest) encode() []byte {
+	buf := make([]byte, kManifestHeaderSize+4*len(m.crcs))
+	off := copy(buf, manifestMagic)
+	off += copy(buf[off:], m.writeId[:])
+	binary.BigEndian.PutUint32(buf[off:], m.size)
+	binary.BigEndian.PutUint32(buf[off+4:], m.chunkSize)
+	binary.BigEndian.PutUint32(buf[off+8:], uint32(len(m.crcs)))
+	off += 12
+	off += copy(buf[off:], m.sum[:])
+	for _, crc := range m.crcs {
+		binary.BigEndian.PutUint32(buf[off:], crc)
+		off += 4
+	}
+	return buf
+}
+
+// decodeChunkManifest returns nil if value is not a manifest.
+func decodeChunkManifest(value []byte) *chunkManifest {
+	if len(value) < kManifestHeaderSize || !bytes.HasPrefix(value, manifestMagic) {
+		return nil
+	}
+	m := &chunkManifest{}
+	off := len(manifestMagic)
+	off += copy(m.writeId[:], value[off:])
+	m.size = binary.BigEndian.Uint32(value[off:])
+	m.chunkSize = binary.BigEndian.Uint32(value[off+4:])
+	n := int(binary.BigEndian.Uint32(value[off+8:]))
+	off += 12
+	off += copy(m.sum[:], value[off:])
+	if m.chunkSize == 0 || len(value) != off+4*n || n != int((uint64(m.size)+uint64(m.chunkSize)-1)/uint64(m.chunkSize)) {
+		return nil
+	}
+	m.crcs = make([]uint32, n)
+	for i := range m.crcs {
+		m.crcs[i] = binary.BigEndian.Uint32(value[off:])
+		off += 4
+	}
+	return m
+}
+
+// chunkKey returns the key of the i-th chunk of the record key.
+func (m *chunkManifest) chunkKey(key []byte, i int) []byte {
+	k := make([]byte, len(key)+1+16+4)
+	off := copy(k, key) + 1
+	off += copy(k[off:], m.writeId[:])
+	binary.BigEndian.PutUint32(k[off:], uint32(i))
+	return k
+}
+
+// chunk returns the i-th chunk of value.
+func (m *chunkManifest) chunk(value []byte, i int) []byte {
+	start := i * int(m.chunkSize)
+	end := start + int(m.chunkSize)
+	if end > len(value) {
+		end = len(value)
+	}
+	return value[start:end]
+}
+
+// chunkingClientT stores the values larger than the chunk size as a manifest
+// record plus chunk records, and forwards everything else to the wrapped
+// client. The chunks are written first, so a value becomes visible atomically
+// when the manifest is written. The chunks replaced by a write or a destroy are
+// destroyed afterwards, or expire with their TTL if this fails.
+type chunkingClientT struct {
+	IClient
+	conf           ChunkingConfig
+	namespace      string // namespace is the one of the wrapped client, if known
+	defaultEnabled bool   // defaultEnabled tells if chunking is used for the namespace of the wrapped client
+	defaultTTL     uint32 // defaultTTL is the TTL of the chunks when the write has no TTL
+	chunkSize      int
+	maxValueSize   int
+}
+
+// WithChunking wraps cli with chunking. The requests without WithNamespace
+// are chunked if the namespace of cli is in the namespace list of conf, or if
+// the list is empty.
+func WithChunking(cli IClient, conf ChunkingConfig) IClient {
+	ns := defaultNamespaceOf(cli)
+	return newChunkingClient(cli, conf, ns, conf.isEnabledFor(ns), uint32(defaultConfig.DefaultTimeToLive))
+}
+
+func newChunkingClient(cli IClient, conf ChunkingConfig, namespace string, defaultEnabled bool, defaultTTL uint32) *chunkingClientT {
+	c := &chunkingClientT{
+		IClient:        cli,
+		conf:           conf,
+		namespace:      namespace,
+		defaultEnabled: defaultEnabled,
+		defaultTTL:     defaultTTL,
+		chunkSize:      conf.ChunkSize,
+		maxValueSize:   conf.MaxValueSize,
+	}
+	if c.chunkSize <= 0 {
+		c.chunkSize = kDefaultChunkSize
+	}
+	if c.maxValueSize <= 0 {
+		c.maxValueSize = kDefaultMaxValueSize
+	}
+	return c
+}
+
+func (c *chunkingClientT) defaultNamespace() string {
+	return c.namespace
+}
+
+// isEnabledFor tells if the namespace of the request is chunked.
+func (c *chunkingClientT) isEnabledFor(options *optionData) bool {
+	if len(options.namespace) == 0 || options.namespace == c.namespace {
+		return c.defaultEnabled
+	}
+	return c.conf.isEnabledFor(options.namespace)
+}
+
+// needChunking tells if value is to be chunked. A value looking like a
+// manifest is chunked as well, so that it is never taken for one.
+func (c *chunkingClientT) needChunking(value []byte) bool {
+	return len(value) > c.chunkSize || bytes.HasPrefix(value, manifestMagic)
+}
+
+func (c *chunkingClientT) Create(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) || !c.needChunking(value) {
+		return c.IClient.Create(key, value, opts...)
+	}
+	return c.write(proto.OpCodeCreate, key, value, options, opts)
+}
+
+func (c *chunkingClientT) Update(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) {
+		return c.IClient.Update(key, value, opts...)
+	}
+	return c.write(proto.OpCodeUpdate, key, value, options, opts)
+}
+
+func (c *chunkingClientT) Set(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) {
+		return c.IClient.Set(key, value, opts...)
+	}
+	return c.write(proto.OpCodeSet, key, value, options, opts)
+}
+
+// write writes the chunks of value, if it is to be chunked, and then the
+// record. The chunks of the value replaced by an Update or a Set are destroyed.
+// Once the record is written, the write succeeds, and the failures in the
+// clean up are only logged.
+func (c *chunkingClientT) write(op proto.OpCode, key []byte, value []byte, options *optionData, opts []IOption) (context IContext, err error) {
+	if len(value) > c.maxValueSize {
+		return nil, ErrBadParam
+	}
+	var old *chunkManifest
+	var curTTL uint32
+	if op != proto.OpCodeCreate {
+		old, curTTL = c.readManifest(key, options)
+	}
+	var m *chunkManifest
+	var ttl uint32
+	if c.needChunking(value) {
+		m = newChunkManifest(value, c.chunkSize)
+		// an Update or a Set keeps the TTL of the record if longer
+		if ttl = c.chunkTTL(options); curTTL > ttl {
+			ttl = curTTL
+		}
+		if err = c.writeChunks(key, value, m, ttl, options); err != nil {
+			return
+		}
+		value = m.encode()
+	}
+	switch op {
+	case proto.OpCodeCreate:
+		context, err = c.IClient.Create(key, value, opts...)
+	case proto.OpCodeUpdate:
+		context, err = c.IClient.Update(key, value, opts...)
+	default:
+		context, err = c.IClient.Set(key, value, opts...)
+	}
+	if err != nil {
+		if m != nil {
+			c.destroyChunks(key, m, options)
+		}
+		return
+	}
+	if m != nil && context.GetTimeToLive() > ttl {
+		// the record got a longer TTL than the chunks, e.g. by a concurrent write
+		if e := c.touchChunks(key, m, context.GetTimeToLive(), options); e != nil {
+			glog.Warningf("fail to extend the TTL of chunks: %s", e)
+		}
+	}
+	if old != nil {
+		c.destroyChunks(key, old, options)
+	}
+	return
+}
+
+// chunkTTL returns the TTL of the chunks of a write, if no record exists.
+func (c *chunkingClientT) chunkTTL(options *optionData) uint32 {
+	if options.ttl != 0 {
+		return options.ttl
+	}
+	return c.defaultTTL
+}
+
+func (c *chunkingClientT) Get(key []byte, opts ...IOption) (value []byte, context IContext, err error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) {
+		return c.IClient.Get(key, opts...)
+	}
+	// a chunk missing because of a concurrent write is read again once
+	for retry := 0; ; retry++ {
+		if value, context, err = c.IClient.Get(key, opts...); err != nil {
+			return
+		}
+		m := decodeChunkManifest(value)
+		if m == nil {
+			return
+		}
+		if value, err = c.readChunks(key, m, options); err != ErrNoKey || retry > 0 {
+			break
+		}
+	}
+	if err == ErrNoKey {
+		err = ErrCorruptedValue
+	}
+	if err != nil {
+		value = nil
+	}
+	return
+}
+
+func (c *chunkingClientT) Destroy(key []byte, opts ...IOption) (err error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) {
+		return c.IClient.Destroy(key, opts...)
+	}
+	old, _ := c.readManifest(key, options)
+	if err = c.IClient.Destroy(key, opts...); err == nil && old != nil {
+		c.destroyChunks(key, old, options)
+	}
+	return
+}
+
+// Touch replaces the TTL of the chunks and then of the record.
+func (c *chunkingClientT) Touch(key []byte, ttl uint32, opts ...IOption) (IContext, error) {
+	options := newOptionData(opts...)
+	if c.isEnabledFor(options) && ttl != 0 {
+		if m, _ := c.readManifest(key, options); m != nil {
+			if err := c.touchChunks(key, m, ttl, options); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.IClient.Touch(key, ttl, opts...)
+}
+
+// readManifest returns the manifest of the record, or nil if the record does
+// not exist or is not chunked, and the remaining TTL of the record. The value
+// is only read if its size fits a manifest.
+func (c *chunkingClientT) readManifest(key []byte, options *optionData) (m *chunkManifest, ttl uint32) {
+	opts := c.chunkOptions(options, 0)
+	meta, err := c.IClient.GetMeta(key, opts...)
+	if err != nil {
+		return
+	}
+	ttl = meta.GetTimeToLive()
+	maxSize := kManifestHeaderSize + 4*((c.maxValueSize+c.chunkSize-1)/c.chunkSize) + kMaxManifestOverhead
+	if sz := int(meta.GetValueSize()); sz < kMinManifestStoredSize || sz > maxSize {
+		return
+	}
+	value, _, err := c.IClient.Get(key, opts...)
+	if err != nil {
+		return
+	}
+	return decodeChunkManifest(value), ttl
+}
+
+// chunkOptions returns the options of the requests for the chunks of a request.
+func (c *chunkingClientT) chunkOptions(options *optionData, ttl uint32) []IOption {
+	opts := make([]IOption, 0, 5)
+	if ttl != 0 {
+		opts = append(opts, WithTTL(ttl))
+	}
+	if len(options.namespace) != 0 {
+		opts = append(opts, WithNamespace(options.namespace))
+	}
+	if len(options.correlationId) != 0 {
+		opts = append(opts, WithCorrelationId(options.correlationId))
+	}
+	if options.ctx != nil {
+		opts = append(opts, WithContext(options.ctx))
+	}
+	if len(options.interceptors) != 0 {
+		opts = append(opts, WithInterceptor(options.interceptors...))
+	}
+	return opts
+}
+
+// forEachChunk calls fn for each chunk of m, kChunkConcurrency at a time, and
+// returns the first error.
+func forEachChunk(m *chunkManifest, fn func(i int) error) (err error) {
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	sem := make(chan struct{}, kChunkConcurrency)
+	for i := range m.crcs {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			if e := fn(i); e != nil {
+				mtx.Lock()
+				if err == nil {
+					err = e
+				}
+				mtx.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return
+}
+
+func (c *chunkingClientT) writeChunks(key []byte, value []byte, m *chunkManifest, ttl uint32, options *optionData) (err error) {
+	opts := c.chunkOptions(options, ttl+kChunkTTLGrace)
+	if err = forEachChunk(m, func(i int) error {
+		_, e := c.IClient.Create(m.chunkKey(key, i), m.chunk(value, i), opts...)
+		return e
+	}); err != nil {
+		c.destroyChunks(key, m, options)
+	}
+	return
+}
+
+// readChunks reads and verifies the chunks of m. The TTL of the request, if
+// any, is extended on the chunks as well.
+func (c *chunkingClientT) readChunks(key []byte, m *chunkManifest, options *optionData) (value []byte, err error) {
+	var ttl uint32
+	if options.ttl != 0 {
+		ttl = options.ttl + kChunkTTLGrace
+	}
+	opts := c.chunkOptions(options, ttl)
+	value = make([]byte, m.size)
+	if err = forEachChunk(m, func(i int) error {
+		chunk, _, e := c.IClient.Get(m.chunkKey(key, i), opts...)
+		if e != nil {
+			return e
+		}
+		if len(chunk) != len(m.chunk(value, i)) || crc32.Checksum(chunk, chunkCrcTable) != m.crcs[i] {
+			glog.Warningf("corrupted chunk %d of %d, size=%d", i, len(m.crcs), len(chunk))
+			return ErrCorruptedValue
+		}
+		copy(m.chunk(value, i), chunk)
+		return nil
+	}); err != nil {
+		return
+	}
+	if sha256.Sum256(value) != m.sum {
+		err = ErrCorruptedValue
+	}
+	return
+}
+
+func (c *chunkingClientT) touchChunks(key []byte, m *chunkManifest, ttl uint32, options *optionData) error {
+	opts := c.chunkOptions(options, 0)
+	return forEachChunk(m, func(i int) error {
+		_, e := c.IClient.Touch(m.chunkKey(key, i), ttl+kChunkTTLGrace, opts...)
+		return e
+	})
+}
+
+// destroyChunks destroys the chunks of m. The chunks failing to be destroyed
+// expire with their TTL.
+func (c *chunkingClientT) destroyChunks(key []byte, m *chunkManifest, options *optionData) {
+	opts := c.chunkOptions(options, 0)
+	if err := forEachChunk(m, func(i int) error {
+		return c.IClient.Destroy(m.chunkKey(key, i), opts...)
+	}); err != nil {
+		glog.Warningf("fail to destroy chunks: %s", err)
+	}
+}
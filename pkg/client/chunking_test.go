@@ -0,0 +1,220 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func TestChunking(t *testing.T) {
+	now := time.Now()
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	fake.SetClock(func() time.Time { return now })
+	cli := WithChunking(fake, ChunkingConfig{Enabled: true, ChunkSize: 1000, MaxValueSize: 10000})
+	key := []byte("key")
+	value := make([]byte, 3500)
+	rand.Read(value)
+
+	if _, err := cli.Set(key, value, WithTTL(100)); err != nil {
+		t.Fatal(err)
+	}
+	if fake.Len() != 5 {
+		t.Errorf("expected manifest and 4 chunks, got %d records", fake.Len())
+	}
+	v, ctx, err := cli.Get(key)
+	if err != nil || !bytes.Equal(v, value) || ctx.GetTimeToLive() != 100 {
+		t.Fatalf("get: %v", err)
+	}
+	if _, err = cli.Create(key, value); err != ErrUniqueKeyViolation {
+		t.Errorf("expected %s, got %v", ErrUniqueKeyViolation, err)
+	}
+	value = value[:2500]
+	if _, err = cli.Update(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if fake.Len() != 4 {
+		t.Errorf("expected manifest and 3 chunks, got %d records", fake.Len())
+	}
+	if v, _, err = cli.Get(key); err != nil || !bytes.Equal(v, value) {
+		t.Fatalf("get: %v", err)
+	}
+
+	// the chunks expire with the record
+	if _, err = cli.Touch(key, 10); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(11 * time.Second)
+	if _, _, err = cli.Get(key); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	now = now.Add(kChunkTTLGrace * time.Second)
+	if fake.Len() != 0 {
+		t.Errorf("expected no record, got %d", fake.Len())
+	}
+
+	if _, err = cli.Set(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Set(key, []byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	if fake.Len() != 1 {
+		t.Errorf("expected 1 record, got %d", fake.Len())
+	}
+	if _, err = cli.Set(key, make([]byte, 10001)); err != ErrBadParam {
+		t.Errorf("expected %s, got %v", ErrBadParam, err)
+	}
+}
+
+func TestChunkingCorruption(t *testing.T) {
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	cli := WithChunking(fake, ChunkingConfig{Enabled: true, ChunkSize: 1000})
+	key := []byte("key")
+
+	if _, err := cli.Set(key, bytes.Repeat([]byte("a"), 2500)); err != nil {
+		t.Fatal(err)
+	}
+	raw, _, err := fake.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := decodeChunkManifest(raw)
+	if m == nil || m.size != 2500 || len(m.crcs) != 3 {
+		t.Fatalf("unexpected manifest %v", m)
+	}
+	if _, err = fake.Set(m.chunkKey(key, 1), bytes.Repeat([]byte("b"), 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = cli.Get(key); err != ErrCorruptedValue {
+		t.Errorf("expected %s, got %v", ErrCorruptedValue, err)
+	}
+	if err = fake.Destroy(m.chunkKey(key, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = cli.Get(key); err != ErrCorruptedValue {
+		t.Errorf("expected %s, got %v", ErrCorruptedValue, err)
+	}
+	if err = cli.Destroy(key); err != nil || fake.Len() != 0 {
+		t.Errorf("destroy: %v, %d records left", err, fake.Len())
+	}
+}
+
+// getCounter counts the Gets sent to the wrapped client.
+type getCounter struct {
+	IClient
+	gets int
+}
+
+func (c *getCounter) Get(key []byte, opts ...IOption) ([]byte, IContext, error) {
+	c.gets++
+	return c.IClient.Get(key, opts...)
+}
+
+func TestChunkingManifestRead(t *testing.T) {
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	counter := &getCounter{IClient: fake}
+	cli := WithChunking(counter, ChunkingConfig{Enabled: true, ChunkSize: 1000, MaxValueSize: 10000})
+	key := []byte("key")
+
+	// only a value the size of a manifest is read before a write
+	for _, sz := range []int{10, 500, 120, 900} {
+		if _, err := cli.Set(key, bytes.Repeat([]byte("a"), sz)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter.gets != 1 {
+		t.Errorf("expected 1 Get, got %d", counter.gets)
+	}
+	counter.gets = 0
+	if _, err := cli.Set(key, bytes.Repeat([]byte("a"), 2500)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Set(key, []byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	if counter.gets != 1 || fake.Len() != 1 {
+		t.Errorf("expected the manifest read and the chunks destroyed, got %d Gets and %d records", counter.gets, fake.Len())
+	}
+}
+
+func TestChunkingTTL(t *testing.T) {
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	cli := WithChunking(fake, ChunkingConfig{Enabled: true, ChunkSize: 1000})
+	key := []byte("key")
+	value := bytes.Repeat([]byte("a"), 2500)
+
+	// the chunks get the longer TTL the record keeps
+	if _, err := cli.Set(key, []byte("small"), WithTTL(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Set(key, value, WithTTL(100)); err != nil {
+		t.Fatal(err)
+	}
+	raw, ctx, err := fake.Get(key)
+	if err != nil || ctx.GetTimeToLive() != 1000 {
+		t.Fatalf("get: ttl %d, %v", ctx.GetTimeToLive(), err)
+	}
+	m := decodeChunkManifest(raw)
+	for i := range m.crcs {
+		if meta, err := fake.GetMeta(m.chunkKey(key, i)); err != nil || meta.GetTimeToLive() != 1000+kChunkTTLGrace {
+			t.Errorf("chunk %d: ttl %d, %v", i, meta.GetTimeToLive(), err)
+		}
+	}
+
+	// once the record is written, failing to extend the chunks does not fail the write
+	if _, err = cli.Set(key, []byte("small"), WithTTL(1000)); err != nil {
+		t.Fatal(err)
+	}
+	fake.InjectFault(Fault{OpCode: proto.OpCodeGetMeta, Err: ErrBusy, Count: 1})
+	fake.InjectFault(Fault{OpCode: proto.OpCodeTouch, Err: ErrBusy})
+	if _, err = cli.Set(key, value, WithTTL(100)); err != nil {
+		t.Errorf("expected the write to succeed, got %v", err)
+	}
+	fake.ClearFaults()
+	if v, _, err := cli.Get(key); err != nil || !bytes.Equal(v, value) {
+		t.Errorf("get: %v", err)
+	}
+}
+
+func TestWithChunkingNamespaces(t *testing.T) {
+	value := bytes.Repeat([]byte("a"), 2500)
+	for _, test := range []struct {
+		namespaces []string
+		numRecords int
+	}{
+		{nil, 4},
+		{[]string{"ns"}, 4},
+		{[]string{"other"}, 1},
+	} {
+		fake := NewFakeClient(Config{Namespace: "ns"})
+		cli := WithChunking(fake, ChunkingConfig{Enabled: true, ChunkSize: 1000, Namespaces: test.namespaces})
+		if _, err := cli.Set([]byte("key"), value); err != nil {
+			t.Fatal(err)
+		}
+		if fake.Len() != test.numRecords {
+			t.Errorf("namespaces %v: expected %d records, got %d", test.namespaces, test.numRecords, fake.Len())
+		}
+	}
+}
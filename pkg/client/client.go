@@ -42,6 +42,7 @@ possible returned error if client successfully received the response from Proxy
   * ErrNoStorage
   * ErrNoKey
   * ErrTTLExtendFailure
+  * ErrCorruptedValue (chunking only)
 
   Update
   * nil
@@ -74,6 +75,33 @@ possible returned error if client successfully received the response from Proxy
   * ErrRecordLocked
   * ErrWriteFailure
 
+  Exists
+  * nil
+  * ErrBadMsg
+  * ErrBadParam
+  * ErrInternal
+  * ErrBusy
+  * ErrNoStorage
+
+  GetMeta
+  * nil
+  * ErrBadMsg
+  * ErrBadParam
+  * ErrInternal
+  * ErrBusy
+  * ErrNoStorage
+  * ErrNoKey
+
+  Touch
+  * nil
+  * ErrBadMsg
+  * ErrBadParam
+  * ErrInternal
+  * ErrBusy
+  * ErrNoStorage
+  * ErrNoKey
+  * ErrRecordLocked
+
 */
 package client
 
@@ -88,6 +116,14 @@ type IContext interface {
 	PrettyPrint(w io.Writer)
 }
 
+// IMeta is the metadata of a record returned by GetMeta.
+type IMeta interface {
+	IContext
+	GetExpirationTime() uint32
+	GetLastModificationTime() uint64 // in nanoseconds since epoch
+	GetValueSize() uint32            // the size of the value as stored
+}
+
 ///TODO check API input arguments
 
 type IClient interface {
@@ -98,6 +134,9 @@ type IClient interface {
 	Destroy(key []byte, opts ...IOption) (err error)
 	UDFGet(key []byte, fname []byte, params []byte, opts ...IOption) ([]byte, IContext, error)
 	UDFSet(key []byte, fname []byte, params []byte, opts ...IOption) (IContext, error)
+	Exists(key []byte, opts ...IOption) (bool, error)
+	GetMeta(key []byte, opts ...IOption) (IMeta, error)
+	Touch(key []byte, ttl uint32, opts ...IOption) (IContext, error)
 }
 
 //type IResult interface {
@@ -34,10 +34,11 @@ import (
 
 // clientImplT is the default implementation of the IClient interface.
 type clientImplT struct {
-	config    Config
-	appName   string
-	namespace string
-	processor *cli.Processor
+	config       Config
+	appName      string
+	namespace    string
+	processor    *cli.Processor
+	interceptors []Interceptor
 }
 
 // newProcessorWithConfig initializes a new Processor with the given configuration.
@@ -55,21 +56,34 @@ func newProcessorWithConfig(conf *Config) *cli.Processor {
 }
 
 // New initializes a new IClient with the given configuration. Returns an error if configuration validation fails.
-func New(conf Config) (IClient, error) {
+// Only client wide options, e.g. WithInterceptor, are taken from opts.
+func New(conf Config, opts ...IOption) (IClient, error) {
 	if err := conf.validate(); err != nil {
 		return nil, err
 	}
 	client := &clientImplT{
-		config:    conf,
-		processor: newProcessorWithConfig(&conf),
-		appName:   conf.Appname,
-		namespace: conf.Namespace,
+		config:       conf,
+		processor:    newProcessorWithConfig(&conf),
+		appName:      conf.Appname,
+		namespace:    conf.Namespace,
+		interceptors: newOptionData(opts...).interceptors,
 	}
 	client.processor.Start()
 	runtime.SetFinalizer(client.processor, func(p *cli.Processor) {
 		p.Close()
 	})
-	return client, nil
+	var cli IClient = client
+	if conf.Chunking.Enabled {
+		ttl := conf.DefaultTimeToLive
+		if ttl <= 0 {
+			ttl = defaultConfig.DefaultTimeToLive
+		}
+		cli = newChunkingClient(cli, conf.Chunking, conf.Namespace, conf.Chunking.isEnabledFor(conf.Namespace), uint32(ttl))
+	}
+	if conf.NearCache.Enabled {
+		return newNearCacheClient(cli, conf.NearCache, conf.Namespace, conf.NearCache.isEnabledFor(conf.Namespace)), nil
+	}
+	return cli, nil
 }
 
 // NewClient initializes a new IClient with the provided server address, namespace and app name.
@@ -136,11 +150,11 @@ func (c *clientImplT) Create(key []byte, value []byte, opts ...IOption) (context
 	options := newOptionData(opts...)
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
-	request := c.NewRequest(proto.OpCodeCreate, key, value, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	request, err := c.newRequestWithOptions(proto.OpCodeCreate, key, value, options)
+	if err != nil {
+		return
 	}
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err != nil {
 			glog.Debug(err)
 		}
@@ -154,11 +168,17 @@ func (c *clientImplT) Get(key []byte, opts ...IOption) (value []byte, context IC
 	options := newOptionData(opts...)
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
-	request := c.NewRequest(proto.OpCodeGet, key, nil, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	request, err := c.newRequestWithOptions(proto.OpCodeGet, key, nil, options)
+	if err != nil {
+		return
 	}
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if options.readVersion != 0 {
+		request.SetReadVersion(options.readVersion)
+	}
+	if !options.asOf.IsZero() {
+		request.SetAsOfTime(uint64(options.asOf.UnixNano()))
+	}
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err == nil {
 			payload := resp.GetPayload()
 			sz := payload.GetLength()
@@ -178,16 +198,16 @@ func (c *clientImplT) Update(key []byte, value []byte, opts ...IOption) (context
 	options := newOptionData(opts...)
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
-	request := c.NewRequest(proto.OpCodeUpdate, key, value, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	request, err := c.newRequestWithOptions(proto.OpCodeUpdate, key, value, options)
+	if err != nil {
+		return
 	}
 	if inCtx := options.context; inCtx != nil {
 		if r, ok := inCtx.(*cli.RecordInfo); ok {
 			r.SetRequestWithUpdateCond(request)
 		}
 	}
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err != nil {
 			glog.Debug(err)
 		}
@@ -201,11 +221,11 @@ func (c *clientImplT) Set(key []byte, value []byte, opts ...IOption) (context IC
 	options := newOptionData(opts...)
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
-	request := c.NewRequest(proto.OpCodeSet, key, value, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	request, err := c.newRequestWithOptions(proto.OpCodeSet, key, value, options)
+	if err != nil {
+		return
 	}
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err != nil {
 			glog.Debug(err)
 		}
@@ -217,11 +237,11 @@ func (c *clientImplT) Set(key []byte, value []byte, opts ...IOption) (context IC
 func (c *clientImplT) Destroy(key []byte, opts ...IOption) (err error) {
 	var resp *proto.OperationalMessage
 	options := newOptionData(opts...)
-	request := c.NewRequest(proto.OpCodeDestroy, key, nil, 0)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	request, err := c.newRequestWithOptions(proto.OpCodeDestroy, key, nil, options)
+	if err != nil {
+		return
 	}
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, nil); err != nil {
 			glog.Debug(err)
 		}
@@ -236,11 +256,9 @@ func (c *clientImplT) UDFGet(key []byte, fname []byte, params []byte, opts ...IO
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
 	request := c.NewUDFRequest(proto.OpCodeUDFGet, key, fname, params, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
-	}
+	c.setRequestOptions(request, options)
 
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err == nil {
 			payload := resp.GetPayload()
 			sz := payload.GetLength()
@@ -261,11 +279,67 @@ func (c *clientImplT) UDFSet(key []byte, fname []byte, params []byte, opts ...IO
 	recInfo := &cli.RecordInfo{}
 	context = recInfo
 	request := c.NewUDFRequest(proto.OpCodeUDFSet, key, fname, params, options.ttl)
-	if len(options.correlationId) > 0 {
-		request.SetCorrelationID([]byte(options.correlationId))
+	c.setRequestOptions(request, options)
+
+	if resp, err = c.processRequest(request, options); err == nil {
+		if err = checkResponse(request, resp, recInfo); err != nil {
+			glog.Debug(err)
+		}
+	}
+	return
+}
+
+// Exists tells if the record exists without transferring its value.
+func (c *clientImplT) Exists(key []byte, opts ...IOption) (exists bool, err error) {
+	var resp *proto.OperationalMessage
+	options := newOptionData(opts...)
+	request, err := c.newRequestWithOptions(proto.OpCodeExists, key, nil, options)
+	if err != nil {
+		return
+	}
+	if resp, err = c.processRequest(request, options); err == nil {
+		if err = checkResponse(request, resp, nil); err == nil {
+			exists = true
+		} else if err == ErrNoKey {
+			err = nil
+		} else {
+			glog.Debug(err)
+		}
 	}
+	return
+}
 
-	if resp, err = c.processor.ProcessRequest(request); err == nil {
+// GetMeta returns the metadata of the record without its value.
+func (c *clientImplT) GetMeta(key []byte, opts ...IOption) (meta IMeta, err error) {
+	var resp *proto.OperationalMessage
+	options := newOptionData(opts...)
+	recInfo := &cli.RecordInfo{}
+	meta = recInfo
+	request, err := c.newRequestWithOptions(proto.OpCodeGetMeta, key, nil, options)
+	if err != nil {
+		return
+	}
+	if resp, err = c.processRequest(request, options); err == nil {
+		if err = checkResponse(request, resp, recInfo); err != nil {
+			glog.Debug(err)
+		}
+	}
+	return
+}
+
+// Touch replaces the TTL of the record without transferring its value. The
+// version of the record is kept.
+func (c *clientImplT) Touch(key []byte, ttl uint32, opts ...IOption) (context IContext, err error) {
+	var resp *proto.OperationalMessage
+	options := newOptionData(opts...)
+	options.ttl = ttl
+	recInfo := &cli.RecordInfo{}
+	context = recInfo
+	request, err := c.newRequestWithOptions(proto.OpCodeTouch, key, nil, options)
+	if err != nil {
+		return
+	}
+	if resp, err = c.processRequest(request, options); err == nil {
 		if err = checkResponse(request, resp, recInfo); err != nil {
 			glog.Debug(err)
 		}
@@ -304,6 +378,75 @@ func (c *clientImplT) NewUDFRequest(op proto.OpCode, key []byte, fname []byte, p
 	return
 }
 
+// namespaceHolder is implemented by the clients knowing the namespace of the
+// requests without WithNamespace.
+type namespaceHolder interface {
+	defaultNamespace() string
+}
+
+// defaultNamespaceOf returns the namespace of the requests of cli without
+// WithNamespace, or "" if not known.
+func defaultNamespaceOf(cli IClient) string {
+	if h, ok := cli.(namespaceHolder); ok {
+		return h.defaultNamespace()
+	}
+	return ""
+}
+
+func (c *clientImplT) defaultNamespace() string {
+	return c.namespace
+}
+
+// namespaceOf returns the namespace given by WithNamespace or the client one.
+func (c *clientImplT) namespaceOf(options *optionData) string {
+	if len(options.namespace) != 0 {
+		return options.namespace
+	}
+	return c.namespace
+}
+
+// newRequestWithOptions creates a new OperationalMessage in the namespace of the options,
+// applying the namespace defaults of the configuration.
+func (c *clientImplT) newRequestWithOptions(op proto.OpCode, key []byte, value []byte, options *optionData) (request *proto.OperationalMessage, err error) {
+	ns := c.namespaceOf(options)
+	nsConf := c.config.NamespaceDefaults[ns]
+
+	ttl := options.ttl
+	if ttl == 0 && (op == proto.OpCodeCreate || op == proto.OpCodeSet) && nsConf.DefaultTimeToLive > 0 {
+		ttl = uint32(nsConf.DefaultTimeToLive)
+	}
+	var payload proto.Payload
+	switch {
+	case len(nsConf.Compression) != 0:
+		err = payload.SetWithCompressedValue(nsConf.Compression, value)
+	case nsConf.Encryption:
+		payload.SetWithClearValue(value)
+		err = payload.Encrypt(proto.PayloadTypeEncryptedByClient)
+	default:
+		payload.SetWithClearValue(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadParam, err.Error())
+	}
+	request = &proto.OperationalMessage{}
+	request.SetRequest(op, key, []byte(ns), &payload, ttl)
+	request.SetNewRequestID()
+	if len(options.correlationId) > 0 {
+		request.SetCorrelationID([]byte(options.correlationId))
+	}
+	return
+}
+
+// setRequestOptions applies the namespace and the correlation ID of the options to the request.
+func (c *clientImplT) setRequestOptions(request *proto.OperationalMessage, options *optionData) {
+	if len(options.namespace) != 0 {
+		request.SetNamespace([]byte(options.namespace))
+	}
+	if len(options.correlationId) > 0 {
+		request.SetCorrelationID([]byte(options.correlationId))
+	}
+}
+
 // checkResponse validates the response from the server against the original request.
 func checkResponse(request *proto.OperationalMessage, response *proto.OperationalMessage, recInfo *cli.RecordInfo) (err error) {
 	opCode := request.GetOpCode()
@@ -0,0 +1,277 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package clienttest runs an embedded Juno cluster, a proxy in front of
+// in-memory storage servers, on loopback ports for application tests.
+//
+//	c, err := clienttest.Start(clienttest.Options{})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer c.Stop()
+//	cli, err := c.NewClient("myns")
+//
+// The proxy is the real one (cmd/proxy), so are the client, the protocol and
+// the quorum logic. The storage servers are NOT: the storage server code
+// (cmd/storageserv/storage) is bound to RocksDB, so the storage layer is
+// emulated in memory, see memss.go. The emulation covers the two-phase
+// commit, the record locks, the versions, TTLs and tombstones the proxy
+// relies on, the meta data reads and TTL changes, and the record history of
+// the namespaces listed in Options.History. It is a separate implementation
+// that may lag behind the storage server, and does not cover the
+// active-active conflict resolution or the storage server admin endpoints.
+// Test those against a real cluster.
+//
+// The proxy keeps its configuration and shard map in package level
+// variables, so only one Cluster can run in a process at a time.
+package clienttest
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/cmd/proxy/config"
+	"github.com/paypal/junodb/cmd/proxy/handler"
+	"github.com/paypal/junodb/cmd/proxy/stats"
+	"github.com/paypal/junodb/pkg/client"
+	"github.com/paypal/junodb/pkg/cluster"
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/service"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kDefaultNumZones       = 3
+	kDefaultNumShards      = 16
+	kDefaultLockExpiration = 600 * time.Millisecond
+	kDefaultStartTimeout   = 5 * time.Second
+)
+
+// Options configures an embedded cluster. Zero values select the defaults.
+type Options struct {
+	NumZones          int
+	NumShards         int
+	DefaultTimeToLive int
+	MaxTimeToLive     int
+	LockExpiration    time.Duration
+	StartTimeout      time.Duration
+
+	// Per namespace record history, as History of the storage server config.
+	History map[string]HistoryOptions
+}
+
+// HistoryOptions keeps the versions of the records of a namespace replaced
+// in the last MaxAge, up to MaxVersions of them. 0 means no limit, and the
+// history is disabled if both are 0.
+type HistoryOptions struct {
+	MaxVersions int
+	MaxAge      time.Duration
+}
+
+// Cluster is a running embedded cluster.
+type Cluster struct {
+	proxyAddr string
+	proxy     *service.Service
+	storages  []*service.Service
+	wg        sync.WaitGroup
+}
+
+var (
+	mtxRunning sync.Mutex
+	running    *Cluster
+)
+
+// Start brings up the storage servers and the proxy, and returns once the
+// proxy has connected to all of them.
+func Start(opts Options) (c *Cluster, err error) {
+	mtxRunning.Lock()
+	defer mtxRunning.Unlock()
+	if running != nil {
+		err = fmt.Errorf("embedded cluster already running at %s", running.proxyAddr)
+		return
+	}
+	opts.setDefaultIfNotDefined()
+
+	var addrs []string
+	if addrs, err = freeAddresses(opts.NumZones + 1); err != nil {
+		return
+	}
+	c = &Cluster{proxyAddr: addrs[0]}
+
+	connInfo := make([][]string, opts.NumZones)
+	for i := 0; i < opts.NumZones; i++ {
+		connInfo[i] = []string{addrs[i+1]}
+		var cfg service.Config
+		cfg.SetListeners([]string{addrs[i+1]})
+		svc, _ := service.NewService(cfg, newMemStorageHandler(opts.LockExpiration, opts.History))
+		c.storages = append(c.storages, svc)
+		c.run(svc)
+	}
+
+	conf := &config.Conf
+	conf.ClusterInfo.ConnInfo = connInfo
+	conf.ClusterInfo.NumZones = uint32(opts.NumZones)
+	conf.ClusterInfo.NumShards = uint32(opts.NumShards)
+	conf.DefaultTimeToLive = opts.DefaultTimeToLive
+	conf.MaxTimeToLive = opts.MaxTimeToLive
+	conf.StateLogEnabled = false
+	conf.EtcdEnabled = false
+	conf.Outbound.ConnectTimeout.Duration = opts.StartTimeout / 2
+
+	cluster.ClusterInfo[0].PopulateFromConfig()
+	stats.Initialize(stats.KTypeStandAloneWorker)
+	if err = cluster.Initialize(&cluster.ClusterInfo[0], &conf.Outbound, nil, nil, nil, nil); err != nil {
+		c.stopStorages()
+		return nil, err
+	}
+	conf.SetListeners([]string{c.proxyAddr})
+	c.proxy = handler.NewProxyService(conf)
+	c.run(c.proxy)
+
+	if err = c.waitForUp(opts.NumZones, opts.StartTimeout); err != nil {
+		c.shutdown()
+		return nil, err
+	}
+	running = c
+	return
+}
+
+// ProxyAddress returns the address the proxy listens on.
+func (c *Cluster) ProxyAddress() string {
+	return c.proxyAddr
+}
+
+// Config returns a client configuration for the given namespace that points
+// to the embedded proxy.
+func (c *Cluster) Config(namespace string) client.Config {
+	return client.Config{
+		Server:            io.ServiceEndpoint{Addr: c.proxyAddr},
+		Appname:           "clienttest",
+		Namespace:         namespace,
+		DefaultTimeToLive: config.Conf.DefaultTimeToLive,
+		ConnectTimeout:    util.Duration{1000 * time.Millisecond},
+		ReadTimeout:       util.Duration{1500 * time.Millisecond},
+		WriteTimeout:      util.Duration{1500 * time.Millisecond},
+		RequestTimeout:    util.Duration{3000 * time.Millisecond},
+	}
+}
+
+// NewClient creates a client for the given namespace.
+func (c *Cluster) NewClient(namespace string) (client.IClient, error) {
+	return client.New(c.Config(namespace))
+}
+
+// Stop shuts down the proxy and the storage servers.
+func (c *Cluster) Stop() {
+	mtxRunning.Lock()
+	defer mtxRunning.Unlock()
+	c.shutdown()
+	if running == c {
+		running = nil
+	}
+}
+
+func (c *Cluster) shutdown() {
+	if c.proxy != nil {
+		c.proxy.Shutdown()
+	}
+	cluster.Finalize()
+	c.stopStorages()
+	c.wg.Wait()
+}
+
+func (c *Cluster) stopStorages() {
+	for _, s := range c.storages {
+		s.Shutdown()
+	}
+}
+
+func (c *Cluster) run(svc *service.Service) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		svc.Run()
+	}()
+}
+
+func (c *Cluster) waitForUp(numZones int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		up := true
+		if conn, err := net.DialTimeout("tcp", c.proxyAddr, 100*time.Millisecond); err == nil {
+			conn.Close()
+		} else {
+			up = false
+		}
+		for i := 0; up && i < numZones; i++ {
+			up = cluster.GetShardMgr().IsConnected(i, 0)
+		}
+		if up {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("embedded cluster not up in %s", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (o *Options) setDefaultIfNotDefined() {
+	if o.NumZones <= 0 {
+		o.NumZones = kDefaultNumZones
+	}
+	if o.NumShards <= 0 {
+		o.NumShards = kDefaultNumShards
+	}
+	if o.DefaultTimeToLive <= 0 {
+		o.DefaultTimeToLive = 3600
+	}
+	if o.MaxTimeToLive <= 0 {
+		o.MaxTimeToLive = 3600 * 24 * 3
+	}
+	if o.LockExpiration <= 0 {
+		o.LockExpiration = kDefaultLockExpiration
+	}
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = kDefaultStartTimeout
+	}
+}
+
+// freeAddresses reserves n loopback ports. The listeners are closed before
+// returning, so there is a small window in which another process could take
+// one of them.
+func freeAddresses(n int) (addrs []string, err error) {
+	var lns []net.Listener
+	defer func() {
+		for _, ln := range lns {
+			ln.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		var ln net.Listener
+		if ln, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+			return nil, err
+		}
+		lns = append(lns, ln)
+		addrs = append(addrs, ln.Addr().String())
+	}
+	return
+}
@@ -0,0 +1,243 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package clienttest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/client"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func TestCluster(t *testing.T) {
+	c, err := Start(Options{
+		History: map[string]HistoryOptions{"history": {MaxVersions: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	if _, err = Start(Options{}); err == nil {
+		t.Error("second cluster should not start")
+	}
+	cli, err := c.NewClient("clienttest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("key")
+
+	if _, err = cli.Create(key, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Create(key, []byte("v1")); err != client.ErrUniqueKeyViolation {
+		t.Errorf("expected %s, got %v", client.ErrUniqueKeyViolation, err)
+	}
+	value, ctx, err := cli.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, []byte("v1")) || ctx.GetVersion() != 1 {
+		t.Errorf("unexpected value %q version %d", value, ctx.GetVersion())
+	}
+	if _, err = cli.Update(key, []byte("v2"), client.WithCond(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Update(key, []byte("v3"), client.WithCond(ctx)); err != client.ErrConditionViolation {
+		t.Errorf("expected %s, got %v", client.ErrConditionViolation, err)
+	}
+	if ctx, err = cli.Set(key, []byte("v4")); err != nil || ctx.GetVersion() != 3 {
+		t.Errorf("set: version %d, err %v", ctx.GetVersion(), err)
+	}
+	if err = cli.Destroy(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = cli.Get(key); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+
+	testInterceptor(t, c)
+	testNamespace(t, c)
+	testMeta(t, c)
+	testHistory(t, c)
+}
+
+func testMeta(t *testing.T, c *Cluster) {
+	cli, err := c.NewClient("clienttest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("metakey")
+	if ok, err := cli.Exists(key); ok || err != nil {
+		t.Errorf("exists before create: %t, %v", ok, err)
+	}
+	if _, err = cli.GetMeta(key); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+	if _, err = cli.Touch(key, 100); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+	if _, err = cli.Create(key, []byte("value"), client.WithTTL(100)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := cli.Exists(key); !ok || err != nil {
+		t.Errorf("exists after create: %t, %v", ok, err)
+	}
+	meta, err := cli.GetMeta(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.GetVersion() != 1 || meta.GetValueSize() != 5 || meta.GetTimeToLive() > 100 ||
+		meta.GetLastModificationTime() == 0 {
+		t.Errorf("unexpected meta: version %d, size %d, ttl %d, lmt %d", meta.GetVersion(),
+			meta.GetValueSize(), meta.GetTimeToLive(), meta.GetLastModificationTime())
+	}
+	ctx, err := cli.Touch(key, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.GetVersion() != 1 || ctx.GetTimeToLive() < 999 {
+		t.Errorf("touch: version %d, ttl %d", ctx.GetVersion(), ctx.GetTimeToLive())
+	}
+	if meta, err = cli.GetMeta(key); err != nil || meta.GetVersion() != 1 || meta.GetTimeToLive() < 999 {
+		t.Errorf("meta after touch: %v, %v", meta, err)
+	}
+}
+
+func testHistory(t *testing.T, c *Cluster) {
+	cli, err := c.NewClient("history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("histkey")
+	var times []time.Time
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		if _, err = cli.Set(key, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+		times = append(times, time.Now())
+		time.Sleep(2 * time.Millisecond)
+	}
+	for version, expected := range map[uint32]string{2: "v2", 3: "v3", 4: "v4"} {
+		v, ctx, err := cli.Get(key, client.WithVersion(version))
+		if err != nil || string(v) != expected || ctx.GetVersion() != version {
+			t.Errorf("version %d: %q, %v", version, v, err)
+		}
+	}
+	// beyond MaxVersions
+	if _, _, err = cli.Get(key, client.WithVersion(1)); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+	if v, _, err := cli.Get(key, client.WithAsOf(times[2])); err != nil || string(v) != "v3" {
+		t.Errorf("as of: %q, %v", v, err)
+	}
+	if err = cli.Destroy(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = cli.Get(key); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+	if v, _, err := cli.Get(key, client.WithVersion(4)); err != nil || string(v) != "v4" {
+		t.Errorf("version after destroy: %q, %v", v, err)
+	}
+	if _, _, err = cli.Get(key, client.WithAsOf(time.Now())); err != client.ErrNoKey {
+		t.Errorf("as of after destroy: expected %s, got %v", client.ErrNoKey, err)
+	}
+	if v, _, err := cli.Get(key, client.WithAsOf(times[3])); err != nil || string(v) != "v4" {
+		t.Errorf("as of before destroy: %q, %v", v, err)
+	}
+	// not kept for the other namespaces
+	other, err := c.NewClient("clienttest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = other.Set(key, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = other.Set(key, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = other.Get(key, client.WithVersion(1)); err != client.ErrNoKey {
+		t.Errorf("expected %s, got %v", client.ErrNoKey, err)
+	}
+}
+
+func testNamespace(t *testing.T, c *Cluster) {
+	conf := c.Config("clienttest")
+	conf.NamespaceDefaults = map[string]client.NamespaceConfig{
+		"compressed": {DefaultTimeToLive: 100, Compression: proto.SnappyCompression},
+	}
+	cli, err := client.New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("nskey")
+	value := bytes.Repeat([]byte("abc"), 100)
+	if _, err = cli.Create(key, []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := cli.Create(key, value, client.WithNamespace("compressed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.GetTimeToLive() != 100 {
+		t.Errorf("expected ttl 100, got %d", ctx.GetTimeToLive())
+	}
+	if v, _, err := cli.Get(key, client.WithNamespace("compressed")); err != nil || !bytes.Equal(v, value) {
+		t.Errorf("unexpected value %q, %v", v, err)
+	}
+	if v, _, err := cli.Get(key); err != nil || !bytes.Equal(v, []byte("v")) {
+		t.Errorf("unexpected value %q, %v", v, err)
+	}
+}
+
+func testInterceptor(t *testing.T, c *Cluster) {
+	var calls []client.Call
+	record := func(call *client.Call, next func()) {
+		next()
+		calls = append(calls, *call)
+	}
+	retry := func(call *client.Call, next func()) {
+		next()
+		if call.Err == client.ErrNoKey {
+			next()
+		}
+	}
+	cli, err := client.New(c.Config("clienttest"), client.WithInterceptor(record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cli.Set([]byte("k"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	cli.Get([]byte("none"), client.WithInterceptor(retry))
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].OpCode != proto.OpCodeSet || calls[0].Namespace != "clienttest" ||
+		calls[0].RequestBytes != 5 || calls[0].Status != proto.OpStatusNoError || calls[0].Latency <= 0 {
+		t.Errorf("unexpected call %+v", calls[0])
+	}
+	if calls[1].Retries != 1 || calls[1].Err != client.ErrNoKey {
+		t.Errorf("unexpected call %+v", calls[1])
+	}
+}
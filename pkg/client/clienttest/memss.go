@@ -0,0 +1,530 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package clienttest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/third_party/forked/golang/glog"
+
+	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+var _ io.IRequestHandler = (*memStorageHandler)(nil)
+
+// memRecord is a storage server record kept in memory.
+type memRecord struct {
+	payload              proto.Payload
+	version              uint32
+	creationTime         uint32
+	expirationTime       uint32
+	lastModificationTime uint64
+	requestId            proto.RequestId
+	originatorId         proto.RequestId
+	markedDelete         bool
+}
+
+// memPrepare is a prepared (locked, uncommitted) request.
+type memPrepare struct {
+	request   proto.OperationalMessage
+	rec       memRecord
+	recExist  bool
+	expiresAt time.Time
+}
+
+// memStorageHandler emulates the two-phase protocol of the storage server
+// (cmd/storageserv/storage) with an in-memory map. It is not meant to be fast
+// or durable, only to let the proxy run unchanged in tests. It does not share
+// code with the storage server, so a change to the processing of the storage
+// server, e.g. in storage.go, needs the same change here for the tests to see
+// it.
+type memStorageHandler struct {
+	mtx            sync.Mutex
+	records        map[string]*memRecord
+	prepared       map[string]*memPrepare
+	history        map[string][]memRecord // the oldest first
+	historyConf    map[string]HistoryOptions
+	lockExpiration time.Duration
+}
+
+func newMemStorageHandler(lockExpiration time.Duration, historyConf map[string]HistoryOptions) *memStorageHandler {
+	return &memStorageHandler{
+		records:        make(map[string]*memRecord),
+		prepared:       make(map[string]*memPrepare),
+		history:        make(map[string][]memRecord),
+		historyConf:    historyConf,
+		lockExpiration: lockExpiration,
+	}
+}
+
+func (h *memStorageHandler) Init() {
+}
+
+func (h *memStorageHandler) Finish() {
+}
+
+func (h *memStorageHandler) GetReqCtxCreator() io.InboundRequestContextCreator {
+	return io.ExtendedRequestContexCreator
+}
+
+func (h *memStorageHandler) OnKeepAlive(connector *io.Connector, reqCtx io.IRequestContext) (err error) {
+	connector.OnKeepAlive()
+	return
+}
+
+func (h *memStorageHandler) Process(reqCtx io.IRequestContext) error {
+	var req proto.OperationalMessage
+	if err := req.Decode(reqCtx.GetMessage()); err != nil {
+		return err
+	}
+	opcode := req.GetOpCode()
+	if opcode == proto.OpCodeNop || opcode == proto.OpCodeVerHandshake {
+		reqCtx.Reply(io.NewInboundRespose(opcode, reqCtx.GetMessage()))
+		return nil
+	}
+	var resp *proto.OperationalMessage
+	if len(req.GetNamespace()) == 0 || len(req.GetKey()) == 0 || !req.IsRequestIDSet() {
+		resp = newStatusResponse(&req, proto.OpStatusBadParam)
+	} else {
+		h.mtx.Lock()
+		resp = h.process(&req)
+		h.mtx.Unlock()
+	}
+	if glog.LOG_DEBUG {
+		glog.Debugf("memss %s -> %s", opcode.String(), resp.GetOpStatus().String())
+	}
+	r, err := io.NewInboundResponseContext(resp)
+	if err == nil {
+		reqCtx.Reply(r)
+	}
+	reqCtx.OnComplete()
+	return err
+}
+
+func (h *memStorageHandler) process(req *proto.OperationalMessage) *proto.OperationalMessage {
+	key := string(req.GetNamespace()) + "\x00" + string(req.GetKey())
+	now := time.Now()
+
+	if p, ok := h.prepared[key]; ok && now.After(p.expiresAt) {
+		delete(h.prepared, key)
+	}
+	rec, exist := h.records[key]
+	if exist && rec.expirationTime <= uint32(now.Unix()) {
+		delete(h.records, key)
+		rec, exist = nil, false
+	}
+
+	switch req.GetOpCode() {
+	case proto.OpCodeRead:
+		if req.IsHistoryRead() {
+			return h.readHistory(key, req, rec)
+		}
+		return h.read(key, req, rec)
+	case proto.OpCodeReadExists, proto.OpCodeReadMeta:
+		if rec == nil {
+			return newStatusResponse(req, proto.OpStatusNoKey)
+		}
+		resp := newResponse(req, recordStatus(rec), rec)
+		if req.GetOpCode() == proto.OpCodeReadMeta {
+			resp.SetValueSize(rec.payload.GetValueLength())
+		}
+		return resp
+	case proto.OpCodeSetTTL:
+		return h.setTTL(key, req, rec)
+	case proto.OpCodePrepareCreate, proto.OpCodePrepareUpdate, proto.OpCodePrepareSet, proto.OpCodePrepareDelete:
+		return h.prepare(key, req, rec, now)
+	case proto.OpCodeCommit:
+		return h.commit(key, req, rec)
+	case proto.OpCodeAbort:
+		if p, ok := h.prepared[key]; ok && p.request.GetRequestID().Equal(req.GetRequestID()) {
+			delete(h.prepared, key)
+			return newStatusResponse(req, proto.OpStatusNoError)
+		}
+		return newStatusResponse(req, proto.OpStatusNoUncommitted)
+	case proto.OpCodeMarkDelete:
+		return h.markDelete(key, req, rec)
+	case proto.OpCodeDelete:
+		if h.isLockedByOther(key, req) {
+			return newStatusResponse(req, proto.OpStatusRecordLocked)
+		}
+		if !exist {
+			return newStatusResponse(req, proto.OpStatusNoKey)
+		}
+		h.saveHistory(key, req, rec, true)
+		delete(h.records, key)
+		return newResponse(req, proto.OpStatusNoError, rec)
+	case proto.OpCodeRepair, proto.OpCodeClone:
+		if h.isLockedByOther(key, req) {
+			return newStatusResponse(req, proto.OpStatusRecordLocked)
+		}
+		if exist {
+			h.saveHistory(key, req, rec, false)
+		}
+		r := &memRecord{
+			version:              req.GetVersion(),
+			creationTime:         req.GetCreationTime(),
+			expirationTime:       req.GetExpirationTime(),
+			lastModificationTime: req.GetLastModificationTime(),
+			requestId:            req.GetRequestID(),
+			originatorId:         req.GetOriginatorRequestID(),
+			markedDelete:         req.GetFlags().IsFlagMarkDeleteSet(),
+		}
+		r.payload.Set(req.GetPayload())
+		r.payload.Clone()
+		h.records[key] = r
+		return newStatusResponse(req, proto.OpStatusNoError)
+	}
+	return newStatusResponse(req, proto.OpStatusServiceDenied)
+}
+
+func (h *memStorageHandler) isLockedByOther(key string, req *proto.OperationalMessage) bool {
+	p, ok := h.prepared[key]
+	return ok && !p.request.GetRequestID().Equal(req.GetRequestID())
+}
+
+func (h *memStorageHandler) read(key string, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	if rec == nil {
+		return newStatusResponse(req, proto.OpStatusNoKey)
+	}
+	status := proto.OpStatusNoError
+	if rec.markedDelete {
+		status = proto.OpStatusKeyMarkedDelete
+	} else if exp := req.GetExpirationTime(); exp > 0 && exp > rec.expirationTime {
+		if h.isLockedByOther(key, req) {
+			status = proto.OpStatusSSReadTTLExtendErr
+		} else {
+			rec.expirationTime = exp
+		}
+	}
+	resp := newResponse(req, status, rec)
+	resp.SetPayload(&rec.payload)
+	return resp
+}
+
+// readHistory replies with the latest version matching the version and the
+// as of time of the request, out of the record and its history, the same way
+// as readHistory of the storage server.
+func (h *memStorageHandler) readHistory(key string, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	now := uint32(time.Now().Unix())
+	var found *memRecord
+	match := func(r *memRecord) {
+		if r.expirationTime <= now {
+			return
+		}
+		if v := req.GetReadVersion(); v != 0 && (r.version != v || r.markedDelete) {
+			return
+		}
+		if asOf := req.GetAsOfTime(); asOf == 0 || r.lastModificationTime <= asOf {
+			found = r
+		}
+	}
+	hist := h.history[key]
+	for i := range hist {
+		match(&hist[i])
+	}
+	if rec != nil {
+		match(rec)
+	}
+	if found == nil {
+		return newStatusResponse(req, proto.OpStatusNoKey)
+	}
+	resp := newResponse(req, recordStatus(found), found)
+	resp.SetPayload(&found.payload)
+	return resp
+}
+
+// setTTL replaces the expiration time of the record, keeping its version.
+func (h *memStorageHandler) setTTL(key string, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	if req.GetExpirationTime() == 0 {
+		return newStatusResponse(req, proto.OpStatusBadParam)
+	}
+	if h.isLockedByOther(key, req) {
+		return newStatusResponse(req, proto.OpStatusRecordLocked)
+	}
+	if rec == nil {
+		return newStatusResponse(req, proto.OpStatusNoKey)
+	}
+	if !rec.markedDelete {
+		rec.expirationTime = req.GetExpirationTime()
+	}
+	return newResponse(req, recordStatus(rec), rec)
+}
+
+// saveHistory keeps rec as a history entry of the key before it is replaced,
+// or deleted if del is set, when the history is enabled for the namespace.
+// As RocksDB.SaveHistory, a deletion also keeps a marked delete entry, and
+// the entries beyond MaxVersions or replaced longer than MaxAge ago are
+// removed.
+func (h *memStorageHandler) saveHistory(key string, req *proto.OperationalMessage, rec *memRecord, del bool) {
+	conf, ok := h.historyConf[string(req.GetNamespace())]
+	if !ok || (conf.MaxVersions <= 0 && conf.MaxAge <= 0) {
+		return
+	}
+	now := time.Now()
+	entries := append(h.history[key], *rec)
+	if del {
+		lmt := uint64(now.UnixNano())
+		if req.IsOriginClusterIdSet() && req.GetLastModificationTime() != 0 {
+			lmt = req.GetLastModificationTime()
+		}
+		if lmt > rec.lastModificationTime {
+			tombstone := *rec
+			tombstone.payload = proto.Payload{}
+			tombstone.lastModificationTime = lmt
+			tombstone.requestId = req.GetRequestID()
+			tombstone.markedDelete = true
+			entries = append(entries, tombstone)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].lastModificationTime < entries[j].lastModificationTime
+	})
+	// an entry replaces the one with the same modification time
+	kept := entries[:0]
+	for i, e := range entries {
+		if i+1 < len(entries) && entries[i+1].lastModificationTime == e.lastModificationTime {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	numRemoved := 0
+	if conf.MaxVersions > 0 && len(kept) > conf.MaxVersions {
+		numRemoved = len(kept) - conf.MaxVersions
+	}
+	if conf.MaxAge > 0 {
+		oldest := uint64(now.Add(-conf.MaxAge).UnixNano())
+		for numRemoved < len(kept)-1 && kept[numRemoved+1].lastModificationTime < oldest {
+			numRemoved++
+		}
+	}
+	h.history[key] = append([]memRecord(nil), kept[numRemoved:]...)
+}
+
+func (h *memStorageHandler) prepare(key string, req *proto.OperationalMessage, rec *memRecord, now time.Time) *proto.OperationalMessage {
+	if p, ok := h.prepared[key]; ok {
+		if p.request.GetRequestID().Equal(req.GetRequestID()) {
+			return newStatusResponse(req, proto.OpStatusAlreadyFulfilled)
+		}
+		return newStatusResponse(req, proto.OpStatusRecordLocked)
+	}
+	p := &memPrepare{
+		request:   *req,
+		recExist:  rec != nil,
+		expiresAt: now.Add(h.lockExpiration),
+	}
+	// the payload refers to the connection's read buffer
+	p.request.GetPayload().Clone()
+	if rec != nil {
+		p.rec = *rec
+	}
+	var resp *proto.OperationalMessage
+	switch req.GetOpCode() {
+	case proto.OpCodePrepareCreate:
+		if rec != nil && !rec.markedDelete {
+			if rec.requestId.Equal(req.GetRequestID()) {
+				return newResponse(req, proto.OpStatusAlreadyFulfilled, rec)
+			}
+			return newResponse(req, proto.OpStatusDupKey, rec)
+		}
+		st := proto.OpStatusNoError
+		if rec != nil {
+			st = proto.OpStatusInserting
+			p.recExist = false
+			p.rec = memRecord{}
+		}
+		resp = newStatusResponse(req, st)
+	case proto.OpCodePrepareUpdate, proto.OpCodePrepareSet:
+		resp = h.prepareUpdate(p, req, rec)
+	case proto.OpCodePrepareDelete:
+		if rec == nil {
+			resp = newStatusResponse(req, proto.OpStatusNoKey)
+		} else {
+			resp = newResponse(req, proto.OpStatusNoError, rec)
+		}
+	}
+	switch resp.GetOpStatus() {
+	case proto.OpStatusNoError, proto.OpStatusInserting:
+		h.prepared[key] = p
+	case proto.OpStatusNoKey:
+		if req.GetOpCode() == proto.OpCodePrepareDelete {
+			h.prepared[key] = p
+		}
+	}
+	return resp
+}
+
+func (h *memStorageHandler) prepareUpdate(p *memPrepare, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	if rec == nil {
+		return newUpdateResponse(req, proto.OpStatusInserting, 0, req.GetExpirationTime(), req.GetCreationTime())
+	}
+	if rec.requestId.Equal(req.GetRequestID()) {
+		return newResponse(req, proto.OpStatusAlreadyFulfilled, rec)
+	}
+	if rec.markedDelete {
+		if req.GetVersion() > 0 {
+			p.recExist = false
+			p.rec = memRecord{}
+			return newUpdateResponse(req, proto.OpStatusInserting, 0, req.GetExpirationTime(), req.GetCreationTime())
+		}
+		return newResponse(req, proto.OpStatusInserting, rec)
+	}
+	if req.GetVersion() > 0 && !req.IsForReplication() {
+		if req.GetVersion() < rec.version ||
+			(req.GetCreationTime() != 0 && req.GetCreationTime() != rec.creationTime) ||
+			(req.GetOriginatorRequestID().IsSet() && !req.GetOriginatorRequestID().Equal(rec.originatorId)) {
+			resp := newResponse(req, proto.OpStatusVersionConflict, rec)
+			resp.SetPayload(&rec.payload)
+			return resp
+		}
+	}
+	return newResponse(req, proto.OpStatusNoError, rec)
+}
+
+func (h *memStorageHandler) commit(key string, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	p, ok := h.prepared[key]
+	if !ok || !p.request.GetRequestID().Equal(req.GetRequestID()) {
+		if rec != nil && rec.requestId.Equal(req.GetRequestID()) {
+			return newResponse(req, proto.OpStatusAlreadyFulfilled, rec)
+		}
+		return newStatusResponse(req, proto.OpStatusNoUncommitted)
+	}
+	delete(h.prepared, key)
+
+	prepOpCode := p.request.GetOpCode()
+	if prepOpCode == proto.OpCodePrepareDelete {
+		if rec != nil {
+			h.saveHistory(key, req, rec, true)
+		}
+		delete(h.records, key)
+		return newStatusResponse(req, proto.OpStatusNoError)
+	}
+	if req.GetCreationTime() == 0 {
+		return newStatusResponse(req, proto.OpStatusBadParam)
+	}
+	if rec != nil {
+		h.saveHistory(key, req, rec, false)
+	}
+	r := p.rec
+	r.requestId = req.GetRequestID()
+	r.creationTime = req.GetCreationTime()
+	r.version = req.GetVersion()
+	r.lastModificationTime = req.GetLastModificationTime()
+	r.payload.Set(p.request.GetPayload())
+	if req.IsOriginatorSet() {
+		r.originatorId = req.GetOriginatorRequestID()
+	} else if !p.recExist {
+		r.originatorId = r.requestId
+	}
+	if exp := req.GetExpirationTime(); exp > r.expirationTime && exp > util.Now() {
+		r.expirationTime = exp
+	}
+	r.markedDelete = false
+	h.records[key] = &r
+
+	resp := newResponse(req, proto.OpStatusNoError, &r)
+	resp.SetExpirationTime(r.expirationTime)
+	return resp
+}
+
+func (h *memStorageHandler) markDelete(key string, req *proto.OperationalMessage, rec *memRecord) *proto.OperationalMessage {
+	if p, ok := h.prepared[key]; ok {
+		if !p.request.GetRequestID().Equal(req.GetRequestID()) {
+			return newStatusResponse(req, proto.OpStatusRecordLocked)
+		}
+		delete(h.prepared, key)
+	}
+	r := memRecord{}
+	if rec != nil {
+		h.saveHistory(key, req, rec, false)
+		r = *rec
+		r.version++
+	} else {
+		r.creationTime = req.GetCreationTime()
+		r.originatorId = req.GetOriginatorRequestID()
+		r.expirationTime = util.GetExpirationTime(req.GetTimeToLive())
+		r.version = 1
+	}
+	if req.GetVersion() != 0 {
+		r.version = req.GetVersion()
+	}
+	r.requestId = req.GetRequestID()
+	r.lastModificationTime = uint64(time.Now().UnixNano())
+	r.payload.Clear()
+	r.markedDelete = true
+	h.records[key] = &r
+	return newStatusResponse(req, proto.OpStatusNoError)
+}
+
+// recordStatus returns the status of a read of the record.
+func recordStatus(rec *memRecord) proto.OpStatus {
+	if rec.markedDelete {
+		return proto.OpStatusKeyMarkedDelete
+	}
+	return proto.OpStatusNoError
+}
+
+// newStatusResponse creates a response carrying only the status.
+func newStatusResponse(req *proto.OperationalMessage, st proto.OpStatus) *proto.OperationalMessage {
+	resp := &proto.OperationalMessage{}
+	resp.SetOpCode(req.GetOpCode())
+	resp.SetOpaque(req.GetOpaque())
+	resp.SetKey(req.GetKey())
+	resp.SetNamespace(req.GetNamespace())
+	resp.SetRequestID(req.GetRequestID())
+	resp.SetAsResponse()
+	resp.SetOpStatus(st)
+	return resp
+}
+
+// newResponse creates a response with the meta data of the given record.
+func newResponse(req *proto.OperationalMessage, st proto.OpStatus, rec *memRecord) *proto.OperationalMessage {
+	resp := newUpdateResponse(req, st, rec.version, rec.expirationTime, rec.creationTime)
+	resp.SetOriginatorRequestID(rec.originatorId)
+	resp.SetLastModificationTime(rec.lastModificationTime)
+	return resp
+}
+
+// newUpdateResponse sets version, TTL and creation time the same way as
+// reqProcCtxT.initResponse of the storage server.
+func newUpdateResponse(req *proto.OperationalMessage, st proto.OpStatus, version uint32, expTime uint32, creationTime uint32) *proto.OperationalMessage {
+	resp := newStatusResponse(req, st)
+	resp.SetVersion(version)
+	resp.SetCreationTime(creationTime)
+
+	remaining := util.GetTimeToLive(expTime)
+	switch req.GetOpCode() {
+	case proto.OpCodePrepareUpdate, proto.OpCodePrepareSet:
+		if req.GetTimeToLive() > remaining {
+			resp.SetTimeToLive(req.GetTimeToLive())
+		} else {
+			resp.SetTimeToLive(remaining)
+		}
+	case proto.OpCodeRead, proto.OpCodeReadExists, proto.OpCodeReadMeta, proto.OpCodeSetTTL:
+		resp.SetTimeToLive(remaining)
+	default:
+		resp.SetTimeToLive(req.GetTimeToLive())
+	}
+	resp.SetExpirationTime(util.GetExpirationTime(resp.GetTimeToLive()))
+	return resp
+}
@@ -0,0 +1,114 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals values stored by Typed.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSONCodec encodes values with encoding/json.
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec encodes values with encoding/gob.
+	GobCodec Codec = gobCodec{}
+	// ProtoCodec encodes protobuf messages. The value type of Typed must be a
+	// pointer to a generated message type, e.g. Typed[*pb.Account].
+	ProtoCodec Codec = protoCodec{}
+)
+
+// CodecFuncs adapts a pair of marshal functions to Codec. It is meant for
+// encodings the module does not depend on, e.g. MessagePack with
+// github.com/vmihailenco/msgpack/v5:
+//
+//	codec := client.CodecFuncs{MarshalFunc: msgpack.Marshal, UnmarshalFunc: msgpack.Unmarshal}
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+func (c CodecFuncs) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+func (c CodecFuncs) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal accepts either a message or a pointer to a message pointer. The
+// latter is allocated if nil.
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Ptr {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		v = rv.Elem().Interface()
+	}
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
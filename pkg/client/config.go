@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/paypal/junodb/pkg/io"
+	"github.com/paypal/junodb/pkg/proto"
 	"github.com/paypal/junodb/pkg/util"
 )
 
@@ -31,16 +32,27 @@ type Duration = util.Duration
 
 // Config holds the configuration values for the Juno client.
 type Config struct {
-	Server             io.ServiceEndpoint // Server defines the ServiceEndpoint of the Juno server.
-	Appname            string             // Appname is the name of the application.
-	Namespace          string             // Namespace is the namespace of the application.
-	RetryCount         int                // RetryCount is the maximum number of retries.
-	DefaultTimeToLive  int                // DefaultTimeToLive is the default TTL (time to live) for requests.
-	ConnectTimeout     Duration           // ConnectTimeout is the timeout for establishing connections.
-	ReadTimeout        Duration           // ReadTimeout is the timeout for read operations.
-	WriteTimeout       Duration           // WriteTimeout is the timeout for write operations.
-	RequestTimeout     Duration           // RequestTimeout is the timeout for each request.
-	ConnRecycleTimeout Duration           // ConnRecycleTimeout is the timeout for connection recycling.
+	Server             io.ServiceEndpoint         // Server defines the ServiceEndpoint of the Juno server.
+	Appname            string                     // Appname is the name of the application.
+	Namespace          string                     // Namespace is the namespace of the application.
+	RetryCount         int                        // RetryCount is the maximum number of retries.
+	DefaultTimeToLive  int                        // DefaultTimeToLive is the default TTL (time to live) for requests.
+	ConnectTimeout     Duration                   // ConnectTimeout is the timeout for establishing connections.
+	ReadTimeout        Duration                   // ReadTimeout is the timeout for read operations.
+	WriteTimeout       Duration                   // WriteTimeout is the timeout for write operations.
+	RequestTimeout     Duration                   // RequestTimeout is the timeout for each request.
+	ConnRecycleTimeout Duration                   // ConnRecycleTimeout is the timeout for connection recycling.
+	NearCache          NearCacheConfig            // NearCache configures the optional in-process cache of Get results.
+	Chunking           ChunkingConfig             // Chunking configures the optional storage of large values in chunks.
+	NamespaceDefaults  map[string]NamespaceConfig // NamespaceDefaults holds per-namespace settings, keyed by namespace.
+}
+
+// NamespaceConfig holds the settings applied to the requests of a namespace,
+// whether it is Config.Namespace or one given with WithNamespace.
+type NamespaceConfig struct {
+	DefaultTimeToLive int    // DefaultTimeToLive is the TTL of Create and Set when WithTTL is not given. Zero lets the proxy decide.
+	Compression       string // Compression is the algorithm compressing values on the client, "" or proto.SnappyCompression.
+	Encryption        bool   // Encryption encrypts values on the client with the key store set by proto.InitializeKeyStore.
 }
 
 // defaultConfig defines the default configuration values.
@@ -86,6 +98,28 @@ func (c *Config) validate() error {
 	if len(c.Namespace) == 0 {
 		return fmt.Errorf("Config.Namespace not specified.")
 	}
+	if c.Chunking.ChunkSize < 0 || c.Chunking.MaxValueSize < 0 {
+		return fmt.Errorf("negative Config.Chunking size")
+	}
+	for ns, nsConf := range c.NamespaceDefaults {
+		if err := nsConf.validate(); err != nil {
+			return fmt.Errorf("Config.NamespaceDefaults[%s]: %s", ns, err.Error())
+		}
+	}
 	// TODO to validate others
 	return nil
 }
+
+// validate checks the namespace settings.
+func (c *NamespaceConfig) validate() error {
+	if c.DefaultTimeToLive < 0 {
+		return fmt.Errorf("negative DefaultTimeToLive")
+	}
+	if len(c.Compression) != 0 && c.Compression != proto.SnappyCompression {
+		return fmt.Errorf("unsupported compression %s", c.Compression)
+	}
+	if len(c.Compression) != 0 && c.Encryption {
+		return fmt.Errorf("compression and encryption cannot be used together")
+	}
+	return nil
+}
@@ -41,6 +41,7 @@ var (
 	ErrWriteFailure   error // Error when a write operation fails.
 	ErrInternal       error // Error when an internal problem occurs.
 	ErrOpNotSupported error // Error when the operation is not supported.
+	ErrCorruptedValue error // Error when a chunked value is missing a chunk or fails the integrity check.
 )
 
 // errorMapping is a map between different operation status and their corresponding errors.
@@ -62,6 +63,7 @@ func init() {
 	ErrWriteFailure = &cli.Error{"write failure"}      // Error when a write operation fails.
 	ErrInternal = &cli.Error{"internal error"}         // Error when an internal error occurs.
 	ErrOpNotSupported = &cli.Error{"Op not supported"} // Error when the operation is not supported.
+	ErrCorruptedValue = &cli.Error{"corrupted value"}  // Error when a chunked value cannot be reassembled.
 
 	// Mapping between the operation status and the corresponding errors.
 	errorMapping = map[proto.OpStatus]error{
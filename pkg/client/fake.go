@@ -0,0 +1,549 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/internal/cli"
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/udf"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+// kFakeMaxTimeToLive mirrors the proxy default of MaxTimeToLive.
+const kFakeMaxTimeToLive = 3600 * 24 * 3
+
+var _ IClient = (*FakeClient)(nil)
+
+// Fault describes an error and/or latency to be injected into FakeClient operations.
+type Fault struct {
+	OpCode  proto.OpCode  // OpCode the fault applies to. OpCodeNop applies to all operations.
+	Latency time.Duration // Latency is added before the operation is handled.
+	Err     error         // Err is returned instead of handling the operation, e.g. ErrBusy, ErrNoStorage.
+	Count   int           // Count is the number of operations affected. Zero means until cleared.
+}
+
+// fakeRecord is a record kept by FakeClient.
+type fakeRecord struct {
+	value                []byte
+	version              uint32
+	creationTime         uint32
+	expirationTime       uint32
+	lastModificationTime uint64
+	originatorId         proto.RequestId
+}
+
+// FakeClient is an in-memory implementation of IClient for application unit tests.
+// It emulates record versions, TTL expiration, conditional update with WithCond,
+// Create unique key violation and UDFs without a proxy or storage servers.
+type FakeClient struct {
+	mtx        sync.Mutex
+	namespace  string
+	defaultTTL uint32
+	nsDefaults map[string]NamespaceConfig
+	records    map[string]*fakeRecord
+	udfs       map[string]udf.IUDF
+	faults     []*Fault
+	now        func() time.Time
+}
+
+// NewFakeClient creates a FakeClient. Only Namespace, DefaultTimeToLive and the
+// TTL of NamespaceDefaults of the configuration are used.
+func NewFakeClient(conf Config) *FakeClient {
+	ttl := conf.DefaultTimeToLive
+	if ttl <= 0 {
+		ttl = defaultConfig.DefaultTimeToLive
+	}
+	c := &FakeClient{
+		namespace:  conf.Namespace,
+		defaultTTL: uint32(ttl),
+		nsDefaults: conf.NamespaceDefaults,
+		records:    make(map[string]*fakeRecord),
+		udfs:       make(map[string]udf.IUDF),
+		now:        time.Now,
+	}
+	c.RegisterUDF(&udf.CounterUDF{})
+	return c
+}
+
+// RegisterUDF makes a UDF available to UDFGet and UDFSet under its name.
+func (c *FakeClient) RegisterUDF(u udf.IUDF) {
+	c.mtx.Lock()
+	c.udfs[u.GetName()] = u
+	c.mtx.Unlock()
+}
+
+// SetClock replaces the time source used for TTL handling. It allows tests to
+// expire records without sleeping.
+func (c *FakeClient) SetClock(now func() time.Time) {
+	c.mtx.Lock()
+	c.now = now
+	c.mtx.Unlock()
+}
+
+// InjectFault adds a fault. Faults are evaluated in the order they are injected.
+func (c *FakeClient) InjectFault(f Fault) {
+	c.mtx.Lock()
+	c.faults = append(c.faults, &f)
+	c.mtx.Unlock()
+}
+
+// ClearFaults removes all the injected faults.
+func (c *FakeClient) ClearFaults() {
+	c.mtx.Lock()
+	c.faults = nil
+	c.mtx.Unlock()
+}
+
+// Reset removes all the records and faults.
+func (c *FakeClient) Reset() {
+	c.mtx.Lock()
+	c.records = make(map[string]*fakeRecord)
+	c.faults = nil
+	c.mtx.Unlock()
+}
+
+// Len returns the number of unexpired records.
+func (c *FakeClient) Len() (n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	now := c.nowUnix()
+	for _, rec := range c.records {
+		if !rec.isExpired(now) {
+			n++
+		}
+	}
+	return
+}
+
+// Create adds a record if the key does not exist.
+func (c *FakeClient) Create(key []byte, value []byte, opts ...IOption) (context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeCreate); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	k := recordKey(ns, key)
+	if rec, ok := c.records[k]; ok && !rec.isExpired(now) {
+		return rec.context(now), ErrUniqueKeyViolation
+	}
+	ttl := options.ttl
+	if ttl == 0 {
+		ttl = c.defaultTTLOf(ns)
+	}
+	rec := &fakeRecord{
+		value:                copyBytes(value),
+		version:              1,
+		creationTime:         now,
+		expirationTime:       now + ttl,
+		lastModificationTime: c.nowNano(),
+	}
+	rec.originatorId.SetNewRequestId()
+	c.records[k] = rec
+	context = rec.context(now)
+	return
+}
+
+// Get returns the value of an unexpired record. A TTL given with WithTTL extends
+// the record lifetime if it is longer than the remaining one. No history is
+// kept, so a Get WithVersion finds the record only if it is the current version,
+// and a Get WithAsOf returns the current version.
+func (c *FakeClient) Get(key []byte, opts ...IOption) (value []byte, context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeGet); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok || (options.readVersion != 0 && options.readVersion != rec.version) {
+		err = ErrNoKey
+		return
+	}
+	if !options.isHistoryRead() {
+		rec.extendTTL(now, options.ttl)
+	}
+	value = copyBytes(rec.value)
+	context = rec.context(now)
+	return
+}
+
+// Update modifies an existing record. If a context is given with WithCond, the
+// update fails with ErrConditionViolation when the record has been modified since.
+func (c *FakeClient) Update(key []byte, value []byte, opts ...IOption) (context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeUpdate); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok {
+		err = ErrNoKey
+		return
+	}
+	if cond := options.context; cond != nil {
+		if (cond.GetVersion() != 0 && cond.GetVersion() < rec.version) ||
+			(cond.GetCreationTime() != 0 && cond.GetCreationTime() != rec.creationTime) {
+			context = rec.context(now)
+			err = ErrConditionViolation
+			return
+		}
+		if r, ok := cond.(*cli.RecordInfo); ok && r.IsOriginatorSet() && !r.IsSameOriginator(rec.recordInfo(now)) {
+			context = rec.context(now)
+			err = ErrConditionViolation
+			return
+		}
+	}
+	rec.value = copyBytes(value)
+	rec.version++
+	rec.lastModificationTime = c.nowNano()
+	rec.extendTTL(now, options.ttl)
+	context = rec.context(now)
+	return
+}
+
+// Set creates a record or overwrites the value of an existing one.
+func (c *FakeClient) Set(key []byte, value []byte, opts ...IOption) (context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeSet); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	if rec, ok := c.lookup(ns, key, now); ok {
+		rec.value = copyBytes(value)
+		rec.version++
+		rec.lastModificationTime = c.nowNano()
+		rec.extendTTL(now, options.ttl)
+		context = rec.context(now)
+		return
+	}
+	ttl := options.ttl
+	if ttl == 0 {
+		ttl = c.defaultTTLOf(ns)
+	}
+	rec := &fakeRecord{
+		value:                copyBytes(value),
+		version:              1,
+		creationTime:         now,
+		expirationTime:       now + ttl,
+		lastModificationTime: c.nowNano(),
+	}
+	rec.originatorId.SetNewRequestId()
+	c.records[recordKey(ns, key)] = rec
+	context = rec.context(now)
+	return
+}
+
+// Destroy removes a record. Destroying a key that does not exist is not an error.
+func (c *FakeClient) Destroy(key []byte, opts ...IOption) (err error) {
+	ns := c.namespaceOf(newOptionData(opts...))
+	if err = c.applyFault(proto.OpCodeDestroy); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, 0); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	delete(c.records, recordKey(ns, key))
+	c.mtx.Unlock()
+	return
+}
+
+// UDFGet applies the UDF to the stored value and returns the result without
+// modifying the record.
+func (c *FakeClient) UDFGet(key []byte, fname []byte, params []byte, opts ...IOption) (value []byte, context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeUDFGet); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok {
+		err = ErrNoKey
+		return
+	}
+	u, found := c.udfs[string(fname)]
+	if !found {
+		err = ErrBadParam
+		return
+	}
+	if value, err = u.Call(key, rec.value, params); err != nil {
+		err = ErrBadParam
+		return
+	}
+	rec.extendTTL(now, options.ttl)
+	context = rec.context(now)
+	return
+}
+
+// UDFSet applies the UDF to the stored value and stores the result.
+func (c *FakeClient) UDFSet(key []byte, fname []byte, params []byte, opts ...IOption) (context IContext, err error) {
+	options := newOptionData(opts...)
+	ns := c.namespaceOf(options)
+	if err = c.applyFault(proto.OpCodeUDFSet); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, options.ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok {
+		err = ErrNoKey
+		return
+	}
+	u, found := c.udfs[string(fname)]
+	if !found {
+		err = ErrBadParam
+		return
+	}
+	var value []byte
+	if value, err = u.Call(key, rec.value, params); err != nil {
+		err = ErrBadParam
+		return
+	}
+	rec.value = value
+	rec.version++
+	rec.lastModificationTime = c.nowNano()
+	rec.extendTTL(now, options.ttl)
+	context = rec.context(now)
+	return
+}
+
+// Exists tells if an unexpired record exists.
+func (c *FakeClient) Exists(key []byte, opts ...IOption) (exists bool, err error) {
+	ns := c.namespaceOf(newOptionData(opts...))
+	if err = c.applyFault(proto.OpCodeExists); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, 0); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	_, exists = c.lookup(ns, key, c.nowUnix())
+	return
+}
+
+// GetMeta returns the metadata of an unexpired record.
+func (c *FakeClient) GetMeta(key []byte, opts ...IOption) (meta IMeta, err error) {
+	ns := c.namespaceOf(newOptionData(opts...))
+	if err = c.applyFault(proto.OpCodeGetMeta); err != nil {
+		return
+	}
+	if err = c.validate(ns, key, 0); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok {
+		err = ErrNoKey
+		return
+	}
+	meta = rec.recordInfo(now)
+	return
+}
+
+// Touch replaces the TTL of an unexpired record. Unlike WithTTL, the TTL may
+// be shortened.
+func (c *FakeClient) Touch(key []byte, ttl uint32, opts ...IOption) (context IContext, err error) {
+	ns := c.namespaceOf(newOptionData(opts...))
+	if err = c.applyFault(proto.OpCodeTouch); err != nil {
+		return
+	}
+	if ttl == 0 {
+		err = ErrBadParam
+		return
+	}
+	if err = c.validate(ns, key, ttl); err != nil {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := c.nowUnix()
+	rec, ok := c.lookup(ns, key, now)
+	if !ok {
+		err = ErrNoKey
+		return
+	}
+	rec.expirationTime = now + ttl
+	context = rec.context(now)
+	return
+}
+
+// applyFault sleeps and/or returns an error as specified by the first matching fault.
+func (c *FakeClient) applyFault(op proto.OpCode) error {
+	c.mtx.Lock()
+	var fault Fault
+	matched := false
+	for i, f := range c.faults {
+		if f.OpCode != proto.OpCodeNop && f.OpCode != op {
+			continue
+		}
+		fault = *f
+		matched = true
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				c.faults = append(c.faults[:i], c.faults[i+1:]...)
+			}
+		}
+		break
+	}
+	c.mtx.Unlock()
+
+	if !matched {
+		return nil
+	}
+	if fault.Latency > 0 {
+		time.Sleep(fault.Latency)
+	}
+	return fault.Err
+}
+
+// validate checks the request the same way the proxy does.
+func (c *FakeClient) validate(ns string, key []byte, ttl uint32) error {
+	if len(key) == 0 || len(ns) == 0 || ttl > kFakeMaxTimeToLive {
+		return ErrBadParam
+	}
+	return nil
+}
+
+func (c *FakeClient) defaultNamespace() string {
+	return c.namespace
+}
+
+// namespaceOf returns the namespace given by WithNamespace or the client one.
+func (c *FakeClient) namespaceOf(options *optionData) string {
+	if len(options.namespace) != 0 {
+		return options.namespace
+	}
+	return c.namespace
+}
+
+// defaultTTLOf returns the default TTL of Create and Set in the namespace.
+func (c *FakeClient) defaultTTLOf(ns string) uint32 {
+	if nsConf, ok := c.nsDefaults[ns]; ok && nsConf.DefaultTimeToLive > 0 {
+		return uint32(nsConf.DefaultTimeToLive)
+	}
+	return c.defaultTTL
+}
+
+func recordKey(ns string, key []byte) string {
+	return ns + "\x00" + string(key)
+}
+
+func (c *FakeClient) lookup(ns string, key []byte, now uint32) (rec *fakeRecord, ok bool) {
+	k := recordKey(ns, key)
+	if rec, ok = c.records[k]; ok && rec.isExpired(now) {
+		delete(c.records, k)
+		rec, ok = nil, false
+	}
+	return
+}
+
+func (c *FakeClient) nowUnix() uint32 {
+	return uint32(c.now().Unix())
+}
+
+func (c *FakeClient) nowNano() uint64 {
+	return uint64(c.now().UnixNano())
+}
+
+func (r *fakeRecord) isExpired(now uint32) bool {
+	return r.expirationTime <= now
+}
+
+func (r *fakeRecord) extendTTL(now uint32, ttl uint32) {
+	if ttl != 0 && now+ttl > r.expirationTime {
+		r.expirationTime = now + ttl
+	}
+}
+
+func (r *fakeRecord) recordInfo(now uint32) *cli.RecordInfo {
+	var msg proto.OperationalMessage
+	msg.SetVersion(r.version)
+	msg.SetCreationTime(r.creationTime)
+	msg.SetTimeToLive(util.GetTimeToLiveFrom(r.expirationTime, time.Unix(int64(now), 0)))
+	msg.SetExpirationTime(r.expirationTime)
+	msg.SetLastModificationTime(r.lastModificationTime)
+	msg.SetValueSize(uint32(len(r.value)))
+	msg.SetOriginatorRequestID(r.originatorId)
+	recInfo := &cli.RecordInfo{}
+	recInfo.SetFromOpMsg(&msg)
+	return recInfo
+}
+
+func (r *fakeRecord) context(now uint32) IContext {
+	return r.recordInfo(now)
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,179 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func TestFakeClientVersioning(t *testing.T) {
+	c := NewFakeClient(Config{Namespace: "ns"})
+	key := []byte("key")
+
+	ctx, err := c.Create(key, []byte("v1"))
+	if err != nil || ctx.GetVersion() != 1 {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err = c.Create(key, []byte("v1")); err != ErrUniqueKeyViolation {
+		t.Errorf("expected %s, got %v", ErrUniqueKeyViolation, err)
+	}
+	if ctx2, err := c.Update(key, []byte("v2"), WithCond(ctx)); err != nil || ctx2.GetVersion() != 2 {
+		t.Errorf("update: %v", err)
+	}
+	if _, err = c.Update(key, []byte("v3"), WithCond(ctx)); err != ErrConditionViolation {
+		t.Errorf("expected %s, got %v", ErrConditionViolation, err)
+	}
+	if v, _, err := c.Get(key, WithVersion(2)); err != nil || string(v) != "v2" {
+		t.Errorf("get version 2: %v", err)
+	}
+	if _, _, err = c.Get(key, WithVersion(1)); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	if _, err = c.Update([]byte("none"), []byte("v")); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	if err = c.Destroy(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = c.Get(key); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+}
+
+func TestFakeClientTTL(t *testing.T) {
+	now := time.Now()
+	c := NewFakeClient(Config{Namespace: "ns"})
+	c.SetClock(func() time.Time { return now })
+
+	if _, err := c.Set([]byte("key"), []byte("v"), WithTTL(10)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Set([]byte("key"), []byte("v"), WithTTL(kFakeMaxTimeToLive+1)); err != ErrBadParam {
+		t.Errorf("expected %s, got %v", ErrBadParam, err)
+	}
+	now = now.Add(11 * time.Second)
+	if _, _, err := c.Get([]byte("key")); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected no record, got %d", c.Len())
+	}
+}
+
+func TestFakeClientMeta(t *testing.T) {
+	now := time.Now()
+	c := NewFakeClient(Config{Namespace: "ns"})
+	c.SetClock(func() time.Time { return now })
+	key := []byte("key")
+
+	if ok, err := c.Exists(key); err != nil || ok {
+		t.Errorf("exists: %t %v", ok, err)
+	}
+	if _, err := c.GetMeta(key); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	if _, err := c.Touch(key, 10); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+	if _, err := c.Set(key, []byte("value"), WithTTL(100)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Exists(key); err != nil || !ok {
+		t.Errorf("exists: %t %v", ok, err)
+	}
+	meta, err := c.GetMeta(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.GetVersion() != 1 || meta.GetValueSize() != 5 || meta.GetTimeToLive() != 100 ||
+		meta.GetLastModificationTime() != uint64(now.UnixNano()) {
+		t.Errorf("unexpected meta: ver=%d size=%d ttl=%d lmt=%d",
+			meta.GetVersion(), meta.GetValueSize(), meta.GetTimeToLive(), meta.GetLastModificationTime())
+	}
+	if _, err = c.Touch(key, 0); err != ErrBadParam {
+		t.Errorf("expected %s, got %v", ErrBadParam, err)
+	}
+	ctx, err := c.Touch(key, 10)
+	if err != nil || ctx.GetTimeToLive() != 10 || ctx.GetVersion() != 1 {
+		t.Errorf("touch: %v", err)
+	}
+	now = now.Add(11 * time.Second)
+	if ok, err := c.Exists(key); err != nil || ok {
+		t.Errorf("exists after expiry: %t %v", ok, err)
+	}
+}
+
+func TestFakeClientUDF(t *testing.T) {
+	c := NewFakeClient(Config{Namespace: "ns"})
+	key := []byte("counter")
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, 1)
+	if _, err := c.Create(key, b); err != nil {
+		t.Fatal(err)
+	}
+	binary.BigEndian.PutUint32(b, 2)
+	if _, err := c.UDFSet(key, []byte("sc"), b); err != nil {
+		t.Fatal(err)
+	}
+	value, _, err := c.Get(key)
+	if err != nil || binary.BigEndian.Uint32(value) != 3 {
+		t.Errorf("unexpected counter %v, err %v", value, err)
+	}
+	if _, _, err = c.UDFGet(key, []byte("unknown"), b); err != ErrBadParam {
+		t.Errorf("expected %s, got %v", ErrBadParam, err)
+	}
+}
+
+func TestFakeClientFault(t *testing.T) {
+	c := NewFakeClient(Config{Namespace: "ns"})
+	c.InjectFault(Fault{OpCode: proto.OpCodeCreate, Err: ErrBusy, Count: 1})
+
+	if _, err := c.Create([]byte("key"), []byte("v")); err != ErrBusy {
+		t.Errorf("expected %s, got %v", ErrBusy, err)
+	}
+	if _, err := c.Create([]byte("key"), []byte("v")); err != nil {
+		t.Errorf("fault should be used up, got %v", err)
+	}
+}
+
+func TestFakeClientNamespace(t *testing.T) {
+	c := NewFakeClient(Config{
+		Namespace:         "ns",
+		NamespaceDefaults: map[string]NamespaceConfig{"other": {DefaultTimeToLive: 5}},
+	})
+	key := []byte("key")
+	if _, err := c.Create(key, []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := c.Create(key, []byte("v"), WithNamespace("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.GetTimeToLive() != 5 {
+		t.Errorf("expected ttl 5, got %d", ctx.GetTimeToLive())
+	}
+	if _, err = c.Create(key, []byte("v"), WithNamespace("ns")); err != ErrUniqueKeyViolation {
+		t.Errorf("expected %s, got %v", ErrUniqueKeyViolation, err)
+	}
+}
@@ -0,0 +1,129 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// Call describes a client operation passed through the interceptor chain.
+// The request fields are set before the first interceptor is invoked. The
+// response fields are set by the time next returns.
+type Call struct {
+	Context       context.Context // Context is the one given by WithContext. Interceptors may replace it for the ones that follow.
+	OpCode        proto.OpCode
+	Namespace     string
+	Key           []byte
+	RequestID     string
+	CorrelationID string
+	RequestBytes  int // RequestBytes is the payload length of the request.
+
+	Status        proto.OpStatus // Status is the response status. It is not meaningful if Err is an I/O error.
+	ResponseBytes int            // ResponseBytes is the payload length of the response.
+	Latency       time.Duration  // Latency is measured from the start of the chain to the completion of the last attempt.
+	Retries       int            // Retries is the number of times the request was sent after the first attempt.
+	Err           error          // Err is the error the operation returns, nil on success.
+
+	startTime time.Time
+}
+
+// Interceptor is a middleware around a client operation. It must call next
+// to proceed with the operation. An interceptor may call next more than once
+// to retry the request, which is reflected in Call.Retries.
+type Interceptor func(call *Call, next func())
+
+// WithInterceptor returns an IOption that adds interceptors. Passed to New,
+// they apply to all the operations of the client, otherwise only to the
+// operation they are passed to, after the client ones.
+func WithInterceptor(interceptors ...Interceptor) IOption {
+	return func(i interface{}) {
+		if data, ok := i.(*optionData); ok {
+			data.interceptors = append(data.interceptors, interceptors...)
+		}
+	}
+}
+
+// WithContext returns an IOption that sets the context passed to interceptors,
+// e.g. to parent tracing spans.
+func WithContext(ctx context.Context) IOption {
+	return func(i interface{}) {
+		if data, ok := i.(*optionData); ok {
+			data.ctx = ctx
+		}
+	}
+}
+
+// newCall creates a Call for the request.
+func newCall(request *proto.OperationalMessage, options *optionData) *Call {
+	ctx := options.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Call{
+		Context:       ctx,
+		OpCode:        request.GetOpCode(),
+		Namespace:     string(request.GetNamespace()),
+		Key:           request.GetKey(),
+		RequestID:     request.GetRequestIDString(),
+		CorrelationID: options.correlationId,
+		RequestBytes:  int(request.GetPayloadValueLength()),
+		startTime:     time.Now(),
+	}
+}
+
+// processRequest sends the request through the interceptor chain.
+func (c *clientImplT) processRequest(request *proto.OperationalMessage, options *optionData) (resp *proto.OperationalMessage, err error) {
+	interceptors := c.interceptors
+	if len(options.interceptors) != 0 {
+		interceptors = append(append([]Interceptor(nil), c.interceptors...), options.interceptors...)
+	}
+	if len(interceptors) == 0 {
+		return c.processor.ProcessRequest(request)
+	}
+	call := newCall(request, options)
+	attempts := 0
+
+	var invoke func(i int)
+	invoke = func(i int) {
+		if i < len(interceptors) {
+			interceptors[i](call, func() { invoke(i + 1) })
+			return
+		}
+		attempts++
+		resp, err = c.processor.ProcessRequest(request)
+		call.Latency = time.Since(call.startTime)
+		call.Retries = attempts - 1
+		call.Err = err
+		call.ResponseBytes = 0
+		if err == nil {
+			call.Status = resp.GetOpStatus()
+			call.ResponseBytes = int(resp.GetPayloadValueLength())
+			var ok bool
+			if call.Err, ok = errorMapping[call.Status]; !ok {
+				call.Err = ErrInternal
+			}
+		}
+	}
+	invoke(0)
+	return
+}
@@ -0,0 +1,345 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paypal/junodb/internal/cli"
+)
+
+const (
+	kDefaultNearCacheMaxEntries   = 10000
+	kDefaultNearCacheMaxStaleness = time.Minute
+	kNumNearCacheGenerations      = 1024 // the keys share the invalidation generations by hash
+)
+
+// NearCacheConfig configures the optional in-process cache of Get results.
+type NearCacheConfig struct {
+	Enabled      bool     // Enabled turns the near cache on.
+	MaxEntries   int      // MaxEntries bounds the number of cached records. LRU entries are evicted first.
+	MaxStaleness Duration // MaxStaleness is how long a record may be served from the cache. Zero means one minute.
+	Namespaces   []string // Namespaces the cache is enabled for. Empty means all.
+}
+
+// NearCacheStats holds the counters of a near cache.
+type NearCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// isEnabledFor returns true if the near cache is to be used for the namespace.
+func (c *NearCacheConfig) isEnabledFor(namespace string) bool {
+	if !c.Enabled {
+		return false
+	}
+	if len(c.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range c.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+type nearCacheEntry struct {
+	key       string
+	value     []byte
+	recInfo   cli.RecordInfo
+	cachedAt  time.Time
+	expiresAt time.Time
+}
+
+// nearCacheClientT serves Get from an LRU cache and forwards everything else to
+// the wrapped client. Local writes invalidate the cached record, before and
+// after the write, and bump the invalidation generation of the key, so that a
+// Get in flight across the write does not cache the value replaced. Writes
+// from other clients are not seen until the entry goes stale.
+type nearCacheClientT struct {
+	IClient
+	conf           NearCacheConfig
+	namespace      string // namespace is the one of the wrapped client, if known
+	defaultEnabled bool   // defaultEnabled tells if the cache is used for the namespace of the wrapped client
+	mtx            sync.Mutex
+	maxEntries     int
+	maxStaleness   time.Duration
+	lru            *list.List
+	entries        map[string]*list.Element
+	generations    [kNumNearCacheGenerations]uint64
+	hits           uint64
+	misses         uint64
+	evictions      uint64
+	now            func() time.Time
+}
+
+// WithNearCache wraps cli with a near cache. The namespace list of conf only
+// applies to requests with WithNamespace, the others are always cached.
+func WithNearCache(cli IClient, conf NearCacheConfig) IClient {
+	return newNearCacheClient(cli, conf, "", true)
+}
+
+func newNearCacheClient(cli IClient, conf NearCacheConfig, namespace string, defaultEnabled bool) *nearCacheClientT {
+	maxEntries := conf.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = kDefaultNearCacheMaxEntries
+	}
+	maxStaleness := conf.MaxStaleness.Duration
+	if maxStaleness <= 0 {
+		maxStaleness = kDefaultNearCacheMaxStaleness
+	}
+	return &nearCacheClientT{
+		IClient:        cli,
+		conf:           conf,
+		namespace:      namespace,
+		defaultEnabled: defaultEnabled,
+		maxEntries:     maxEntries,
+		maxStaleness:   maxStaleness,
+		lru:            list.New(),
+		entries:        make(map[string]*list.Element),
+		now:            time.Now,
+	}
+}
+
+// GetNearCacheStats returns the near cache counters of cli. ok is false if cli
+// has no near cache.
+func GetNearCacheStats(cli IClient) (stats NearCacheStats, ok bool) {
+	var c *nearCacheClientT
+	if c, ok = cli.(*nearCacheClientT); ok {
+		stats = c.Stats()
+	}
+	return
+}
+
+func (c *nearCacheClientT) Stats() NearCacheStats {
+	c.mtx.Lock()
+	n := c.lru.Len()
+	c.mtx.Unlock()
+	return NearCacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Entries:   n,
+	}
+}
+
+// Get returns the cached record if it is neither stale nor expired. A Get with
+// WithTTL always goes to the server, as it may extend the record's TTL, and so
+// does a Get of an earlier version, which is not cached.
+func (c *nearCacheClientT) Get(key []byte, opts ...IOption) (value []byte, context IContext, err error) {
+	options := newOptionData(opts...)
+	if !c.isEnabledFor(options) || options.isHistoryRead() {
+		return c.IClient.Get(key, opts...)
+	}
+	k := c.cacheKey(key, options)
+	if options.ttl == 0 {
+		if value, context = c.lookup(k); context != nil {
+			atomic.AddUint64(&c.hits, 1)
+			return
+		}
+	}
+	atomic.AddUint64(&c.misses, 1)
+	gen := c.generation(k)
+	if value, context, err = c.IClient.Get(key, opts...); err == nil {
+		c.add(k, value, context, gen)
+	} else if err == ErrNoKey {
+		c.invalidate(k)
+	}
+	return
+}
+
+func (c *nearCacheClientT) Create(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.Create(key, value, opts...)
+}
+
+func (c *nearCacheClientT) Update(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.Update(key, value, opts...)
+}
+
+func (c *nearCacheClientT) Set(key []byte, value []byte, opts ...IOption) (IContext, error) {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.Set(key, value, opts...)
+}
+
+func (c *nearCacheClientT) Destroy(key []byte, opts ...IOption) error {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.Destroy(key, opts...)
+}
+
+func (c *nearCacheClientT) Touch(key []byte, ttl uint32, opts ...IOption) (IContext, error) {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.Touch(key, ttl, opts...)
+}
+
+func (c *nearCacheClientT) UDFSet(key []byte, fname []byte, params []byte, opts ...IOption) (IContext, error) {
+	k := c.cacheKey(key, newOptionData(opts...))
+	c.invalidate(k)
+	defer c.invalidate(k)
+	return c.IClient.UDFSet(key, fname, params, opts...)
+}
+
+func (c *nearCacheClientT) defaultNamespace() string {
+	if len(c.namespace) != 0 {
+		return c.namespace
+	}
+	return defaultNamespaceOf(c.IClient)
+}
+
+// isEnabledFor tells if the namespace of the request is cached.
+func (c *nearCacheClientT) isEnabledFor(options *optionData) bool {
+	if len(options.namespace) == 0 || options.namespace == c.namespace {
+		return c.defaultEnabled
+	}
+	return c.conf.isEnabledFor(options.namespace)
+}
+
+// cacheKey returns the key of the cache entry. The namespace of the wrapped
+// client is stored as the empty one, so that requests with and without
+// WithNamespace share the entry.
+func (c *nearCacheClientT) cacheKey(key []byte, options *optionData) string {
+	ns := options.namespace
+	if ns == c.namespace {
+		ns = ""
+	}
+	return ns + "\x00" + string(key)
+}
+
+// lookup returns a copy of the cached value and a context with the remaining TTL.
+func (c *nearCacheClientT) lookup(key string) (value []byte, context IContext) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*nearCacheEntry)
+	now := c.now()
+	if !now.Before(entry.expiresAt) {
+		c.remove(elem)
+		return
+	}
+	c.lru.MoveToFront(elem)
+	recInfo := entry.recInfo
+	if ttl := entry.recInfo.GetTimeToLive(); ttl != 0 {
+		elapsed := uint32(now.Sub(entry.cachedAt) / time.Second)
+		if elapsed < ttl {
+			recInfo.SetTimeToLive(ttl - elapsed)
+		} else {
+			recInfo.SetTimeToLive(0)
+		}
+	}
+	value = copyBytes(entry.value)
+	context = &recInfo
+	return
+}
+
+// generationIndex returns the index of the invalidation generation of the key.
+func generationIndex(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % kNumNearCacheGenerations)
+}
+
+// generation returns the invalidation generation of the key, to be given to
+// add.
+func (c *nearCacheClientT) generation(key string) uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.generations[generationIndex(key)]
+}
+
+// add caches the record read, unless the key has been invalidated since gen
+// was taken, or a later version is already cached.
+func (c *nearCacheClientT) add(key string, value []byte, context IContext, gen uint64) {
+	recInfo, ok := context.(*cli.RecordInfo)
+	if !ok {
+		return
+	}
+	now := c.now()
+	expiresAt := now.Add(time.Duration(recInfo.GetTimeToLive()) * time.Second)
+	if c.maxStaleness > 0 && now.Add(c.maxStaleness).Before(expiresAt) {
+		expiresAt = now.Add(c.maxStaleness)
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+	entry := &nearCacheEntry{
+		key:       key,
+		value:     copyBytes(value),
+		recInfo:   *recInfo,
+		cachedAt:  now,
+		expiresAt: expiresAt,
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.generations[generationIndex(key)] != gen {
+		return
+	}
+	if elem, found := c.entries[key]; found {
+		if elem.Value.(*nearCacheEntry).recInfo.GetVersion() > recInfo.GetVersion() {
+			return
+		}
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// invalidate removes the entry of the key, and bumps its invalidation
+// generation.
+func (c *nearCacheClientT) invalidate(key string) {
+	c.mtx.Lock()
+	c.generations[generationIndex(key)]++
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.mtx.Unlock()
+}
+
+// remove must be called with mtx held.
+func (c *nearCacheClientT) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*nearCacheEntry).key)
+}
@@ -0,0 +1,166 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/internal/cli"
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+// countingClient counts the Get calls reaching the wrapped client.
+type countingClient struct {
+	IClient
+	gets int
+}
+
+func (c *countingClient) Get(key []byte, opts ...IOption) ([]byte, IContext, error) {
+	c.gets++
+	return c.IClient.Get(key, opts...)
+}
+
+func TestNearCache(t *testing.T) {
+	now := time.Now()
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	fake.SetClock(func() time.Time { return now })
+	backend := &countingClient{IClient: fake}
+	cli := WithNearCache(backend, NearCacheConfig{Enabled: true, MaxEntries: 2, MaxStaleness: Duration{time.Minute}})
+	cli.(*nearCacheClientT).now = func() time.Time { return now }
+	key := []byte("key")
+
+	if _, err := cli.Set(key, []byte("v1"), WithTTL(30)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if v, _, err := cli.Get(key); err != nil || !bytes.Equal(v, []byte("v1")) {
+			t.Fatalf("unexpected %q, %v", v, err)
+		}
+	}
+	if backend.gets != 1 {
+		t.Errorf("expected 1 backend get, got %d", backend.gets)
+	}
+	now = now.Add(10 * time.Second)
+	if _, ctx, _ := cli.Get(key); ctx.GetTimeToLive() != 20 {
+		t.Errorf("expected ttl 20, got %d", ctx.GetTimeToLive())
+	}
+
+	// local write invalidates
+	if _, err := cli.Set(key, []byte("v2"), WithTTL(30)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, _ := cli.Get(key); !bytes.Equal(v, []byte("v2")) {
+		t.Errorf("expected v2, got %q", v)
+	}
+
+	// record TTL is honoured
+	now = now.Add(31 * time.Second)
+	if _, _, err := cli.Get(key); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+
+	// LRU eviction
+	for _, k := range []string{"a", "b", "c"} {
+		cli.Set([]byte(k), []byte(k))
+		cli.Get([]byte(k))
+	}
+	stats, ok := GetNearCacheStats(cli)
+	if !ok || stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestNearCacheNamespaces(t *testing.T) {
+	conf := NearCacheConfig{Enabled: true, Namespaces: []string{"a"}}
+	if !conf.isEnabledFor("a") || conf.isEnabledFor("b") {
+		t.Error("unexpected namespace enablement")
+	}
+}
+
+// blockingClient holds the Get calls until released, returning the value
+// read when they were made.
+type blockingClient struct {
+	IClient
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingClient) Get(key []byte, opts ...IOption) ([]byte, IContext, error) {
+	value, ctx, err := c.IClient.Get(key, opts...)
+	c.started <- struct{}{}
+	<-c.release
+	return value, ctx, err
+}
+
+func TestNearCacheGetAcrossWrite(t *testing.T) {
+	fake := NewFakeClient(Config{Namespace: "ns"})
+	backend := &blockingClient{IClient: fake, started: make(chan struct{}), release: make(chan struct{})}
+	cli := WithNearCache(backend, NearCacheConfig{Enabled: true})
+	key := []byte("key")
+	fake.Set(key, []byte("v1"), WithTTL(30))
+
+	done := make(chan struct{})
+	go func() {
+		cli.Get(key)
+		close(done)
+	}()
+	<-backend.started
+	if _, err := cli.Set(key, []byte("v2"), WithTTL(30)); err != nil {
+		t.Fatal(err)
+	}
+	close(backend.release)
+	<-done
+
+	go func() { <-backend.started }()
+	if v, _, _ := cli.Get(key); !bytes.Equal(v, []byte("v2")) {
+		t.Errorf("expected v2, got %q", v)
+	}
+}
+
+func TestNearCacheVersion(t *testing.T) {
+	c := newNearCacheClient(NewFakeClient(Config{Namespace: "ns"}), NearCacheConfig{Enabled: true}, "", true)
+	if c.maxStaleness != kDefaultNearCacheMaxStaleness {
+		t.Errorf("unexpected default max staleness %s", c.maxStaleness)
+	}
+	recInfo := func(version uint32) *cli.RecordInfo {
+		var msg proto.OperationalMessage
+		msg.SetVersion(version)
+		msg.SetTimeToLive(30)
+		r := &cli.RecordInfo{}
+		r.SetFromOpMsg(&msg)
+		return r
+	}
+	newer, older := recInfo(2), recInfo(1)
+
+	gen := c.generation("k")
+	c.add("k", []byte("v2"), newer, gen)
+	c.add("k", []byte("v1"), older, gen)
+	if v, _ := c.lookup("k"); !bytes.Equal(v, []byte("v2")) {
+		t.Errorf("older version cached over the newer one: %q", v)
+	}
+
+	c.invalidate("k")
+	c.add("k", []byte("v1"), older, gen)
+	if _, ctx := c.lookup("k"); ctx != nil {
+		t.Error("cached after the key was invalidated")
+	}
+}
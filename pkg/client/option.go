@@ -20,13 +20,21 @@
 // Package client provides functionalities for client configurations.
 package client
 
-import ()
+import (
+	"context"
+	"time"
+)
 
 // optionData struct contains client options.
 type optionData struct {
 	ttl           uint32  // Time to live value.
 	context       IContext  // Client context.
 	correlationId string  // Correlation ID for tracking.
+	interceptors  []Interceptor  // Interceptors around the request.
+	ctx           context.Context  // Context passed to interceptors.
+	namespace     string  // Namespace overriding the client one.
+	readVersion   uint32  // Version of the record to get.
+	asOf          time.Time  // Get the record as of the time.
 }
 
 // IOption type represents a function that applies options on optionData.
@@ -79,6 +87,45 @@ func WithCorrelationId(id string) IOption {
 	}
 }
 
+// WithNamespace function returns an IOption that sets the namespace of the request,
+// overriding Config.Namespace.
+func WithNamespace(ns string) IOption {
+	return func(i interface{}) {
+		// Check if the passed interface can be casted to *optionData
+		if data, ok := i.(*optionData); ok {
+			data.namespace = ns  // Set the namespace.
+		}
+	}
+}
+
+// WithVersion function returns an IOption that makes Get return the given version of
+// the record. The earlier versions are kept only for the namespaces with history enabled
+// on the storage servers.
+func WithVersion(version uint32) IOption {
+	return func(i interface{}) {
+		// Check if the passed interface can be casted to *optionData
+		if data, ok := i.(*optionData); ok {
+			data.readVersion = version  // Set the version to get.
+		}
+	}
+}
+
+// WithAsOf function returns an IOption that makes Get return the version of the record
+// current at the given time, or ErrNoKey if it did not exist or was deleted then.
+func WithAsOf(t time.Time) IOption {
+	return func(i interface{}) {
+		// Check if the passed interface can be casted to *optionData
+		if data, ok := i.(*optionData); ok {
+			data.asOf = t  // Set the time to get the record as of.
+		}
+	}
+}
+
+// isHistoryRead tells if the options ask for an earlier version of the record.
+func (d *optionData) isHistoryRead() bool {
+	return d.readVersion != 0 || !d.asOf.IsZero()
+}
+
 // newOptionData function applies the options passed in and returns an initialized optionData.
 func newOptionData(opts ...IOption) *optionData {
 	data := &optionData{}  // Initialize a new optionData.
@@ -0,0 +1,98 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const kOTelInstrumentationName = "github.com/paypal/junodb/pkg/client"
+
+// NewOTelInterceptor returns an interceptor creating a client span per
+// operation and recording the latency, payload sizes and retries as metrics.
+// nil providers select the global ones.
+func NewOTelInterceptor(tp trace.TracerProvider, mp metric.MeterProvider) (Interceptor, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	tracer := tp.Tracer(kOTelInstrumentationName)
+	meter := mp.Meter(kOTelInstrumentationName)
+
+	duration, err := meter.Float64Histogram("juno.client.duration",
+		metric.WithDescription("Latency of Juno client operations"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	reqSize, err := meter.Int64Histogram("juno.client.request.size",
+		metric.WithDescription("Payload size of Juno client requests"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	respSize, err := meter.Int64Histogram("juno.client.response.size",
+		metric.WithDescription("Payload size of Juno client responses"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := meter.Int64Counter("juno.client.retries",
+		metric.WithDescription("Number of Juno client request retries"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(call *Call, next func()) {
+		ctx, span := tracer.Start(call.Context, "juno."+call.OpCode.String(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("juno.namespace", call.Namespace),
+				attribute.String("juno.request_id", call.RequestID),
+			))
+		call.Context = ctx
+		next()
+
+		attrs := []attribute.KeyValue{
+			attribute.String("juno.operation", call.OpCode.String()),
+			attribute.String("juno.namespace", call.Namespace),
+			attribute.String("juno.status", call.Status.ShortNameString()),
+		}
+		span.SetAttributes(attrs[2], attribute.Int("juno.retries", call.Retries))
+		if call.Err != nil {
+			span.SetStatus(codes.Error, call.Err.Error())
+		}
+		span.End()
+
+		opt := metric.WithAttributes(attrs...)
+		duration.Record(ctx, float64(call.Latency.Microseconds())/1000, opt)
+		reqSize.Record(ctx, int64(call.RequestBytes), opt)
+		respSize.Record(ctx, int64(call.ResponseBytes), opt)
+		if call.Retries > 0 {
+			retries.Add(ctx, int64(call.Retries), opt)
+		}
+	}, nil
+}
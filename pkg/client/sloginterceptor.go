@@ -0,0 +1,67 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"log/slog"
+)
+
+// NewSlogInterceptor returns an interceptor logging every operation with its
+// outcome. Successful operations and the expected application errors, i.e.
+// ErrNoKey, ErrUniqueKeyViolation and ErrConditionViolation, are logged at
+// debug level, the others at warning level. A nil logger selects slog.Default().
+func NewSlogInterceptor(logger *slog.Logger) Interceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(call *Call, next func()) {
+		next()
+
+		level := slog.LevelDebug
+		switch call.Err {
+		case nil, ErrNoKey, ErrUniqueKeyViolation, ErrConditionViolation:
+		default:
+			level = slog.LevelWarn
+		}
+		if !logger.Enabled(call.Context, level) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("op", call.OpCode.String()),
+			slog.String("ns", call.Namespace),
+			slog.String("rid", call.RequestID),
+			slog.String("status", call.Status.ShortNameString()),
+			slog.Duration("latency", call.Latency),
+			slog.Int("req_bytes", call.RequestBytes),
+			slog.Int("resp_bytes", call.ResponseBytes),
+			slog.Int("retries", call.Retries),
+		}
+		if call.CorrelationID != "" {
+			attrs = append(attrs, slog.String("corr_id", call.CorrelationID))
+		}
+		if call.Err != nil {
+			attrs = append(attrs, slog.String("error", call.Err.Error()))
+		}
+		logger.LogAttrs(call.Context, level, "juno client request", attrs...)
+	}
+}
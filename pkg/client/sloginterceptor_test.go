@@ -0,0 +1,47 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/paypal/junodb/pkg/proto"
+)
+
+func TestSlogInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	icpt := NewSlogInterceptor(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	call := &Call{OpCode: proto.OpCodeGet, Namespace: "ns"}
+	icpt(call, func() { call.Err = ErrNoKey })
+	if buf.Len() != 0 {
+		t.Errorf("ErrNoKey should be logged at debug level: %s", buf.String())
+	}
+	icpt(call, func() { call.Err = ErrBusy })
+	if out := buf.String(); !strings.Contains(out, "op=Get") || !strings.Contains(out, "server busy") {
+		t.Errorf("unexpected log %s", out)
+	}
+}
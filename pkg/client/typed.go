@@ -0,0 +1,129 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"fmt"
+)
+
+// kDefaultMaxRMWRetries is the number of times ReadModifyWrite retries on
+// ErrConditionViolation before giving up.
+const kDefaultMaxRMWRetries = 10
+
+// Typed wraps an IClient and encodes values of type T with a Codec.
+type Typed[T any] struct {
+	cli        IClient
+	codec      Codec
+	maxRetries int
+}
+
+// NewTyped creates a Typed client on top of cli.
+func NewTyped[T any](cli IClient, codec Codec) *Typed[T] {
+	return &Typed[T]{
+		cli:        cli,
+		codec:      codec,
+		maxRetries: kDefaultMaxRMWRetries,
+	}
+}
+
+// SetMaxRetries sets the number of retries of ReadModifyWrite.
+func (t *Typed[T]) SetMaxRetries(n int) {
+	t.maxRetries = n
+}
+
+// Client returns the underlying IClient.
+func (t *Typed[T]) Client() IClient {
+	return t.cli
+}
+
+func (t *Typed[T]) Create(key []byte, value T, opts ...IOption) (IContext, error) {
+	data, err := t.encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return t.cli.Create(key, data, opts...)
+}
+
+func (t *Typed[T]) Get(key []byte, opts ...IOption) (value T, context IContext, err error) {
+	var data []byte
+	if data, context, err = t.cli.Get(key, opts...); err != nil {
+		return
+	}
+	value, err = t.decode(data)
+	return
+}
+
+func (t *Typed[T]) Update(key []byte, value T, opts ...IOption) (IContext, error) {
+	data, err := t.encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return t.cli.Update(key, data, opts...)
+}
+
+func (t *Typed[T]) Set(key []byte, value T, opts ...IOption) (IContext, error) {
+	data, err := t.encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return t.cli.Set(key, data, opts...)
+}
+
+func (t *Typed[T]) Destroy(key []byte, opts ...IOption) error {
+	return t.cli.Destroy(key, opts...)
+}
+
+// ReadModifyWrite reads the record, applies fn and writes the result back
+// conditionally with WithCond. If the record was changed in between, it
+// starts over, up to the configured number of retries. opts are passed to
+// both Get and Update.
+func (t *Typed[T]) ReadModifyWrite(key []byte, fn func(T) T, opts ...IOption) (value T, context IContext, err error) {
+	for i := 0; ; i++ {
+		var cur T
+		if cur, context, err = t.Get(key, opts...); err != nil {
+			return
+		}
+		value = fn(cur)
+		var ctx IContext
+		ctx, err = t.Update(key, value, append(opts, WithCond(context))...)
+		if err == nil {
+			context = ctx
+			return
+		}
+		if err != ErrConditionViolation || i >= t.maxRetries {
+			return
+		}
+	}
+}
+
+func (t *Typed[T]) encode(value T) ([]byte, error) {
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadParam, err.Error())
+	}
+	return data, nil
+}
+
+func (t *Typed[T]) decode(data []byte) (value T, err error) {
+	if err = t.codec.Unmarshal(data, &value); err != nil {
+		err = fmt.Errorf("decode value: %s", err.Error())
+	}
+	return
+}
@@ -0,0 +1,93 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type typedTestValue struct {
+	Name  string
+	Count int
+}
+
+func TestTypedCodecs(t *testing.T) {
+	codecs := map[string]Codec{
+		"json":  JSONCodec,
+		"gob":   GobCodec,
+		"funcs": CodecFuncs{MarshalFunc: json.Marshal, UnmarshalFunc: json.Unmarshal},
+	}
+	for name, codec := range codecs {
+		tc := NewTyped[typedTestValue](NewFakeClient(Config{Namespace: "ns"}), codec)
+		key := []byte("key")
+		if _, err := tc.Create(key, typedTestValue{Name: "a", Count: 1}); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		v, ctx, err := tc.Get(key)
+		if err != nil || v.Name != "a" || v.Count != 1 || ctx.GetVersion() != 1 {
+			t.Errorf("%s: unexpected %+v, %v", name, v, err)
+		}
+	}
+
+	tc := NewTyped[*wrapperspb.StringValue](NewFakeClient(Config{Namespace: "ns"}), ProtoCodec)
+	if _, err := tc.Set([]byte("key"), wrapperspb.String("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, err := tc.Get([]byte("key")); err != nil || v.GetValue() != "hello" {
+		t.Errorf("proto: unexpected %v, %v", v, err)
+	}
+	if _, err := NewTyped[int](NewFakeClient(Config{Namespace: "ns"}), ProtoCodec).Set([]byte("key"), 1); !errors.Is(err, ErrBadParam) {
+		t.Errorf("expected %s, got %v", ErrBadParam, err)
+	}
+}
+
+func TestTypedReadModifyWrite(t *testing.T) {
+	tc := NewTyped[typedTestValue](NewFakeClient(Config{Namespace: "ns"}), JSONCodec)
+	tc.SetMaxRetries(100)
+	key := []byte("key")
+	if _, err := tc.Create(key, typedTestValue{}); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := tc.ReadModifyWrite(key, func(v typedTestValue) typedTestValue {
+				v.Count++
+				return v
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, ctx, _ := tc.Get(key); v.Count != 10 || ctx.GetVersion() != 11 {
+		t.Errorf("unexpected count %d version %d", v.Count, ctx.GetVersion())
+	}
+	if _, _, err := tc.ReadModifyWrite([]byte("none"), func(v typedTestValue) typedTestValue { return v }); err != ErrNoKey {
+		t.Errorf("expected %s, got %v", ErrNoKey, err)
+	}
+}
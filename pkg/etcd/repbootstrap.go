@@ -0,0 +1,146 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package etcd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Keys for bootstrapping a replication target
+//
+// rep_bootstrap holds the RepBootstrapSpec of the current bootstrap. Proxies
+// buffer the live replication to the target while it is in begin state, and
+// storage servers of the source zone stream their snapshot to the target.
+// rep_bootstrap_state_<zone>_<node> holds the progress of each storage server.
+const (
+	TagRepBootstrap            = "rep_bootstrap"
+	TagRepBootstrapStatePrefix = "rep_bootstrap_state"
+
+	TagRepBootstrapBegin  = "begin"
+	TagRepBootstrapFinish = "finish"
+	TagRepBootstrapAbort  = "abort"
+
+	TagRepBootstrapTarget      = "target"
+	TagRepBootstrapAddr        = "addr"
+	TagRepBootstrapSSL         = "ssl"
+	TagRepBootstrapZone        = "zone"
+	TagRepBootstrapNamespaces  = "ns"
+	TagRepBootstrapNsDelimiter = ","
+)
+
+type RepBootstrapSpec struct {
+	State      string
+	Target     string
+	Addr       string
+	SSLEnabled bool
+	Zone       int
+	Namespaces []string
+	RateLimit  int // KBps, 0 for the redist default
+}
+
+func KeyRepBootstrapState(zone int, node int) string {
+	return Key(TagRepBootstrapStatePrefix, zone, node)
+}
+
+// begin|target=dr|addr=host:port|ssl=true|zone=0|ns=ns1,ns2|ratelimit=10000
+func (s *RepBootstrapSpec) String() string {
+	var b strings.Builder
+	b.WriteString(s.State)
+	add := func(k string, v string) {
+		b.WriteString(TagFieldSeparator)
+		b.WriteString(k)
+		b.WriteString(TagKeyValueSeparator)
+		b.WriteString(v)
+	}
+	add(TagRepBootstrapTarget, s.Target)
+	if s.State == TagRepBootstrapBegin {
+		add(TagRepBootstrapAddr, s.Addr)
+		if s.SSLEnabled {
+			add(TagRepBootstrapSSL, "true")
+		}
+		add(TagRepBootstrapZone, strconv.Itoa(s.Zone))
+		if len(s.Namespaces) != 0 {
+			add(TagRepBootstrapNamespaces, strings.Join(s.Namespaces, TagRepBootstrapNsDelimiter))
+		}
+		if s.RateLimit > 0 {
+			add(TagRedistRateLimit, strconv.Itoa(s.RateLimit))
+		}
+	}
+	return b.String()
+}
+
+func ParseRepBootstrapSpec(value string) (s *RepBootstrapSpec, err error) {
+	fields := strings.Split(value, TagFieldSeparator)
+	s = &RepBootstrapSpec{State: fields[0]}
+
+	switch s.State {
+	case TagRepBootstrapBegin, TagRepBootstrapFinish, TagRepBootstrapAbort:
+	default:
+		return nil, fmt.Errorf("unknown bootstrap state '%s'", s.State)
+	}
+	for _, f := range fields[1:] {
+		kv := strings.SplitN(f, TagKeyValueSeparator, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad bootstrap field '%s'", f)
+		}
+		switch kv[0] {
+		case TagRepBootstrapTarget:
+			s.Target = kv[1]
+		case TagRepBootstrapAddr:
+			s.Addr = kv[1]
+		case TagRepBootstrapSSL:
+			s.SSLEnabled, err = strconv.ParseBool(kv[1])
+		case TagRepBootstrapZone:
+			s.Zone, err = strconv.Atoi(kv[1])
+		case TagRepBootstrapNamespaces:
+			if len(kv[1]) != 0 {
+				s.Namespaces = strings.Split(kv[1], TagRepBootstrapNsDelimiter)
+			}
+		case TagRedistRateLimit:
+			s.RateLimit, err = strconv.Atoi(kv[1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad bootstrap field '%s': %s", f, err.Error())
+		}
+	}
+	if len(s.Target) == 0 {
+		return nil, fmt.Errorf("bootstrap target not specified")
+	}
+	if s.State == TagRepBootstrapBegin && len(s.Addr) == 0 {
+		return nil, fmt.Errorf("bootstrap target address not specified")
+	}
+	return
+}
+
+// GetRepBootstrapStates returns the progress reported by the storage servers,
+// keyed by rep_bootstrap_state_<zone>_<node>.
+func (e *EtcdClient) GetRepBootstrapStates() (states map[string]string, err error) {
+	resp, err := e.getWithPrefix(TagRepBootstrapStatePrefix)
+	if err != nil {
+		return
+	}
+	states = make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		states[string(kv.Key)] = string(kv.Value)
+	}
+	return
+}
@@ -0,0 +1,56 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package etcd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepBootstrapSpec(t *testing.T) {
+	spec := RepBootstrapSpec{
+		State:      TagRepBootstrapBegin,
+		Target:     "dr",
+		Addr:       "10.0.0.1:5080",
+		SSLEnabled: true,
+		Zone:       1,
+		Namespaces: []string{"ns1", "ns2"},
+		RateLimit:  2000,
+	}
+	value := spec.String()
+	parsed, err := ParseRepBootstrapSpec(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&spec, parsed) {
+		t.Errorf("%s: got %+v", value, parsed)
+	}
+
+	finish := RepBootstrapSpec{State: TagRepBootstrapFinish, Target: "dr"}
+	if value = finish.String(); value != "finish|target=dr" {
+		t.Errorf("unexpected value %s", value)
+	}
+
+	for _, bad := range []string{"", "yes|target=dr", "begin|target=dr", "begin|addr=a:1", "begin|target=dr|addr=a:1|zone=x"} {
+		if _, err = ParseRepBootstrapSpec(bad); err == nil {
+			t.Errorf("expect error for '%s'", bad)
+		}
+	}
+}
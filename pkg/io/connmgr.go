@@ -20,7 +20,9 @@
 package io
 
 import (
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/paypal/junodb/third_party/forked/golang/glog"
@@ -29,7 +31,35 @@ import (
 type InboundConnManager struct {
 	mtx         sync.Mutex
 	activeConns map[*Connector]struct{}
+	streamConns map[net.Conn]struct{} // the connections served by a ConnServer
 	wg          sync.WaitGroup
+	closing     int32 // set once Shutdown is called
+}
+
+// streamConn is a connection served by a ConnServer. Once the manager is
+// shutting down, the read deadline is kept expired, so that a ConnServer
+// re-arming it for the next request, e.g. with the idle timeout, returns
+// after the request in progress.
+type streamConn struct {
+	net.Conn
+	connMgr *InboundConnManager
+}
+
+func (c *streamConn) SetDeadline(t time.Time) error {
+	err := c.Conn.SetDeadline(t)
+	if c.connMgr.isClosing() {
+		c.Conn.SetReadDeadline(time.Now())
+	}
+	return err
+}
+
+func (c *streamConn) SetReadDeadline(t time.Time) error {
+	err := c.Conn.SetReadDeadline(t)
+	// checked after setting the deadline, for Shutdown not to be missed
+	if c.connMgr.isClosing() {
+		c.Conn.SetReadDeadline(time.Now())
+	}
+	return err
 }
 
 func (m *InboundConnManager) TrackConn(c *Connector, add bool) {
@@ -54,14 +84,47 @@ func (m *InboundConnManager) TrackConn(c *Connector, add bool) {
 	m.mtx.Unlock()
 }
 
+// TrackStreamConn adds or removes a connection served by a ConnServer.
+func (m *InboundConnManager) TrackStreamConn(c net.Conn, add bool) {
+	m.mtx.Lock()
+	if m.streamConns == nil {
+		m.streamConns = make(map[net.Conn]struct{})
+	}
+	if add {
+		m.streamConns[c] = struct{}{}
+		m.wg.Add(1)
+	} else {
+		delete(m.streamConns, c)
+		m.wg.Done()
+	}
+	m.mtx.Unlock()
+}
+
+// newStreamConn returns the connection to be passed to a ConnServer.
+func (m *InboundConnManager) newStreamConn(c net.Conn) net.Conn {
+	return &streamConn{Conn: c, connMgr: m}
+}
+
+func (m *InboundConnManager) isClosing() bool {
+	return atomic.LoadInt32(&m.closing) != 0
+}
+
 func (m *InboundConnManager) Shutdown() {
+	atomic.StoreInt32(&m.closing, 1)
 	m.mtx.Lock()
 	for connector := range m.activeConns {
 		connector.Stop()
 	}
+	// the ConnServer returns on the read error, after the request in progress
+	for conn := range m.streamConns {
+		conn.SetReadDeadline(time.Now())
+	}
 	m.mtx.Unlock()
 }
 
+// WaitForShutdownToComplete waits for the connections to be done, up to
+// timeout. The connections served by a ConnServer still open then are
+// closed.
 func (m *InboundConnManager) WaitForShutdownToComplete(timeout time.Duration) {
 	done := make(chan bool)
 	go func() {
@@ -72,10 +135,18 @@ func (m *InboundConnManager) WaitForShutdownToComplete(timeout time.Duration) {
 	select {
 	case <-done:
 	case <-time.After(timeout):
+		m.mtx.Lock()
+		if len(m.streamConns) != 0 {
+			glog.Warningf("closing %d connections still open after %s", len(m.streamConns), timeout)
+		}
+		for conn := range m.streamConns {
+			conn.Close()
+		}
+		m.mtx.Unlock()
 	}
 }
 
 // thread safe?
 func (m *InboundConnManager) GetNumActiveConnections() uint32 {
-	return uint32(len(m.activeConns))
+	return uint32(len(m.activeConns) + len(m.streamConns))
 }
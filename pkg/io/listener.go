@@ -21,6 +21,7 @@ package io
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
 	"time"
@@ -40,6 +41,15 @@ type (
 	InboundRequestContextCreator func(magic []byte, c *Connector) (ctx IRequestContext, err error)
 	ListenerType                 byte
 
+	// ConnServer serves a connection of a protocol other than Juno and Mayfly
+	// until the connection is closed or fails. The requests are passed to
+	// reqHandler in contexts created with NewRequestContext.
+	ConnServer func(conn net.Conn, iocfg InboundConfig, reqHandler IRequestHandler)
+
+	// ConnServerFactory creates the ConnServer of a listener, for the
+	// protocols keeping a server per listener.
+	ConnServerFactory func(iocfg InboundConfig, reqHandler IRequestHandler) ConnServer
+
 	IListener interface {
 		GetName() string
 		GetType() ListenerType
@@ -59,13 +69,42 @@ type (
 		reqHandler  IRequestHandler
 		connMgr     *InboundConnManager
 		lsnrType    ListenerType
+		connServer  ConnServer
 	}
 )
 
 var (
-	supportedListenerNetworks map[string]bool = make(map[string]bool)
+	supportedListenerNetworks map[string]bool              = make(map[string]bool)
+	connServers               map[string]ConnServer        = make(map[string]ConnServer)
+	connServerFactories       map[string]ConnServerFactory = make(map[string]ConnServerFactory)
 )
 
+// RegisterConnServer registers the server of the connections of the listeners
+// with the protocol. To be called before the listeners are created.
+func RegisterConnServer(protocol string, s ConnServer) {
+	connServers[protocol] = s
+}
+
+// RegisterConnServerFactory registers the factory of the server of the
+// connections of each listener with the protocol. To be called before the
+// listeners are created.
+func RegisterConnServerFactory(protocol string, f ConnServerFactory) {
+	connServerFactories[protocol] = f
+}
+
+func getConnServer(protocol string, iocfg InboundConfig, reqHandler IRequestHandler) (s ConnServer, err error) {
+	if len(protocol) != 0 {
+		if f, ok := connServerFactories[protocol]; ok {
+			return f(iocfg, reqHandler), nil
+		}
+		var ok bool
+		if s, ok = connServers[protocol]; !ok {
+			err = fmt.Errorf("listener protocol %s not supported", protocol)
+		}
+	}
+	return
+}
+
 func init() {
 	supportedListenerNetworks["tcp"] = true
 }
@@ -83,6 +122,9 @@ func NewListener(cfg ListenerConfig, iocfg InboundConfig, reqHandler IRequestHan
 	if len(ln.config.Network) == 0 {
 		ln.config.Network = "tcp"
 	}
+	if ln.connServer, err = getConnServer(cfg.Protocol, ln.ioConfig, reqHandler); err != nil {
+		return
+	}
 	if ln.netListener, err = net.Listen(ln.config.Network, ln.config.Addr); err == nil {
 		if cfg.SSLEnabled {
 			sslLsnr := &SslListener{
@@ -111,6 +153,9 @@ func NewListenerWithFd(cfg ListenerConfig, iocfg InboundConfig, f *os.File, reqH
 	if len(ln.config.Network) == 0 {
 		ln.config.Network = "tcp"
 	}
+	if ln.connServer, err = getConnServer(cfg.Protocol, ln.ioConfig, reqHandler); err != nil {
+		return
+	}
 	if ln.netListener, err = net.FileListener(f); err == nil {
 		if cfg.SSLEnabled {
 			sslLsnr := &SslListener{
@@ -158,6 +203,10 @@ func (l *Listener) AcceptAndServe() error {
 }
 
 func (l *Listener) startNewConnector(conn net.Conn) {
+	if l.connServer != nil {
+		l.serveConn(conn)
+		return
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	bufSize := l.ioConfig.IOBufSize
 	if bufSize == 0 {
@@ -184,6 +233,20 @@ func (l *Listener) startNewConnector(conn net.Conn) {
 	connector.Start()
 }
 
+// serveConn serves a connection with the ConnServer of the listener protocol.
+func (l *Listener) serveConn(c net.Conn) {
+	c.SetReadDeadline(time.Time{})
+	conn := l.connMgr.newStreamConn(c)
+	l.connMgr.TrackStreamConn(conn, true)
+	go func() {
+		defer func() {
+			conn.Close()
+			l.connMgr.TrackStreamConn(conn, false)
+		}()
+		l.connServer(conn, l.ioConfig, l.reqHandler)
+	}()
+}
+
 func (l *Listener) GetType() ListenerType {
 	return l.lsnrType
 }
@@ -33,7 +33,8 @@ type (
 
 	ListenerConfig struct {
 		ServiceEndpoint
-		Name string
+		Name     string
+		Protocol string // "" for Juno and Mayfly, or one registered with RegisterConnServer
 	}
 )
 
@@ -150,6 +150,21 @@ func NewInboundRequestContext(c *Connector) (r *InboundRequestContext) {
 	return
 }
 
+// NewRequestContext returns the context of a request not read by a Connector,
+// e.g. translated from another protocol by a ConnServer. The response is sent
+// to ch.
+func NewRequestContext(msg *proto.RawMessage, ch chan<- IResponseContext) (r *InboundRequestContext) {
+	r = &InboundRequestContext{
+		RequestContext: RequestContext{
+			message:      *msg,
+			chResponse:   ch,
+			timeReceived: time.Now(),
+		},
+	}
+	msg.GiveUpBufferOwnership()
+	return
+}
+
 func NewOutboundRequestContext(msg *proto.RawMessage, opaque uint32,
 	ctx context.Context, ch chan<- IResponseContext, to time.Duration) (r *OutboundRequestContext) {
 	r = &OutboundRequestContext{
@@ -31,6 +31,8 @@ const (
 	RRDropMaxRetry CMetric = CMetric(iota)
 	RRDropQueueFull
 	RRDropRecExpired
+	RRSpill
+	RRAlarm
 	SSL_CLIENT_INFO
 	CLIENT_INFO
 	Accept
@@ -110,6 +112,8 @@ var (
 	rrDropMaxRetryCounterOnce   sync.Once
 	rrDropQueueFullCounterOnce  sync.Once
 	rrDropRecExpiredCounterOnce sync.Once
+	rrSpillCounterOnce          sync.Once
+	rrAlarmCounterOnce          sync.Once
 	acceptCounterOnce           sync.Once
 	closeCounterOnce            sync.Once
 	rapiCounterOnce             sync.Once
@@ -141,6 +145,8 @@ var countMetricMap map[CMetric]*countMetric = map[CMetric]*countMetric{
 	RRDropMaxRetry:   {"RR_Drop_MaxRetry", "Records dropped in replication queue due to max retry failures", nil, &rrDropMaxRetryCounterOnce, nil, nil},
 	RRDropQueueFull:  {"RR_Drop_QueueFull", "Records dropped in replication queue due to queue is full", nil, &rrDropQueueFullCounterOnce, nil, nil},
 	RRDropRecExpired: {"RR_Drop_RecExpired", "Records dropped in replication queue due to expiry of records", nil, &rrDropRecExpiredCounterOnce, nil, nil},
+	RRSpill:          {"RR_Spill", "Records kept in the replication spill log instead of being dropped", nil, &rrSpillCounterOnce, nil, nil},
+	RRAlarm:          {"RR_Alarm", "Replication target alarms raised on lag, queue size or no success", nil, &rrAlarmCounterOnce, nil, nil},
 	SSL_CLIENT_INFO:  {"SSL_CLIENT_INFO", "Client app Info", nil, &sslClientInfoOnce, nil, nil},
 	CLIENT_INFO:      {"CLIENT_INFO", "Client app Info", nil, &clientInfoOnce, nil, nil},
 	Accept:           {"accept", "Accepting incoming connections", nil, &acceptCounterOnce, nil, nil},
@@ -167,6 +167,22 @@ func (op *OperationalMessage) decodeMetaField(tag byte, szField uint8, raw []byt
 		if err = op.udfName.decode(szField, raw, copyData); err != nil {
 			return
 		}
+	case kFieldTagOriginClusterID:
+		if err = op.originClusterId.decode(raw); err != nil {
+			return
+		}
+	case kFieldTagReadVersion:
+		if err = op.readVersion.decode(raw); err != nil {
+			return
+		}
+	case kFieldTagAsOfTime:
+		if err = op.asOfTime.decode(raw); err != nil {
+			return
+		}
+	case kFieldTagValueSize:
+		if err = op.valueSize.decode(raw); err != nil {
+			return
+		}
 	default:
 
 	}
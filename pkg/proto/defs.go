@@ -68,7 +68,10 @@ const (
 	OpCodeDestroy     = OpCode(5)
 	OpCodeUDFGet      = OpCode(6)
 	OpCodeUDFSet      = OpCode(7)
-	OpCodeLastProxyOp = OpCode(8) // add proxy op before this
+	OpCodeExists      = OpCode(8)
+	OpCodeGetMeta     = OpCode(9)
+	OpCodeTouch       = OpCode(10)
+	OpCodeLastProxyOp = OpCode(11) // add proxy op before this
 
 	OpCodePrepareCreate = OpCode(0x81)
 	OpCodeRead          = OpCode(0x82)
@@ -76,6 +79,9 @@ const (
 	OpCodePrepareSet    = OpCode(0x84)
 	OpCodePrepareDelete = OpCode(0x85)
 	OpCodeDelete        = OpCode(0x86)
+	OpCodeReadExists    = OpCode(0x87)
+	OpCodeReadMeta      = OpCode(0x88)
+	OpCodeSetTTL        = OpCode(0x89)
 
 	OpCodeCommit     = OpCode(0xC1)
 	OpCodeAbort      = OpCode(0xC2)
@@ -132,6 +138,9 @@ var (
 		OpCodeDestroy: "Destroy",
 		OpCodeUDFGet:  "UDFGet",
 		OpCodeUDFSet:  "UDFSet",
+		OpCodeExists:  "Exists",
+		OpCodeGetMeta: "GetMeta",
+		OpCodeTouch:   "Touch",
 
 		OpCodePrepareCreate: "PrepareCreate",
 		OpCodeRead:          "Read",
@@ -139,6 +148,9 @@ var (
 		OpCodePrepareSet:    "PrepareSet",
 		OpCodePrepareDelete: "PrepareDelete",
 		OpCodeDelete:        "Delete",
+		OpCodeReadExists:    "ReadExists",
+		OpCodeReadMeta:      "ReadMeta",
+		OpCodeSetTTL:        "SetTTL",
 		OpCodeCommit:        "Commit",
 		OpCodeAbort:         "Abort",
 		OpCodeRepair:        "Repair",
@@ -159,6 +171,9 @@ var (
 		OpCodeDestroy: "D",
 		OpCodeUDFGet:  "UG",
 		OpCodeUDFSet:  "US",
+		OpCodeExists:  "E",
+		OpCodeGetMeta: "GM",
+		OpCodeTouch:   "T",
 
 		OpCodePrepareCreate: "P",
 		OpCodeRead:          "R",
@@ -166,6 +181,9 @@ var (
 		OpCodePrepareSet:    "P",
 		OpCodePrepareDelete: "P",
 		OpCodeDelete:        "D",
+		OpCodeReadExists:    "RE",
+		OpCodeReadMeta:      "RM",
+		OpCodeSetTTL:        "ST",
 		OpCodeMarkDelete:    "MD",
 		OpCodeCommit:        "C",
 		OpCodeAbort:         "A",
@@ -252,7 +270,7 @@ func (s OpStatus) ShortNameString() string {
 func (op OpCode) IsForStorage() bool {
 	switch op {
 	case OpCodePrepareCreate, OpCodeRead, OpCodePrepareUpdate, OpCodePrepareSet, OpCodePrepareDelete,
-		OpCodeDelete,
+		OpCodeDelete, OpCodeReadExists, OpCodeReadMeta, OpCodeSetTTL,
 		OpCodeCommit, OpCodeAbort, OpCodeRepair, OpCodeClone, OpCodeVerHandshake, OpCodeMarkDelete,
 		OpCodeMockSetParam, OpCodeMockReSet:
 		return true
@@ -106,6 +106,27 @@ func (m *OperationalMessage) getInfoForMetaHeader(tagAndSizeTypes []uint8) (numF
 		numFields++
 	}
 
+	if m.originClusterId.isSet() {
+		tagAndSizeTypes[numFields] = m.originClusterId.tagAndSizeTypeByte()
+		totalSize += m.originClusterId.size()
+		numFields++
+	}
+	if m.readVersion.isSet() {
+		tagAndSizeTypes[numFields] = m.readVersion.tagAndSizeTypeByte()
+		totalSize += m.readVersion.size()
+		numFields++
+	}
+	if m.asOfTime.isSet() {
+		tagAndSizeTypes[numFields] = m.asOfTime.tagAndSizeTypeByte()
+		totalSize += m.asOfTime.size()
+		numFields++
+	}
+	if m.valueSize.isSet() {
+		tagAndSizeTypes[numFields] = m.valueSize.tagAndSizeTypeByte()
+		totalSize += m.valueSize.size()
+		numFields++
+	}
+
 	return
 }
 
@@ -187,6 +208,30 @@ func (m *OperationalMessage) encodeMetaFields(szComp int, buf []byte) (err error
 		}
 		off += fsz
 	}
+	if m.originClusterId.isSet() {
+		if fsz, err = m.originClusterId.encode(buf[off:]); err != nil {
+			return
+		}
+		off += fsz
+	}
+	if m.readVersion.isSet() {
+		if fsz, err = m.readVersion.encode(buf[off:]); err != nil {
+			return
+		}
+		off += fsz
+	}
+	if m.asOfTime.isSet() {
+		if fsz, err = m.asOfTime.encode(buf[off:]); err != nil {
+			return
+		}
+		off += fsz
+	}
+	if m.valueSize.isSet() {
+		if fsz, err = m.valueSize.encode(buf[off:]); err != nil {
+			return
+		}
+		off += fsz
+	}
 
 	for ; off < szComp; off++ {
 		buf[off] = 0
@@ -35,6 +35,10 @@
 	    0x09 | Correlation ID                       | 0
 	    0x0a | RequestHandlingTime                  | 0x01
 		0x0b | UDF Name			                    | 0
+	    0x0c | Origin Cluster ID                    | 0x01
+	    0x0d | Read Version                         | 0x01
+	    0x0e | Read As Of Time (nano second)        | 0x02
+	    0x0f | Value Size                           | 0x01
 	  -------+--------------------------------------+------
 
 
@@ -81,6 +85,10 @@ const (
 	kFieldTagCorrelationID
 	kFieldTagRequestHandlingTime
 	kFieldTagUDFName
+	kFieldTagOriginClusterID
+	kFieldTagReadVersion
+	kFieldTagAsOfTime
+	kFieldTagValueSize
 	kNumSupportedFields
 )
 
@@ -107,7 +115,11 @@ type (
 	creationTimeT         struct{ uint32T }
 	expirationTimeT       struct{ uint32T }
 	requestHandlingTimeT  struct{ uint32T }
+	originClusterIdT      struct{ uint32T }
+	readVersionT          struct{ uint32T }
+	valueSizeT            struct{ uint32T }
 	lastModificationTimeT struct{ uint64T }
+	asOfTimeT             struct{ uint64T }
 	requestIdT            struct{ requestIdBaseT }
 	originatorT           struct{ requestIdBaseT }
 
@@ -173,6 +185,18 @@ func (t requestHandlingTimeT) tagAndSizeTypeByte() uint8 {
 	return kFieldTagRequestHandlingTime | kMetaField_4Bytes
 }
 
+func (t originClusterIdT) tagAndSizeTypeByte() uint8 {
+	return kFieldTagOriginClusterID | kMetaField_4Bytes
+}
+
+func (t readVersionT) tagAndSizeTypeByte() uint8 {
+	return kFieldTagReadVersion | kMetaField_4Bytes
+}
+
+func (t valueSizeT) tagAndSizeTypeByte() uint8 {
+	return kFieldTagValueSize | kMetaField_4Bytes
+}
+
 // uint64 meta field
 func (t uint64T) isSet() bool {
 	return t != 0
@@ -212,6 +236,10 @@ func (t lastModificationTimeT) tagAndSizeTypeByte() uint8 {
 	return kFieldTagLastModificationTime | kMetaField_8Bytes
 }
 
+func (t asOfTimeT) tagAndSizeTypeByte() uint8 {
+	return kFieldTagAsOfTime | kMetaField_8Bytes
+}
+
 // 16-byte meta field
 func (t *requestIdBaseT) value() []byte {
 	return t.Bytes()
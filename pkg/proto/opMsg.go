@@ -52,6 +52,10 @@ type OperationalMessage struct {
 	correlationID        correlationIdT
 	requestHandlingTime  requestHandlingTimeT
 	udfName              udfNameT
+	originClusterId      originClusterIdT
+	readVersion          readVersionT
+	asOfTime             asOfTimeT
+	valueSize            valueSizeT
 }
 
 func (op *OperationalMessage) SetMessage(opcode OpCode, key []byte, namespace []byte, payload *Payload, ttl uint32) {
@@ -374,6 +378,56 @@ func (m *OperationalMessage) IsUDFNameSet() bool {
 	return m.udfName.isSet()
 }
 
+// SetOriginClusterId sets the id of the cluster where the write was first
+// accepted. It is used for active-active replication. 0 means not set.
+func (m *OperationalMessage) SetOriginClusterId(id uint32) {
+	m.originClusterId.set(id)
+}
+
+func (m *OperationalMessage) GetOriginClusterId() uint32 {
+	return m.originClusterId.value()
+}
+
+func (m *OperationalMessage) IsOriginClusterIdSet() bool {
+	return m.originClusterId.isSet()
+}
+
+// SetReadVersion makes a read return the given version of the record, from
+// the history kept by the storage server.
+func (m *OperationalMessage) SetReadVersion(v uint32) {
+	m.readVersion.set(v)
+}
+
+func (m *OperationalMessage) GetReadVersion() uint32 {
+	return m.readVersion.value()
+}
+
+// SetAsOfTime makes a read return the version of the record current at the
+// given time, in nanoseconds since epoch.
+func (m *OperationalMessage) SetAsOfTime(t uint64) {
+	m.asOfTime.set(t)
+}
+
+func (m *OperationalMessage) GetAsOfTime() uint64 {
+	return m.asOfTime.value()
+}
+
+// IsHistoryRead returns true if the read is for an earlier version of the
+// record.
+func (m *OperationalMessage) IsHistoryRead() bool {
+	return m.readVersion.isSet() || m.asOfTime.isSet()
+}
+
+// SetValueSize sets the size of the value as stored, in the response of a
+// meta data read.
+func (m *OperationalMessage) SetValueSize(sz uint32) {
+	m.valueSize.set(sz)
+}
+
+func (m *OperationalMessage) GetValueSize() uint32 {
+	return m.valueSize.value()
+}
+
 func (m *OperationalMessage) PrettyPrint(w io.Writer) {
 	fmt.Fprintf(w, "OPaque        : %#v\n", m.opaque)
 	fmt.Fprintf(w, "OpCode        : %#v\t%s\n", m.opCode, m.opCode.String())
@@ -419,4 +473,16 @@ func (m *OperationalMessage) PrettyPrint(w io.Writer) {
 	if m.udfName.isSet() {
 		fmt.Fprintf(w, "UDF Name      : %s\n", string(m.udfName.value()))
 	}
+	if m.originClusterId.isSet() {
+		fmt.Fprintf(w, "Origin Cluster : %d\n", m.originClusterId.value())
+	}
+	if m.readVersion.isSet() {
+		fmt.Fprintf(w, "Read Version   : %d\n", m.readVersion.value())
+	}
+	if m.asOfTime.isSet() {
+		fmt.Fprintf(w, "As Of Time     : %d\n", m.asOfTime.value())
+	}
+	if m.valueSize.isSet() {
+		fmt.Fprintf(w, "Value Size     : %d\n", m.valueSize.value())
+	}
 }
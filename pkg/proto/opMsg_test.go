@@ -322,3 +322,64 @@ func TestUDF(t *testing.T) {
 	fmt.Print("test udf set\n")
 	testUDFRequestResponse(t, OpCodeUDFSet, []byte("key2"), []byte("sc"), param)
 }
+
+func TestOriginClusterId(t *testing.T) {
+	request := &OperationalMessage{}
+	request.SetRequest(OpCodeSet, []byte("key"), []byte("testns"), nil, 10)
+	request.SetNewRequestID()
+	request.SetAsReplication()
+	request.SetLastModificationTime(1)
+	request.SetOriginClusterId(2)
+
+	var raw RawMessage
+	if err := request.Encode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var r OperationalMessage
+	if err := r.Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsOriginClusterIdSet() || r.GetOriginClusterId() != 2 || r.GetLastModificationTime() != 1 {
+		t.Errorf("origin cluster id not decoded: %d", r.GetOriginClusterId())
+	}
+}
+
+func TestHistoryRead(t *testing.T) {
+	request := &OperationalMessage{}
+	request.SetRequest(OpCodeGet, []byte("key"), []byte("testns"), nil, 0)
+	request.SetNewRequestID()
+	request.SetReadVersion(3)
+	request.SetAsOfTime(1700000000000000000)
+
+	var raw RawMessage
+	if err := request.Encode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var r OperationalMessage
+	if err := r.Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsHistoryRead() || r.GetReadVersion() != 3 || r.GetAsOfTime() != 1700000000000000000 {
+		t.Errorf("history read fields not decoded: %d %d", r.GetReadVersion(), r.GetAsOfTime())
+	}
+}
+
+func TestValueSize(t *testing.T) {
+	response := &OperationalMessage{}
+	response.SetRequest(OpCodeGetMeta, []byte("key"), []byte("testns"), nil, 0)
+	response.SetAsResponse()
+	response.SetNewRequestID()
+	response.SetValueSize(2048)
+
+	var raw RawMessage
+	if err := response.Encode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var r OperationalMessage
+	if err := r.Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if r.GetOpCode() != OpCodeGetMeta || r.GetValueSize() != 2048 {
+		t.Errorf("value size not decoded: %s %d", r.GetOpCode(), r.GetValueSize())
+	}
+}
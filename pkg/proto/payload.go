@@ -106,6 +106,25 @@ func (p *Payload) SetWithClearValue(value []byte) {
 	p.data = value
 }
 
+// SetWithCompressedValue compresses the value on behalf of the client. The data
+// is prefixed with the length and the name of the compression type.
+func (p *Payload) SetWithCompressedValue(compType string, value []byte) (err error) {
+	if len(value) == 0 {
+		p.SetWithClearValue(value)
+		return
+	}
+	if compType != SnappyCompression {
+		return ErrUnsupportedCompressionType
+	}
+	encoded := snappy.Encode(nil, value)
+	data := make([]byte, 1+len(compType)+len(encoded))
+	data[0] = byte(len(compType))
+	copy(data[1:], compType)
+	copy(data[1+len(compType):], encoded)
+	p.SetPayload(PayloadTypecompressedByClient, data)
+	return
+}
+
 // /TODO
 func (p *Payload) GetClearValue() (value []byte, err error) {
 	if p.GetLength() == 0 {
@@ -222,6 +222,9 @@ func newGoTlsContext(server bool, certPEMBlock []byte, keyPEMBlock []byte, ks pr
 			ClientCAs:          clientCAs,
 			Certificates:       []tls.Certificate{cert},
 			InsecureSkipVerify: true,
+			// for the HTTP and gRPC listeners. HTTP/2 is only picked by the
+			// clients not offering HTTP/1.1, e.g. the gRPC clients.
+			NextProtos: []string{"http/1.1", "h2"},
 		}
 		tlscfg.SetSessionTicketKeys([][32]byte{key})
 		ticker := time.NewTicker(time.Hour)
@@ -48,6 +48,7 @@ func init() {
 	needToRecordTTL[proto.OpCodeUpdate] = true
 	needToRecordTTL[proto.OpCodeSet] = true
 	needToRecordTTL[proto.OpCodeUDFGet] = true
+	needToRecordTTL[proto.OpCodeTouch] = true
 }
 
 type (
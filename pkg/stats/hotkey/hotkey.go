@@ -0,0 +1,410 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+// Package hotkey tracks the keys and the namespaces receiving the most reads,
+// writes and bytes, with a count-min sketch and a top-K heap per metric. The
+// keys are spread by hash over shards of their own sketches, heaps and lock,
+// so that the requests of different keys rarely wait on each other. The
+// counts are halved every DecayInterval, so that the report reflects the
+// recent traffic.
+package hotkey
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+const (
+	kReads = iota
+	kWrites
+	kBytes
+	kNumMetrics
+)
+
+const (
+	kNumShardBits = 4
+	kNumShards    = 1 << kNumShardBits
+
+	// the width of the sketch of a shard, which counts 1/kNumShards of the keys
+	kSketchWidth   = 4096 / kNumShards
+	kSketchDepth   = 4
+	kMaxKeyLength  = 64   // longer keys are truncated in the report
+	kMaxNamespaces = 1024 // the namespaces beyond are counted under kOtherNamespace
+
+	kOtherNamespace = "(other)"
+)
+
+type (
+	Config struct {
+		Enabled       bool
+		TopK          int           // number of keys tracked per metric
+		DecayInterval util.Duration // the counts are halved every interval
+	}
+
+	// Tracker is safe for concurrent use.
+	Tracker struct {
+		interval time.Duration
+		k        int
+		shards   [kNumShards]trackerShard
+	}
+
+	// trackerShard counts the keys whose hash falls in the shard, and the
+	// namespaces of their requests.
+	trackerShard struct {
+		mtx        sync.Mutex
+		lastDecay  time.Time
+		sketches   [kNumMetrics]sketch
+		tops       [kNumMetrics]topK
+		namespaces map[string]*[kNumMetrics]uint64
+	}
+
+	sketch []uint64
+
+	item struct {
+		hash  uint64
+		ns    string
+		key   string
+		count uint64
+		index int
+	}
+
+	// topK is a min-heap of the k items with the highest counts of a metric.
+	topK struct {
+		k      int
+		items  []*item
+		byHash map[uint64]*item
+	}
+)
+
+var (
+	DefaultConfig = Config{
+		Enabled:       false,
+		TopK:          20,
+		DecayInterval: util.Duration{Duration: time.Minute},
+	}
+
+	tracker *Tracker
+)
+
+func (c *Config) Validate() (err error) {
+	if !c.Enabled {
+		return
+	}
+	if c.TopK <= 0 {
+		err = fmt.Errorf("HotKeys.TopK %d not positive", c.TopK)
+	} else if c.DecayInterval.Duration <= 0 {
+		err = fmt.Errorf("HotKeys.DecayInterval %s not positive", c.DecayInterval.Duration)
+	}
+	return
+}
+
+// Initialize sets up the tracker of the process if enabled. It is to be
+// called before any request is processed.
+func Initialize(conf *Config) {
+	if conf.Enabled {
+		tracker = NewTracker(conf)
+	}
+}
+
+// Enabled returns whether the tracker of the process has been set up.
+func Enabled() bool {
+	return tracker != nil
+}
+
+// Record counts a request to the tracker of the process, if any.
+func Record(ns []byte, key []byte, op proto.OpCode, size int) {
+	if t := tracker; t != nil {
+		t.Record(ns, key, op, size)
+	}
+}
+
+// GetReport returns the report of the tracker of the process, nil if not
+// enabled.
+func GetReport() *Report {
+	if t := tracker; t != nil {
+		return t.Report()
+	}
+	return nil
+}
+
+func NewTracker(conf *Config) *Tracker {
+	t := &Tracker{
+		interval: conf.DecayInterval.Duration,
+		k:        conf.TopK,
+	}
+	now := time.Now()
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.lastDecay = now
+		s.namespaces = make(map[string]*[kNumMetrics]uint64)
+		for m := 0; m < kNumMetrics; m++ {
+			s.sketches[m] = make(sketch, kSketchDepth*kSketchWidth)
+			s.tops[m] = topK{k: conf.TopK, byHash: make(map[uint64]*item, conf.TopK+1)}
+		}
+	}
+	return t
+}
+
+// metricOf returns the metric counting the requests of the opcode, -1 if
+// not counted. The commits and the aborts are the second phase of a write
+// already counted with its prepare.
+func metricOf(op proto.OpCode) int {
+	switch op {
+	case proto.OpCodeGet, proto.OpCodeUDFGet, proto.OpCodeExists, proto.OpCodeGetMeta,
+		proto.OpCodeRead, proto.OpCodeReadExists, proto.OpCodeReadMeta:
+		return kReads
+	case proto.OpCodeCreate, proto.OpCodeUpdate, proto.OpCodeSet, proto.OpCodeDestroy, proto.OpCodeUDFSet, proto.OpCodeTouch,
+		proto.OpCodePrepareCreate, proto.OpCodePrepareUpdate, proto.OpCodePrepareSet, proto.OpCodePrepareDelete,
+		proto.OpCodeDelete, proto.OpCodeSetTTL, proto.OpCodeRepair, proto.OpCodeMarkDelete:
+		return kWrites
+	}
+	return -1
+}
+
+func keyHash(ns []byte, key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(ns)
+	h.Write([]byte{0})
+	h.Write(key)
+	return h.Sum64()
+}
+
+// Record counts a request of the opcode on the key, with size the number of
+// the value bytes of the request and the response.
+func (t *Tracker) Record(ns []byte, key []byte, op proto.OpCode, size int) {
+	m := metricOf(op)
+	if m < 0 || len(key) == 0 {
+		return
+	}
+	h := keyHash(ns, key)
+	now := time.Now()
+
+	s := &t.shards[h>>(64-kNumShardBits)]
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if now.Sub(s.lastDecay) >= t.interval {
+		s.decay()
+		s.lastDecay = now
+	}
+	s.tops[m].update(h, s.sketches[m].add(h, 1), ns, key)
+	if size > 0 {
+		s.tops[kBytes].update(h, s.sketches[kBytes].add(h, uint64(size)), ns, key)
+	}
+
+	nsStats, ok := s.namespaces[string(ns)]
+	if !ok {
+		name := string(ns)
+		if len(s.namespaces) >= kMaxNamespaces {
+			name = kOtherNamespace
+		}
+		if nsStats, ok = s.namespaces[name]; !ok {
+			nsStats = &[kNumMetrics]uint64{}
+			s.namespaces[name] = nsStats
+		}
+	}
+	nsStats[m]++
+	if size > 0 {
+		nsStats[kBytes] += uint64(size)
+	}
+}
+
+// decay halves the counts of all the shards.
+func (t *Tracker) decay() {
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mtx.Lock()
+		s.decay()
+		s.mtx.Unlock()
+	}
+}
+
+func (s *trackerShard) decay() {
+	for i := 0; i < kNumMetrics; i++ {
+		s.sketches[i].halve()
+		s.tops[i].halve()
+	}
+	for ns, st := range s.namespaces {
+		for i := 0; i < kNumMetrics; i++ {
+			st[i] >>= 1
+		}
+		if st[kReads] == 0 && st[kWrites] == 0 && st[kBytes] == 0 {
+			delete(s.namespaces, ns)
+		}
+	}
+}
+
+// Report returns the tracked keys of each metric, with the estimated counts
+// of all the metrics, and the counts of the namespaces.
+func (t *Tracker) Report() *Report {
+	r := &Report{DecayInterval: t.interval.String()}
+	entries := make(map[uint64]*Entry)
+	namespaces := make(map[string]*NamespaceEntry)
+	for i := range t.shards {
+		s := &t.shards[i]
+		s.mtx.Lock()
+		s.addEntries(entries)
+		for ns, st := range s.namespaces {
+			e, ok := namespaces[ns]
+			if !ok {
+				e = &NamespaceEntry{Namespace: ns}
+				namespaces[ns] = e
+			}
+			e.Reads += st[kReads]
+			e.Writes += st[kWrites]
+			e.Bytes += st[kBytes]
+		}
+		s.mtx.Unlock()
+	}
+	r.setTopKeys(entries, t.k)
+	for _, e := range namespaces {
+		r.Namespaces = append(r.Namespaces, *e)
+	}
+	r.sortNamespaces()
+	return r
+}
+
+// addEntries adds the keys tracked by the shard, with the estimated counts of
+// all the metrics.
+func (s *trackerShard) addEntries(entries map[uint64]*Entry) {
+	for m := 0; m < kNumMetrics; m++ {
+		for _, it := range s.tops[m].items {
+			if _, ok := entries[it.hash]; ok {
+				continue
+			}
+			entries[it.hash] = &Entry{
+				Hash:      fmt.Sprintf("%016x", it.hash),
+				Namespace: it.ns,
+				Key:       it.key,
+				Reads:     s.sketches[kReads].estimate(it.hash),
+				Writes:    s.sketches[kWrites].estimate(it.hash),
+				Bytes:     s.sketches[kBytes].estimate(it.hash),
+			}
+		}
+	}
+}
+
+func (s sketch) add(h uint64, n uint64) (count uint64) {
+	h1, h2 := uint32(h), uint32(h>>32)|1
+	for i := uint32(0); i < kSketchDepth; i++ {
+		c := &s[i*kSketchWidth+(h1+i*h2)%kSketchWidth]
+		*c += n
+		if i == 0 || *c < count {
+			count = *c
+		}
+	}
+	return
+}
+
+func (s sketch) estimate(h uint64) (count uint64) {
+	h1, h2 := uint32(h), uint32(h>>32)|1
+	for i := uint32(0); i < kSketchDepth; i++ {
+		c := s[i*kSketchWidth+(h1+i*h2)%kSketchWidth]
+		if i == 0 || c < count {
+			count = c
+		}
+	}
+	return
+}
+
+func (s sketch) halve() {
+	for i := range s {
+		s[i] >>= 1
+	}
+}
+
+func (q *topK) Len() int           { return len(q.items) }
+func (q *topK) Less(i, j int) bool { return q.items[i].count < q.items[j].count }
+func (q *topK) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+func (q *topK) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(q.items)
+	q.items = append(q.items, it)
+}
+func (q *topK) Pop() interface{} {
+	n := len(q.items)
+	it := q.items[n-1]
+	q.items = q.items[:n-1]
+	return it
+}
+
+// update sets the count of the key, and adds the key if its count is higher
+// than the lowest one tracked.
+func (q *topK) update(h uint64, count uint64, ns []byte, key []byte) {
+	if it, ok := q.byHash[h]; ok {
+		it.count = count
+		heap.Fix(q, it.index)
+		return
+	}
+	if len(q.items) < q.k {
+		it := &item{hash: h, count: count, ns: string(ns), key: printableKey(key)}
+		q.byHash[h] = it
+		heap.Push(q, it)
+	} else if len(q.items) != 0 && count > q.items[0].count {
+		it := q.items[0]
+		delete(q.byHash, it.hash)
+		*it = item{hash: h, count: count, ns: string(ns), key: printableKey(key)}
+		q.byHash[h] = it
+		heap.Fix(q, 0)
+	}
+}
+
+func (q *topK) halve() {
+	items := q.items[:0]
+	for _, it := range q.items {
+		it.count >>= 1
+		if it.count == 0 {
+			delete(q.byHash, it.hash)
+		} else {
+			items = append(items, it)
+		}
+	}
+	q.items = items
+	for i, it := range q.items {
+		it.index = i
+	}
+	heap.Init(q)
+}
+
+func printableKey(key []byte) string {
+	if len(key) > kMaxKeyLength {
+		return util.ToPrintableString(key[:kMaxKeyLength]) + "..."
+	}
+	return util.ToPrintableString(key)
+}
+
+func sortEntries(entries []Entry, value func(e *Entry) uint64) {
+	sort.Slice(entries, func(i, j int) bool {
+		vi, vj := value(&entries[i]), value(&entries[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return entries[i].Hash < entries[j].Hash
+	})
+}
@@ -0,0 +1,112 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package hotkey
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paypal/junodb/pkg/proto"
+	"github.com/paypal/junodb/pkg/util"
+)
+
+func newTestTracker(k int) *Tracker {
+	return NewTracker(&Config{Enabled: true, TopK: k, DecayInterval: util.Duration{Duration: time.Hour}})
+}
+
+func TestTrackerTopKeys(t *testing.T) {
+	tr := newTestTracker(5)
+	ns := []byte("ns")
+	for i := 0; i < 1000; i++ {
+		tr.Record(ns, []byte("hot-read"), proto.OpCodeGet, 10)
+		if i%2 == 0 {
+			tr.Record(ns, []byte("hot-write"), proto.OpCodeSet, 1000)
+		}
+		tr.Record(ns, []byte(fmt.Sprintf("cold-%d", i)), proto.OpCodePrepareUpdate, 1)
+		tr.Record(ns, []byte("committed"), proto.OpCodeCommit, 0)
+	}
+	r := tr.Report()
+	if len(r.Reads) != 1 || r.Reads[0].Key != "hot-read" || r.Reads[0].Reads < 1000 {
+		t.Errorf("unexpected top reads %+v", r.Reads)
+	}
+	if len(r.Writes) != 5 || r.Writes[0].Key != "hot-write" || r.Writes[0].Writes < 500 {
+		t.Errorf("unexpected top writes %+v", r.Writes)
+	}
+	if len(r.Bytes) != 5 || r.Bytes[0].Key != "hot-write" || r.Bytes[1].Key != "hot-read" {
+		t.Errorf("unexpected top bytes %+v", r.Bytes)
+	}
+	if len(r.Namespaces) != 1 || r.Namespaces[0].Reads != 1000 || r.Namespaces[0].Writes != 1500 {
+		t.Errorf("unexpected namespaces %+v", r.Namespaces)
+	}
+
+	tr.decay()
+	r = tr.Report()
+	if r.Reads[0].Reads < 500 || r.Reads[0].Reads >= 1000 || r.Namespaces[0].Reads != 500 {
+		t.Errorf("counts not halved %+v", r)
+	}
+}
+
+func TestTrackerConcurrentRecords(t *testing.T) {
+	tr := newTestTracker(3)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tr.Record([]byte("ns"), []byte(fmt.Sprintf("k%d-%d", g, i%50)), proto.OpCodeGet, 0)
+				tr.Record([]byte("ns"), []byte("hot"), proto.OpCodeGet, 0)
+			}
+		}(g)
+	}
+	wg.Wait()
+	r := tr.Report()
+	if len(r.Reads) != 3 || r.Reads[0].Key != "hot" || r.Reads[0].Reads < 8000 {
+		t.Errorf("unexpected top reads %+v", r.Reads)
+	}
+	if len(r.Namespaces) != 1 || r.Namespaces[0].Reads != 16000 {
+		t.Errorf("unexpected namespaces %+v", r.Namespaces)
+	}
+}
+
+func TestMergeAndFilter(t *testing.T) {
+	var reports []*Report
+	for i := 0; i < 2; i++ {
+		tr := newTestTracker(3)
+		for j := 0; j < 10; j++ {
+			tr.Record([]byte("a"), []byte("k1"), proto.OpCodeGet, 0)
+			tr.Record([]byte("b"), []byte(fmt.Sprintf("k%d", i+2)), proto.OpCodeGet, 0)
+		}
+		reports = append(reports, tr.Report())
+	}
+	r := Merge(reports, 2)
+	if len(r.Reads) != 2 || r.Reads[0].Key != "k1" || r.Reads[0].Reads < 20 {
+		t.Errorf("unexpected merged reads %+v", r.Reads)
+	}
+	if len(r.Namespaces) != 2 || r.Namespaces[0].Reads != 20 || r.Namespaces[1].Reads != 20 {
+		t.Errorf("unexpected merged namespaces %+v", r.Namespaces)
+	}
+	r.Filter("b", 1)
+	if len(r.Reads) != 1 || r.Reads[0].Namespace != "b" || len(r.Namespaces) != 1 {
+		t.Errorf("unexpected filtered report %+v", r)
+	}
+}
@@ -0,0 +1,221 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package hotkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+type (
+	// Entry is a tracked key, with the estimated counts, which may be higher
+	// than the actual ones.
+	Entry struct {
+		Hash      string // FNV-1a of the namespace and the key
+		Namespace string
+		Key       string // printable, truncated
+		Reads     uint64
+		Writes    uint64
+		Bytes     uint64
+	}
+
+	NamespaceEntry struct {
+		Namespace string
+		Reads     uint64
+		Writes    uint64
+		Bytes     uint64
+	}
+
+	// Report is served as JSON on /hotkeys.
+	Report struct {
+		DecayInterval string
+		Reads         []Entry // by reads
+		Writes        []Entry // by writes
+		Bytes         []Entry // by bytes
+		Namespaces    []NamespaceEntry
+	}
+)
+
+// Merge sums the counts of the reports of the workers, and keeps the k keys
+// with the highest counts of each metric.
+func Merge(reports []*Report, k int) *Report {
+	r := &Report{}
+	entries := make(map[uint64]*Entry)
+	namespaces := make(map[string]*NamespaceEntry)
+	for _, rep := range reports {
+		if len(r.DecayInterval) == 0 {
+			r.DecayInterval = rep.DecayInterval
+		}
+		seen := make(map[uint64]bool)
+		for _, list := range [][]Entry{rep.Reads, rep.Writes, rep.Bytes} {
+			for _, e := range list {
+				h, err := strconv.ParseUint(e.Hash, 16, 64)
+				if err != nil || seen[h] {
+					continue
+				}
+				seen[h] = true
+				if m, ok := entries[h]; ok {
+					m.Reads += e.Reads
+					m.Writes += e.Writes
+					m.Bytes += e.Bytes
+				} else {
+					m := e
+					entries[h] = &m
+				}
+			}
+		}
+		for _, e := range rep.Namespaces {
+			if m, ok := namespaces[e.Namespace]; ok {
+				m.Reads += e.Reads
+				m.Writes += e.Writes
+				m.Bytes += e.Bytes
+			} else {
+				m := e
+				namespaces[e.Namespace] = &m
+			}
+		}
+	}
+	r.setTopKeys(entries, k)
+	for _, e := range namespaces {
+		r.Namespaces = append(r.Namespaces, *e)
+	}
+	r.sortNamespaces()
+	return r
+}
+
+func (r *Report) setTopKeys(entries map[uint64]*Entry, k int) {
+	top := func(value func(e *Entry) uint64) (list []Entry) {
+		for _, e := range entries {
+			if value(e) != 0 {
+				list = append(list, *e)
+			}
+		}
+		sortEntries(list, value)
+		if len(list) > k {
+			list = list[:k]
+		}
+		return
+	}
+	r.Reads = top(func(e *Entry) uint64 { return e.Reads })
+	r.Writes = top(func(e *Entry) uint64 { return e.Writes })
+	r.Bytes = top(func(e *Entry) uint64 { return e.Bytes })
+}
+
+func (r *Report) sortNamespaces() {
+	sort.Slice(r.Namespaces, func(i, j int) bool {
+		a, b := &r.Namespaces[i], &r.Namespaces[j]
+		if a.Reads+a.Writes != b.Reads+b.Writes {
+			return a.Reads+a.Writes > b.Reads+b.Writes
+		}
+		return a.Namespace < b.Namespace
+	})
+}
+
+// Filter keeps the keys and the namespace of ns if not empty, and the first
+// n keys of each metric if n is positive.
+func (r *Report) Filter(ns string, n int) {
+	filter := func(list []Entry) []Entry {
+		if len(ns) != 0 {
+			kept := list[:0]
+			for _, e := range list {
+				if e.Namespace == ns {
+					kept = append(kept, e)
+				}
+			}
+			list = kept
+		}
+		if n > 0 && len(list) > n {
+			list = list[:n]
+		}
+		return list
+	}
+	r.Reads = filter(r.Reads)
+	r.Writes = filter(r.Writes)
+	r.Bytes = filter(r.Bytes)
+	if len(ns) != 0 {
+		kept := r.Namespaces[:0]
+		for _, e := range r.Namespaces {
+			if e.Namespace == ns {
+				kept = append(kept, e)
+			}
+		}
+		r.Namespaces = kept
+	}
+}
+
+func (r *Report) WriteText(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, t := range []struct {
+		metric string
+		list   []Entry
+	}{{"reads", r.Reads}, {"writes", r.Writes}, {"bytes", r.Bytes}} {
+		fmt.Fprintf(tw, "Top keys by %s, counts halved every %s\n", t.metric, r.DecayInterval)
+		fmt.Fprintln(tw, "  namespace\tkey\thash\treads\twrites\tbytes")
+		for _, e := range t.list {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%d\t%d\t%d\n", e.Namespace, e.Key, e.Hash, e.Reads, e.Writes, e.Bytes)
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintln(tw, "Namespaces")
+	fmt.Fprintln(tw, "  namespace\treads\twrites\tbytes")
+	for _, e := range r.Namespaces {
+		fmt.Fprintf(tw, "  %s\t%d\t%d\t%d\n", e.Namespace, e.Reads, e.Writes, e.Bytes)
+	}
+	tw.Flush()
+}
+
+// HttpHandler serves the report of the tracker of the process.
+func HttpHandler(w http.ResponseWriter, r *http.Request) {
+	rep := GetReport()
+	if rep == nil {
+		http.Error(w, "hot key tracking not enabled", http.StatusNotFound)
+		return
+	}
+	ServeReport(w, r, rep)
+}
+
+// ServeReport writes the report filtered by the "ns" and the "n" query
+// parameters, as text with "format=text" and as JSON otherwise.
+func ServeReport(w http.ResponseWriter, r *http.Request, rep *Report) {
+	query := r.URL.Query()
+	var n int
+	if s := query.Get("n"); len(s) != 0 {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n < 0 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+	}
+	rep.Filter(query.Get("ns"), n)
+	if query.Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rep.WriteText(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(rep)
+}
@@ -0,0 +1,172 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const kRotateTimeFmt = "20060102T150405.000000"
+
+// RotatingFile is a log file, <prefix>log, appended to. Once larger than
+// maxSize, it is renamed with the time of the rotation, e.g.
+// <prefix>20231018T150405.000000.log, and the oldest rotated files beyond
+// maxFiles are removed. A maxFiles of 0 keeps all of them.
+type RotatingFile struct {
+	dir      string
+	prefix   string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+}
+
+func NewRotatingFile(dir string, prefix string, maxSize int64, maxFiles int) *RotatingFile {
+	return &RotatingFile{
+		dir:      dir,
+		prefix:   prefix,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+}
+
+// Open creates the directory and the file if needed.
+func (f *RotatingFile) Open() (err error) {
+	if err = os.MkdirAll(f.dir, 0755); err != nil {
+		return
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) open() (err error) {
+	var file *os.File
+	if file, err = os.OpenFile(f.Name(), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644); err != nil {
+		return
+	}
+	var fi os.FileInfo
+	if fi, err = file.Stat(); err != nil {
+		file.Close()
+		return
+	}
+	f.file = file
+	f.size = fi.Size()
+	return
+}
+
+// Name returns the path of the current file.
+func (f *RotatingFile) Name() string {
+	return filepath.Join(f.dir, f.prefix+"log")
+}
+
+func (f *RotatingFile) File() *os.File {
+	return f.file
+}
+
+func (f *RotatingFile) Size() int64 {
+	return f.size
+}
+
+// RotatedFiles returns the rotated files, the oldest first.
+func (f *RotatingFile) RotatedFiles() []string {
+	files, _ := filepath.Glob(filepath.Join(f.dir, f.prefix+"*.log"))
+	kept := files[:0]
+	for _, name := range files {
+		if name != f.Name() {
+			kept = append(kept, name)
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
+
+// Write appends b to the file, after a rotation if the file is larger than
+// maxSize. The file is reopened if a previous rotation failed. If b is only
+// partly written, e.g. on a full disk, the file is truncated back to its size
+// before the write, or rotated if it cannot be, so that the next write does
+// not follow a torn fragment.
+func (f *RotatingFile) Write(b []byte) (n int, err error) {
+	if f.file == nil {
+		if err = f.open(); err != nil {
+			return
+		}
+	}
+	if f.size >= f.maxSize {
+		if err = f.Rotate(); err != nil {
+			return
+		}
+	}
+	size := f.size
+	if n, err = f.file.Write(b); err == nil {
+		f.size += int64(n)
+		return
+	}
+	if n != 0 {
+		if terr := f.file.Truncate(size); terr != nil {
+			f.size += int64(n)
+			f.Rotate()
+		}
+		n = 0
+	}
+	return
+}
+
+// Rotate renames the current file with the time, and opens a new one.
+func (f *RotatingFile) Rotate() (err error) {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	rotated := filepath.Join(f.dir, f.prefix+time.Now().UTC().Format(kRotateTimeFmt)+".log")
+	if err = os.Rename(f.Name(), rotated); err != nil && !os.IsNotExist(err) {
+		f.open()
+		return
+	}
+	if f.maxFiles > 0 {
+		files := f.RotatedFiles()
+		for i := 0; i < len(files)-f.maxFiles; i++ {
+			os.Remove(files[i])
+		}
+	}
+	return f.open()
+}
+
+// Truncate cuts the current file to size.
+func (f *RotatingFile) Truncate(size int64) (err error) {
+	if err = f.file.Truncate(size); err == nil {
+		f.size = size
+	}
+	return
+}
+
+func (f *RotatingFile) Sync() error {
+	return f.file.Sync()
+}
+
+func (f *RotatingFile) Close() (err error) {
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	return
+}
@@ -0,0 +1,54 @@
+//
+//  Copyright 2023 PayPal Inc.
+//
+//  Licensed to the Apache Software Foundation (ASF) under one or more
+//  contributor license agreements.  See the NOTICE file distributed with
+//  this work for additional information regarding copyright ownership.
+//  The ASF licenses this file to You under the Apache License, Version 2.0
+//  (the "License"); you may not use this file except in compliance with
+//  the License.  You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package util
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	f := NewRotatingFile(dir, "test.0.", 10, 2)
+	if err := f.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("0123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // rotated files named after the time
+	}
+	if rotated := f.RotatedFiles(); len(rotated) != 2 {
+		t.Errorf("%d rotated files kept, 2 expected: %v", len(rotated), rotated)
+	}
+	if data, err := os.ReadFile(f.Name()); err != nil || string(data) != "0123456789\n" {
+		t.Errorf("unexpected content %q of %s, err=%v", data, f.Name(), err)
+	}
+
+	f.Close()
+	reopened := NewRotatingFile(dir, "test.0.", 10, 2)
+	if err := reopened.Open(); err != nil || reopened.Size() != 11 {
+		t.Errorf("size %d after reopening, err=%v", reopened.Size(), err)
+	}
+	reopened.Close()
+}
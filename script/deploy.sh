@@ -76,6 +76,7 @@ cp $JUNO_BUILD_DIR/junocfg	junoclustercfg
 
 cp $JUNO_BUILD_DIR/storageserv  junostorageserv
 cp $JUNO_BUILD_DIR/dbcopy       junostorageserv
+cp $JUNO_BUILD_DIR/nsdump       junostorageserv
 cp $JUNO_BUILD_DIR/dbscanserv   junostorageserv
 
 cp -r $BUILDTOP/package_config/package/junoserv/secrets/	junoserv